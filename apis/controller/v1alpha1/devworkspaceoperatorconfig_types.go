@@ -16,6 +16,9 @@
 package v1alpha1
 
 import (
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -42,6 +45,140 @@ type RoutingConfig struct {
 	// On OpenShift, the DevWorkspace Operator will attempt to determine the appropriate
 	// value automatically. Must be specified on Kubernetes.
 	ClusterHostSuffix string `json:"clusterHostSuffix,omitempty"`
+	// SingleHostname is the hostname used to expose all DevWorkspace endpoints for
+	// the "single-host" routing class. Endpoints are exposed on the path
+	// /<namespace>/<devworkspace-name>/<endpoint-name>/ on this host. Must be
+	// specified to use the "single-host" routing class.
+	SingleHostname string `json:"singleHostname,omitempty"`
+	// IngressClassName is the name of the IngressClass used for Ingresses created
+	// for DevWorkspace endpoints. If specified, the "kubernetes.io/ingress.class"
+	// annotation is not set on Ingresses. Can be overridden for an endpoint using
+	// the attribute "controller.devfile.io/ingress-class-name".
+	IngressClassName string `json:"ingressClassName,omitempty"`
+	// AllowedEndpointIngressClasses lists the IngressClasses that can be selected for
+	// an endpoint using the attribute "controller.devfile.io/ingress-class-name".
+	// DevWorkspaces that select any other IngressClass fail to start. If not specified,
+	// endpoints can only select the IngressClass specified in IngressClassName.
+	AllowedEndpointIngressClasses []string `json:"allowedEndpointIngressClasses,omitempty"`
+	// Annotations are additional annotations applied to Ingresses, Routes, and
+	// Services created for DevWorkspace endpoints. Annotations specified here
+	// override annotations set by the DevWorkspace Operator, except for annotations
+	// with the "controller.devfile.io/" prefix. Additional annotations for the
+	// Ingress or Route of an endpoint can be specified using the attribute
+	// "controller.devfile.io/routing-annotations".
+	Annotations map[string]string `json:"annotations,omitempty"`
+	// AllowedEndpointAnnotations lists the annotation keys that can be set for an
+	// endpoint using the attribute "controller.devfile.io/routing-annotations".
+	// DevWorkspaces that set any other annotation fail to start. If not specified,
+	// endpoints cannot set annotations.
+	AllowedEndpointAnnotations []string `json:"allowedEndpointAnnotations,omitempty"`
+	// Labels are additional labels applied to Ingresses, Routes, and Services
+	// created for DevWorkspace endpoints. Labels with the "controller.devfile.io/"
+	// prefix are ignored. Additional labels for the Ingress or Route of an
+	// endpoint can be specified using the attribute "controller.devfile.io/routing-labels".
+	Labels map[string]string `json:"labels,omitempty"`
+	// Gateway configures the Gateway API Gateway that HTTPRoutes created for the
+	// "gateway" routing class are attached to. Must be specified to use the
+	// "gateway" routing class.
+	Gateway *GatewayConfig `json:"gateway,omitempty"`
+	// TLS configures TLS for Ingresses created for DevWorkspace endpoints on
+	// Kubernetes. If specified, secure endpoints are exposed using https URLs.
+	// On OpenShift, Routes are always secured using the cluster's certificate
+	// and this field is ignored.
+	TLS *RoutingTLSConfig `json:"tls,omitempty"`
+	// OIDC configures the OpenID Connect provider used to authenticate requests
+	// to DevWorkspace endpoints for the "oidc" routing class. Must be specified
+	// to use the "oidc" routing class.
+	OIDC *OIDCConfig `json:"oidc,omitempty"`
+	// NetworkPolicy configures NetworkPolicies that restrict network access to
+	// DevWorkspace pods.
+	NetworkPolicy *NetworkPolicyConfig `json:"networkPolicy,omitempty"`
+	// NonHTTPEndpoints configures how public endpoints that use the "tcp" or "udp"
+	// protocol are exposed outside the cluster.
+	NonHTTPEndpoints *NonHTTPEndpointsConfig `json:"nonHTTPEndpoints,omitempty"`
+}
+
+type NonHTTPEndpointsConfig struct {
+	// ServiceType is the type of the Service used to expose public endpoints that
+	// use the "tcp" or "udp" protocol. Defaults to "LoadBalancer".
+	// +kubebuilder:validation:Enum=LoadBalancer;NodePort
+	ServiceType corev1.ServiceType `json:"serviceType,omitempty"`
+	// NodePortHost is the hostname or IP address reported in the URL of endpoints
+	// exposed through a NodePort Service, e.g. the address of a cluster node or an
+	// external load balancer forwarding to cluster nodes. Must be specified if
+	// ServiceType is "NodePort".
+	NodePortHost string `json:"nodePortHost,omitempty"`
+}
+
+type NetworkPolicyConfig struct {
+	// Enabled controls whether a NetworkPolicy is created for each DevWorkspace. The
+	// NetworkPolicy only allows traffic to a DevWorkspace's pods from the DevWorkspace
+	// itself, from the ingress controller or router to public endpoints, from pods in
+	// the same namespace to discoverable endpoints, and from the sources specified in
+	// AdditionalSources. Defaults to false.
+	Enabled *bool `json:"enabled,omitempty"`
+	// IngressNamespaceSelector selects the namespaces of the ingress controller or
+	// router that public endpoints are exposed through. If not specified, namespaces
+	// with the label "network.openshift.io/policy-group: ingress" are selected on
+	// OpenShift, and the namespace "ingress-nginx" is selected on Kubernetes.
+	IngressNamespaceSelector *metav1.LabelSelector `json:"ingressNamespaceSelector,omitempty"`
+	// AdditionalSources are additional sources that are allowed to access all
+	// ports of DevWorkspace pods, e.g. monitoring or service mesh components.
+	AdditionalSources []networkingv1.NetworkPolicyPeer `json:"additionalSources,omitempty"`
+}
+
+type OIDCConfig struct {
+	// IssuerURL is the URL of the OpenID Connect issuer.
+	IssuerURL string `json:"issuerURL"`
+	// ClientID is the OAuth client ID used by the authenticating proxy.
+	ClientID string `json:"clientID"`
+	// ClientSecretName is the name of a secret containing the OAuth client secret
+	// in the key "client-secret". The secret must exist in each namespace where
+	// DevWorkspaces using the "oidc" routing class are run.
+	ClientSecretName string `json:"clientSecretName"`
+	// UserClaim is the ID token claim that is compared with the username of the
+	// creator of a DevWorkspace to determine if a user is allowed to access the
+	// DevWorkspace's endpoints. This should match the claim used by the Kubernetes
+	// API server to determine usernames (--oidc-username-claim). Defaults to "sub".
+	UserClaim string `json:"userClaim,omitempty"`
+	// UsernamePrefix is the prefix added by the Kubernetes API server to the value
+	// of UserClaim to form usernames (--oidc-username-prefix). It is removed from
+	// the username of the creator of a DevWorkspace before comparing it with the
+	// value of UserClaim. Defaults to no prefix.
+	UsernamePrefix string `json:"usernamePrefix,omitempty"`
+}
+
+type RoutingTLSConfig struct {
+	// SecretName is the name of a secret containing a wildcard TLS certificate
+	// for the cluster host suffix. The secret must exist in each namespace where
+	// DevWorkspaces are run. If SecretName, Issuer, and ClusterIssuer are not
+	// specified, Ingresses use the ingress controller's default certificate.
+	SecretName string `json:"secretName,omitempty"`
+	// Issuer is the name of a cert-manager Issuer used to obtain a certificate
+	// for each endpoint's hostname. The Issuer must exist in each namespace where
+	// DevWorkspaces are run. Cannot be specified with SecretName or ClusterIssuer.
+	Issuer string `json:"issuer,omitempty"`
+	// ClusterIssuer is the name of a cert-manager ClusterIssuer used to obtain a
+	// certificate for each endpoint's hostname. Cannot be specified with
+	// SecretName or Issuer.
+	ClusterIssuer string `json:"clusterIssuer,omitempty"`
+}
+
+type GatewayConfig struct {
+	// Name is the name of the Gateway that HTTPRoutes are attached to.
+	Name string `json:"name"`
+	// Namespace is the namespace of the Gateway. If not specified, HTTPRoutes
+	// are attached to a Gateway in the DevWorkspace's namespace. If the Gateway
+	// is in a different namespace, its listeners must allow routes from
+	// DevWorkspace namespaces.
+	Namespace string `json:"namespace,omitempty"`
+	// SectionName is the name of the Gateway listener that HTTPRoutes are
+	// attached to. If not specified, HTTPRoutes are attached to all listeners
+	// of the Gateway that allow them.
+	SectionName string `json:"sectionName,omitempty"`
+	// TLS specifies whether the Gateway listener terminates TLS, in which case
+	// secure endpoints are exposed using https URLs. Defaults to false.
+	TLS *bool `json:"tls,omitempty"`
 }
 
 type WorkspaceConfig struct {
@@ -59,22 +196,149 @@ type WorkspaceConfig struct {
 	// StorageClassName defines and optional storageClass to use for persistent
 	// volume claims created to support DevWorkspaces
 	StorageClassName *string `json:"storageClassName,omitempty"`
+	// CommonPVCSize defines the size of the persistent volume claim created to
+	// support workspace storage when the 'common' or 'async' storage types are
+	// used. If not specified, the default value of '1Gi' is used. A size set in
+	// a namespace's per-namespace configmap takes precedence over this value.
+	// If the size of an existing PVC is increased and its storage class allows
+	// volume expansion, the PVC is expanded; PVCs are never shrunk.
+	CommonPVCSize *resource.Quantity `json:"commonPVCSize,omitempty"`
+	// ComputeCommonPVCSize configures whether the size of the common PVC in a
+	// namespace should be increased to fit the volume components of all
+	// DevWorkspaces in the namespace that use it. If enabled, the PVC's size is
+	// the larger of CommonPVCSize and the sum of the sizes of the volumes of
+	// these DevWorkspaces, where volumes that do not specify a size count as
+	// 1Gi. Only volumes defined directly in a DevWorkspace (and not in its
+	// parent or plugins) are considered. Defaults to false.
+	ComputeCommonPVCSize *bool `json:"computeCommonPVCSize,omitempty"`
 	// IdleTimeout determines how long a workspace should sit idle before being
-	// automatically scaled down. Proper functionality of this configuration property
-	// requires support in the workspace being started. If not specified, the default
-	// value of "15m" is used.
+	// automatically stopped. The timeout is provided to workspaces in the
+	// DEVWORKSPACE_IDLE_TIMEOUT environment variable, and is used by the controller
+	// to stop idle workspaces if StopIdleWorkspaces is enabled. Setting a duration
+	// of zero (e.g. "0s") disables idling. If not specified, the default value of
+	// "15m" is used.
 	IdleTimeout string `json:"idleTimeout,omitempty"`
+	// StopIdleWorkspaces configures whether the controller stops workspaces that have
+	// been idle for longer than IdleTimeout. Activity in a workspace is reported by
+	// updating the "controller.devfile.io/last-activity" annotation on the DevWorkspace;
+	// workspaces with no reported activity since they started are considered idle once
+	// they have been running for longer than IdleTimeout. Defaults to false.
+	StopIdleWorkspaces *bool `json:"stopIdleWorkspaces,omitempty"`
 	// ProgressTimeout determines the maximum duration a DevWorkspace can be in
 	// a "Starting" or "Failing" phase without progressing before it is automatically failed.
 	// Duration should be specified in a format parseable by Go's time package, e.g.
 	// "15m", "20s", "1h30m", etc. If not specified, the default value of "5m" is used.
 	ProgressTimeout string `json:"progressTimeout,omitempty"`
+	// MaxRunDuration determines the maximum duration a DevWorkspace can run before it is
+	// automatically stopped. Duration should be specified in a format parseable by Go's
+	// time package, e.g. "8h", "1h30m", etc. The annotation "controller.devfile.io/max-run-duration"
+	// can be used to override this value for individual DevWorkspaces. If not specified,
+	// DevWorkspaces can run indefinitely.
+	MaxRunDuration string `json:"maxRunDuration,omitempty"`
 	// IgnoredUnrecoverableEvents defines a list of Kubernetes event names that should
 	// be ignored when deciding to fail a DevWorkspace startup. This option should be used
 	// if a transient cluster issue is triggering false-positives (for example, if
 	// the cluster occasionally encounters FailedScheduling events). Events listed
 	// here will not trigger DevWorkspace failures.
 	IgnoredUnrecoverableEvents []string `json:"ignoredUnrecoverableEvents,omitempty"`
+	// ImageBuild configures how image components in DevWorkspaces are built. If a registry
+	// is not configured, DevWorkspaces that contain image components will fail to start.
+	ImageBuild *ImageBuildConfig `json:"imageBuild,omitempty"`
+	// Quotas configures limits on the number of DevWorkspaces users can create and run.
+	// Quotas are enforced both when DevWorkspaces are created or started and when they are
+	// reconciled. If not specified, no limits are enforced.
+	Quotas *WorkspaceQuotaConfig `json:"quotas,omitempty"`
+	// Backup configures backing up DevWorkspace storage using CSI VolumeSnapshots. Backups
+	// can always be requested for individual DevWorkspaces by applying the annotation
+	// "controller.devfile.io/backup"; this configuration controls additional automatic backups.
+	Backup *BackupConfig `json:"backup,omitempty"`
+	// StorageGarbageCollection configures periodic removal of data left on the common PVC
+	// by DevWorkspaces that no longer exist (e.g. if the DevWorkspace's storage finalizer
+	// was removed manually or cleanup failed).
+	StorageGarbageCollection *StorageGarbageCollectionConfig `json:"storageGarbageCollection,omitempty"`
+	// StorageUsage configures periodic measurement of the disk space used by each
+	// DevWorkspace on the common PVC.
+	StorageUsage *StorageUsageConfig `json:"storageUsage,omitempty"`
+}
+
+type BackupConfig struct {
+	// VolumeSnapshotClassName defines the VolumeSnapshotClass used when creating snapshots
+	// of DevWorkspace storage. If not specified, the cluster's default VolumeSnapshotClass
+	// is used.
+	VolumeSnapshotClassName *string `json:"volumeSnapshotClassName,omitempty"`
+	// Schedule is a cron schedule (e.g. "0 2 * * *") on which running DevWorkspaces are
+	// backed up. The annotation "controller.devfile.io/backup-schedule" can be used to override
+	// this value for individual DevWorkspaces. If not specified, DevWorkspaces are not backed
+	// up on a schedule.
+	Schedule string `json:"schedule,omitempty"`
+	// BackupOnDelete defines whether a snapshot of a DevWorkspace's storage should be taken
+	// before its storage is cleaned up when the DevWorkspace is deleted. Deletion of the
+	// DevWorkspace is blocked until the snapshot is ready to use. Defaults to false.
+	BackupOnDelete *bool `json:"backupOnDelete,omitempty"`
+	// MaxBackups is the maximum number of VolumeSnapshots kept for each DevWorkspace. When a
+	// new backup is taken, the oldest snapshots of the DevWorkspace beyond this number are
+	// deleted. Snapshots taken when a DevWorkspace is deleted are not removed. If not specified,
+	// all snapshots are kept.
+	// +kubebuilder:validation:Minimum=1
+	MaxBackups *int `json:"maxBackups,omitempty"`
+}
+
+type StorageGarbageCollectionConfig struct {
+	// Interval defines how often orphaned data on the common PVC is collected in each namespace.
+	// Duration should be specified in a format parseable by Go's time package, e.g. "24h".
+	// Setting a duration of zero (e.g. "0s") disables garbage collection. If not specified,
+	// the default value of "24h" is used.
+	Interval string `json:"interval,omitempty"`
+	// DryRun defines whether orphaned data should only be reported instead of removed.
+	// When enabled, orphaned data is logged and reported in metrics, but left on the
+	// common PVC. Defaults to false.
+	DryRun *bool `json:"dryRun,omitempty"`
+}
+
+type StorageUsageConfig struct {
+	// Interval defines how often the disk space used by DevWorkspaces on the common PVC is
+	// measured in each namespace. Duration should be specified in a format parseable by Go's
+	// time package, e.g. "1h". Setting a duration of zero (e.g. "0s") disables measuring
+	// storage usage. If not specified, the default value of "1h" is used.
+	Interval string `json:"interval,omitempty"`
+	// SoftLimit defines the amount of storage a single DevWorkspace is expected to use on
+	// the common PVC. DevWorkspaces using more than 90% of this amount have the
+	// StorageUsageWarning condition set. The limit is not enforced. If not specified, no
+	// warning is reported.
+	SoftLimit *resource.Quantity `json:"softLimit,omitempty"`
+}
+
+type WorkspaceQuotaConfig struct {
+	// MaxRunningPerUser is the maximum number of DevWorkspaces a single user (as determined by
+	// the "controller.devfile.io/creator" label) can have running at once, across all namespaces.
+	// If not specified, no limit is enforced.
+	// +kubebuilder:validation:Minimum=0
+	MaxRunningPerUser *int `json:"maxRunningPerUser,omitempty"`
+	// MaxRunningPerNamespace is the maximum number of DevWorkspaces that can be running at once
+	// in a single namespace. If not specified, no limit is enforced.
+	// +kubebuilder:validation:Minimum=0
+	MaxRunningPerNamespace *int `json:"maxRunningPerNamespace,omitempty"`
+	// MaxWorkspacesPerUser is the maximum number of DevWorkspaces (running or stopped) a single
+	// user can have, across all namespaces. If not specified, no limit is enforced.
+	// +kubebuilder:validation:Minimum=0
+	MaxWorkspacesPerUser *int `json:"maxWorkspacesPerUser,omitempty"`
+}
+
+type ImageBuildConfig struct {
+	// Registry defines the registry (and optional repository path) that images built
+	// for DevWorkspaces are pushed to, e.g. "quay.io/my-org". Images built from an
+	// image component are pushed to <registry>/<image-name>:<devworkspace-id>
+	Registry string `json:"registry,omitempty"`
+	// PushSecret defines the name of a secret of type kubernetes.io/dockerconfigjson in
+	// the DevWorkspace's namespace that is used to push images to the registry. If not
+	// specified, images are pushed without credentials. The secret is also added to the
+	// image pull secrets of DevWorkspaces that use built images.
+	PushSecret string `json:"pushSecret,omitempty"`
+	// AllowRootBuilds allows image components that set rootRequired: true to be built.
+	// Such builds run in a privileged container as root, which grants access to the node
+	// the build runs on; this should only be enabled if all users that can create
+	// DevWorkspaces are trusted. Defaults to false.
+	AllowRootBuilds *bool `json:"allowRootBuilds,omitempty"`
 }
 
 // DevWorkspaceOperatorConfig is the Schema for the devworkspaceoperatorconfigs API
@@ -41,6 +41,9 @@ const (
 	DevWorkspaceRoutingCluster     DevWorkspaceRoutingClass = "cluster"
 	DevWorkspaceRoutingClusterTLS  DevWorkspaceRoutingClass = "cluster-tls"
 	DevWorkspaceRoutingWebTerminal DevWorkspaceRoutingClass = "web-terminal"
+	DevWorkspaceRoutingGateway     DevWorkspaceRoutingClass = "gateway"
+	DevWorkspaceRoutingOIDC        DevWorkspaceRoutingClass = "oidc"
+	DevWorkspaceRoutingSingleHost  DevWorkspaceRoutingClass = "single-host"
 )
 
 // DevWorkspaceRoutingStatus defines the observed state of DevWorkspaceRouting
@@ -23,9 +23,41 @@ import (
 	"github.com/devfile/api/v2/pkg/apis/workspaces/v1alpha2"
 	"github.com/devfile/api/v2/pkg/attributes"
 	v1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BackupConfig) DeepCopyInto(out *BackupConfig) {
+	*out = *in
+	if in.VolumeSnapshotClassName != nil {
+		in, out := &in.VolumeSnapshotClassName, &out.VolumeSnapshotClassName
+		*out = new(string)
+		**out = **in
+	}
+	if in.BackupOnDelete != nil {
+		in, out := &in.BackupOnDelete, &out.BackupOnDelete
+		*out = new(bool)
+		**out = **in
+	}
+	if in.MaxBackups != nil {
+		in, out := &in.MaxBackups, &out.MaxBackups
+		*out = new(int)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackupConfig.
+func (in *BackupConfig) DeepCopy() *BackupConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(BackupConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *DevWorkspaceOperatorConfig) DeepCopyInto(out *DevWorkspaceOperatorConfig) {
 	*out = *in
@@ -287,13 +319,115 @@ func (in ExposedEndpointList) DeepCopy() ExposedEndpointList {
 	return *out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GatewayConfig) DeepCopyInto(out *GatewayConfig) {
+	*out = *in
+	if in.TLS != nil {
+		in, out := &in.TLS, &out.TLS
+		*out = new(bool)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GatewayConfig.
+func (in *GatewayConfig) DeepCopy() *GatewayConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(GatewayConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ImageBuildConfig) DeepCopyInto(out *ImageBuildConfig) {
+	*out = *in
+	if in.AllowRootBuilds != nil {
+		in, out := &in.AllowRootBuilds, &out.AllowRootBuilds
+		*out = new(bool)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ImageBuildConfig.
+func (in *ImageBuildConfig) DeepCopy() *ImageBuildConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(ImageBuildConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NetworkPolicyConfig) DeepCopyInto(out *NetworkPolicyConfig) {
+	*out = *in
+	if in.Enabled != nil {
+		in, out := &in.Enabled, &out.Enabled
+		*out = new(bool)
+		**out = **in
+	}
+	if in.IngressNamespaceSelector != nil {
+		in, out := &in.IngressNamespaceSelector, &out.IngressNamespaceSelector
+		*out = new(metav1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.AdditionalSources != nil {
+		in, out := &in.AdditionalSources, &out.AdditionalSources
+		*out = make([]networkingv1.NetworkPolicyPeer, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NetworkPolicyConfig.
+func (in *NetworkPolicyConfig) DeepCopy() *NetworkPolicyConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(NetworkPolicyConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NonHTTPEndpointsConfig) DeepCopyInto(out *NonHTTPEndpointsConfig) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NonHTTPEndpointsConfig.
+func (in *NonHTTPEndpointsConfig) DeepCopy() *NonHTTPEndpointsConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(NonHTTPEndpointsConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OIDCConfig) DeepCopyInto(out *OIDCConfig) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OIDCConfig.
+func (in *OIDCConfig) DeepCopy() *OIDCConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(OIDCConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *OperatorConfiguration) DeepCopyInto(out *OperatorConfiguration) {
 	*out = *in
 	if in.Routing != nil {
 		in, out := &in.Routing, &out.Routing
 		*out = new(RoutingConfig)
-		**out = **in
+		(*in).DeepCopyInto(*out)
 	}
 	if in.Workspace != nil {
 		in, out := &in.Workspace, &out.Workspace
@@ -389,6 +523,55 @@ func (in *PodAdditions) DeepCopy() *PodAdditions {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *RoutingConfig) DeepCopyInto(out *RoutingConfig) {
 	*out = *in
+	if in.AllowedEndpointIngressClasses != nil {
+		in, out := &in.AllowedEndpointIngressClasses, &out.AllowedEndpointIngressClasses
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Annotations != nil {
+		in, out := &in.Annotations, &out.Annotations
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.AllowedEndpointAnnotations != nil {
+		in, out := &in.AllowedEndpointAnnotations, &out.AllowedEndpointAnnotations
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Labels != nil {
+		in, out := &in.Labels, &out.Labels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Gateway != nil {
+		in, out := &in.Gateway, &out.Gateway
+		*out = new(GatewayConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.TLS != nil {
+		in, out := &in.TLS, &out.TLS
+		*out = new(RoutingTLSConfig)
+		**out = **in
+	}
+	if in.OIDC != nil {
+		in, out := &in.OIDC, &out.OIDC
+		*out = new(OIDCConfig)
+		**out = **in
+	}
+	if in.NetworkPolicy != nil {
+		in, out := &in.NetworkPolicy, &out.NetworkPolicy
+		*out = new(NetworkPolicyConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.NonHTTPEndpoints != nil {
+		in, out := &in.NonHTTPEndpoints, &out.NonHTTPEndpoints
+		*out = new(NonHTTPEndpointsConfig)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RoutingConfig.
@@ -401,6 +584,61 @@ func (in *RoutingConfig) DeepCopy() *RoutingConfig {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RoutingTLSConfig) DeepCopyInto(out *RoutingTLSConfig) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RoutingTLSConfig.
+func (in *RoutingTLSConfig) DeepCopy() *RoutingTLSConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(RoutingTLSConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StorageGarbageCollectionConfig) DeepCopyInto(out *StorageGarbageCollectionConfig) {
+	*out = *in
+	if in.DryRun != nil {
+		in, out := &in.DryRun, &out.DryRun
+		*out = new(bool)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StorageGarbageCollectionConfig.
+func (in *StorageGarbageCollectionConfig) DeepCopy() *StorageGarbageCollectionConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(StorageGarbageCollectionConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StorageUsageConfig) DeepCopyInto(out *StorageUsageConfig) {
+	*out = *in
+	if in.SoftLimit != nil {
+		in, out := &in.SoftLimit, &out.SoftLimit
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StorageUsageConfig.
+func (in *StorageUsageConfig) DeepCopy() *StorageUsageConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(StorageUsageConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *WorkspaceConfig) DeepCopyInto(out *WorkspaceConfig) {
 	*out = *in
@@ -409,11 +647,51 @@ func (in *WorkspaceConfig) DeepCopyInto(out *WorkspaceConfig) {
 		*out = new(string)
 		**out = **in
 	}
+	if in.CommonPVCSize != nil {
+		in, out := &in.CommonPVCSize, &out.CommonPVCSize
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+	if in.ComputeCommonPVCSize != nil {
+		in, out := &in.ComputeCommonPVCSize, &out.ComputeCommonPVCSize
+		*out = new(bool)
+		**out = **in
+	}
+	if in.StopIdleWorkspaces != nil {
+		in, out := &in.StopIdleWorkspaces, &out.StopIdleWorkspaces
+		*out = new(bool)
+		**out = **in
+	}
 	if in.IgnoredUnrecoverableEvents != nil {
 		in, out := &in.IgnoredUnrecoverableEvents, &out.IgnoredUnrecoverableEvents
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.ImageBuild != nil {
+		in, out := &in.ImageBuild, &out.ImageBuild
+		*out = new(ImageBuildConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Quotas != nil {
+		in, out := &in.Quotas, &out.Quotas
+		*out = new(WorkspaceQuotaConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Backup != nil {
+		in, out := &in.Backup, &out.Backup
+		*out = new(BackupConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.StorageGarbageCollection != nil {
+		in, out := &in.StorageGarbageCollection, &out.StorageGarbageCollection
+		*out = new(StorageGarbageCollectionConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.StorageUsage != nil {
+		in, out := &in.StorageUsage, &out.StorageUsage
+		*out = new(StorageUsageConfig)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WorkspaceConfig.
@@ -425,3 +703,33 @@ func (in *WorkspaceConfig) DeepCopy() *WorkspaceConfig {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WorkspaceQuotaConfig) DeepCopyInto(out *WorkspaceQuotaConfig) {
+	*out = *in
+	if in.MaxRunningPerUser != nil {
+		in, out := &in.MaxRunningPerUser, &out.MaxRunningPerUser
+		*out = new(int)
+		**out = **in
+	}
+	if in.MaxRunningPerNamespace != nil {
+		in, out := &in.MaxRunningPerNamespace, &out.MaxRunningPerNamespace
+		*out = new(int)
+		**out = **in
+	}
+	if in.MaxWorkspacesPerUser != nil {
+		in, out := &in.MaxWorkspacesPerUser, &out.MaxWorkspacesPerUser
+		*out = new(int)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WorkspaceQuotaConfig.
+func (in *WorkspaceQuotaConfig) DeepCopy() *WorkspaceQuotaConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(WorkspaceQuotaConfig)
+	in.DeepCopyInto(out)
+	return out
+}
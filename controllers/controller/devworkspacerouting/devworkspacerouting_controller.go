@@ -64,9 +64,11 @@ type DevWorkspaceRoutingReconciler struct {
 // +kubebuilder:rbac:groups=controller.devfile.io,resources=devworkspaceroutings/status,verbs=get;update;patch
 // +kubebuilder:rbac:groups="",resources=services,verbs=*
 // +kubebuilder:rbac:groups=networking.k8s.io,resources=ingresses,verbs=*
+// +kubebuilder:rbac:groups=networking.k8s.io,resources=networkpolicies,verbs=*
 // +kubebuilder:rbac:groups=route.openshift.io,resources=routes,verbs=*
 // +kubebuidler:rbac:groups=route.openshift.io,resources=routes/status,verbs=get,list,watch
 // +kubebuilder:rbac:groups=route.openshift.io,resources=routes/custom-host,verbs=create
+// +kubebuilder:rbac:groups=gateway.networking.k8s.io,resources=httproutes,verbs=*
 
 func (r *DevWorkspaceRoutingReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
 	reqLogger := r.Log.WithValues("Request.Namespace", req.Namespace, "Request.Name", req.Name)
@@ -127,6 +129,9 @@ func (r *DevWorkspaceRoutingReconciler) Reconcile(ctx context.Context, req ctrl.
 
 	restrictedAccess, setRestrictedAccess := instance.Annotations[constants.DevWorkspaceRestrictedAccessAnnotation]
 	routingObjects, err := solver.GetSpecObjects(instance, workspaceMeta)
+	if err == nil {
+		err = solvers.ApplyRoutingConfig(instance.Spec.Endpoints, &routingObjects)
+	}
 	if err != nil {
 		var notReady *solvers.RoutingNotReady
 		if errors.As(err, &notReady) {
@@ -179,6 +184,17 @@ func (r *DevWorkspaceRoutingReconciler) Reconcile(ctx context.Context, req ctrl.
 		}
 	}
 
+	httpRoutes := routingObjects.HTTPRoutes
+	for idx := range httpRoutes {
+		err := controllerutil.SetControllerReference(instance, &httpRoutes[idx], r.Scheme)
+		if err != nil {
+			return reconcile.Result{}, err
+		}
+		if setRestrictedAccess {
+			httpRoutes[idx].SetAnnotations(maputils.Append(httpRoutes[idx].GetAnnotations(), constants.DevWorkspaceRestrictedAccessAnnotation, restrictedAccess))
+		}
+	}
+
 	servicesInSync, clusterServices, err := r.syncServices(instance, services)
 	if err != nil {
 		reqLogger.Error(err, "Error syncing services")
@@ -188,6 +204,22 @@ func (r *DevWorkspaceRoutingReconciler) Reconcile(ctx context.Context, req ctrl.
 		return reconcile.Result{Requeue: true}, r.reconcileStatus(instance, nil, nil, false, "Preparing services")
 	}
 
+	networkPolicies := getSpecNetworkPolicies(instance, routingObjects)
+	for idx := range networkPolicies {
+		err := controllerutil.SetControllerReference(instance, &networkPolicies[idx], r.Scheme)
+		if err != nil {
+			return reconcile.Result{}, err
+		}
+	}
+	networkPoliciesInSync, err := r.syncNetworkPolicies(instance, networkPolicies)
+	if err != nil {
+		reqLogger.Error(err, "Error syncing network policies")
+		return reconcile.Result{Requeue: true}, r.reconcileStatus(instance, nil, nil, false, "Preparing network policies")
+	} else if !networkPoliciesInSync {
+		reqLogger.Info("Network policies not in sync")
+		return reconcile.Result{Requeue: true}, r.reconcileStatus(instance, nil, nil, false, "Preparing network policies")
+	}
+
 	clusterRoutingObj := solvers.RoutingObjects{
 		Services: clusterServices,
 	}
@@ -214,6 +246,18 @@ func (r *DevWorkspaceRoutingReconciler) Reconcile(ctx context.Context, req ctrl.
 		clusterRoutingObj.Ingresses = clusterIngresses
 	}
 
+	if infrastructure.SupportsGatewayAPI() {
+		httpRoutesInSync, clusterHTTPRoutes, err := r.syncHTTPRoutes(instance, httpRoutes)
+		if err != nil {
+			reqLogger.Error(err, "Error syncing HTTPRoutes")
+			return reconcile.Result{Requeue: true}, r.reconcileStatus(instance, nil, nil, false, "Preparing HTTPRoutes")
+		} else if !httpRoutesInSync {
+			reqLogger.Info("HTTPRoutes not in sync")
+			return reconcile.Result{Requeue: true}, r.reconcileStatus(instance, nil, nil, false, "Preparing HTTPRoutes")
+		}
+		clusterRoutingObj.HTTPRoutes = clusterHTTPRoutes
+	}
+
 	exposedEndpoints, endpointsAreReady, err := solver.GetExposedEndpoints(instance.Spec.Endpoints, clusterRoutingObj)
 	if err != nil {
 		reqLogger.Error(err, "Could not get exposed endpoints for devworkspace")
@@ -318,7 +362,8 @@ func (r *DevWorkspaceRoutingReconciler) SetupWithManager(mgr ctrl.Manager) error
 		WithOptions(controller.Options{MaxConcurrentReconciles: maxConcurrentReconciles}).
 		For(&controllerv1alpha1.DevWorkspaceRouting{}).
 		Owns(&corev1.Service{}).
-		Owns(&networkingv1.Ingress{})
+		Owns(&networkingv1.Ingress{}).
+		Owns(&networkingv1.NetworkPolicy{})
 	if infrastructure.IsOpenShift() {
 		bld.Owns(&routeV1.Route{})
 	}
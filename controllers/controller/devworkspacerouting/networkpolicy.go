@@ -0,0 +1,254 @@
+//
+// Copyright (c) 2019-2021 Red Hat, Inc.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package devworkspacerouting
+
+import (
+	"sort"
+
+	dw "github.com/devfile/api/v2/pkg/apis/workspaces/v1alpha2"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	controllerv1alpha1 "github.com/devfile/devworkspace-operator/apis/controller/v1alpha1"
+	"github.com/devfile/devworkspace-operator/controllers/controller/devworkspacerouting/solvers"
+	"github.com/devfile/devworkspace-operator/pkg/common"
+	"github.com/devfile/devworkspace-operator/pkg/config"
+	"github.com/devfile/devworkspace-operator/pkg/constants"
+	"github.com/devfile/devworkspace-operator/pkg/infrastructure"
+)
+
+var (
+	openShiftIngressNamespaceSelector = &metav1.LabelSelector{
+		MatchLabels: map[string]string{"network.openshift.io/policy-group": "ingress"},
+	}
+	kubernetesIngressNamespaceSelector = &metav1.LabelSelector{
+		MatchLabels: map[string]string{"kubernetes.io/metadata.name": "ingress-nginx"},
+	}
+)
+
+// getSpecNetworkPolicies returns the NetworkPolicy that should exist for a DevWorkspaceRouting, based on the routing
+// objects provisioned for it. The NetworkPolicy allows traffic to the workspace's pods from
+//   - the workspace's own pods, on all ports
+//   - the ingress controller or router namespaces, on ports exposed through Ingresses, Routes, or HTTPRoutes
+//   - any source, on ports exposed through LoadBalancer or NodePort services
+//   - all pods in the namespace, on ports of discoverable endpoints
+//   - any additional sources from the operator configuration, on all ports
+//
+// Fields that are defaulted by the API server (port protocols and policy types) are always set explicitly to avoid
+// the NetworkPolicy being detected as out of sync with the cluster.
+//
+// Returns nil if NetworkPolicies are disabled in the operator configuration.
+func getSpecNetworkPolicies(routing *controllerv1alpha1.DevWorkspaceRouting, routingObjects solvers.RoutingObjects) []networkingv1.NetworkPolicy {
+	policyConfig := config.Routing.NetworkPolicy
+	if policyConfig == nil || policyConfig.Enabled == nil || !*policyConfig.Enabled {
+		return nil
+	}
+	workspaceId := routing.Spec.DevWorkspaceId
+	workspaceSelector := metav1.LabelSelector{
+		MatchLabels: map[string]string{constants.DevWorkspaceIDLabel: workspaceId},
+	}
+
+	rules := []networkingv1.NetworkPolicyIngressRule{
+		{
+			From: []networkingv1.NetworkPolicyPeer{{PodSelector: workspaceSelector.DeepCopy()}},
+		},
+	}
+
+	if publicPorts := getPublicTargetPorts(routingObjects); len(publicPorts) > 0 {
+		namespaceSelector := policyConfig.IngressNamespaceSelector
+		if namespaceSelector == nil {
+			if infrastructure.IsOpenShift() {
+				namespaceSelector = openShiftIngressNamespaceSelector
+			} else {
+				namespaceSelector = kubernetesIngressNamespaceSelector
+			}
+		}
+		rules = append(rules, networkingv1.NetworkPolicyIngressRule{
+			From:  []networkingv1.NetworkPolicyPeer{{NamespaceSelector: namespaceSelector.DeepCopy()}},
+			Ports: getNetworkPolicyPorts(publicPorts),
+		})
+	}
+
+	if externalPorts := getExternalServicePorts(routingObjects.Services); len(externalPorts) > 0 {
+		// Traffic to LoadBalancer and NodePort services may come from any source outside the cluster
+		rules = append(rules, networkingv1.NetworkPolicyIngressRule{
+			Ports: externalPorts,
+		})
+	}
+
+	if discoverablePorts := getDiscoverablePorts(routing.Spec.Endpoints); len(discoverablePorts) > 0 {
+		rules = append(rules, networkingv1.NetworkPolicyIngressRule{
+			From:  []networkingv1.NetworkPolicyPeer{{PodSelector: &metav1.LabelSelector{}}},
+			Ports: getNetworkPolicyPorts(discoverablePorts),
+		})
+	}
+
+	if len(policyConfig.AdditionalSources) > 0 {
+		var additionalSources []networkingv1.NetworkPolicyPeer
+		for _, source := range policyConfig.AdditionalSources {
+			additionalSources = append(additionalSources, *source.DeepCopy())
+		}
+		rules = append(rules, networkingv1.NetworkPolicyIngressRule{
+			From: additionalSources,
+		})
+	}
+
+	return []networkingv1.NetworkPolicy{
+		{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      common.NetworkPolicyName(workspaceId),
+				Namespace: routing.Namespace,
+				Labels: map[string]string{
+					constants.DevWorkspaceIDLabel: workspaceId,
+				},
+			},
+			Spec: networkingv1.NetworkPolicySpec{
+				PodSelector: workspaceSelector,
+				Ingress:     rules,
+				PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeIngress},
+			},
+		},
+	}
+}
+
+// getPublicTargetPorts returns the set of container ports that are exposed outside the cluster by the Ingresses,
+// Routes, and HTTPRoutes in routingObjects. Service ports are resolved to target ports using the Services in
+// routingObjects.
+func getPublicTargetPorts(routingObjects solvers.RoutingObjects) map[int32]bool {
+	targetPorts := map[string]map[int32]int32{}
+	for _, service := range routingObjects.Services {
+		targetPorts[service.Name] = map[int32]int32{}
+		for _, port := range service.Spec.Ports {
+			if port.TargetPort.Type == intstr.Int && port.TargetPort.IntVal != 0 {
+				targetPorts[service.Name][port.Port] = port.TargetPort.IntVal
+			} else {
+				targetPorts[service.Name][port.Port] = port.Port
+			}
+		}
+	}
+	publicPorts := map[int32]bool{}
+	addServicePort := func(serviceName string, port int32) {
+		if targetPort, ok := targetPorts[serviceName][port]; ok {
+			publicPorts[targetPort] = true
+		} else {
+			publicPorts[port] = true
+		}
+	}
+
+	for _, ingress := range routingObjects.Ingresses {
+		for _, rule := range ingress.Spec.Rules {
+			if rule.HTTP == nil {
+				continue
+			}
+			for _, path := range rule.HTTP.Paths {
+				if path.Backend.Service != nil && path.Backend.Service.Port.Number != 0 {
+					addServicePort(path.Backend.Service.Name, path.Backend.Service.Port.Number)
+				}
+			}
+		}
+	}
+	for _, route := range routingObjects.Routes {
+		if route.Spec.Port != nil && route.Spec.Port.TargetPort.Type == intstr.Int {
+			addServicePort(route.Spec.To.Name, route.Spec.Port.TargetPort.IntVal)
+		}
+	}
+	for _, httpRoute := range routingObjects.HTTPRoutes {
+		rules, _, _ := unstructured.NestedSlice(httpRoute.Object, "spec", "rules")
+		for _, rule := range rules {
+			ruleMap, ok := rule.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			backendRefs, _, _ := unstructured.NestedSlice(ruleMap, "backendRefs")
+			for _, backendRef := range backendRefs {
+				backendRefMap, ok := backendRef.(map[string]interface{})
+				if !ok {
+					continue
+				}
+				name, _, _ := unstructured.NestedString(backendRefMap, "name")
+				port, found, _ := unstructured.NestedInt64(backendRefMap, "port")
+				if found {
+					addServicePort(name, int32(port))
+				}
+			}
+		}
+	}
+	return publicPorts
+}
+
+// getExternalServicePorts returns the target ports of LoadBalancer and NodePort services in services, with their
+// protocols.
+func getExternalServicePorts(services []corev1.Service) []networkingv1.NetworkPolicyPort {
+	var policyPorts []networkingv1.NetworkPolicyPort
+	for _, service := range services {
+		if service.Spec.Type != corev1.ServiceTypeLoadBalancer && service.Spec.Type != corev1.ServiceTypeNodePort {
+			continue
+		}
+		for _, servicePort := range service.Spec.Ports {
+			protocol := servicePort.Protocol
+			if protocol == "" {
+				protocol = corev1.ProtocolTCP
+			}
+			targetPort := servicePort.TargetPort
+			if targetPort.Type == intstr.Int && targetPort.IntVal == 0 {
+				targetPort = intstr.FromInt(int(servicePort.Port))
+			}
+			policyPorts = append(policyPorts, networkingv1.NetworkPolicyPort{
+				Protocol: &protocol,
+				Port:     &targetPort,
+			})
+		}
+	}
+	return policyPorts
+}
+
+// getDiscoverablePorts returns the set of target ports of discoverable endpoints, which are intended to be accessed
+// by other pods in the namespace.
+func getDiscoverablePorts(endpoints map[string]controllerv1alpha1.EndpointList) map[int32]bool {
+	discoverablePorts := map[int32]bool{}
+	for _, machineEndpoints := range endpoints {
+		for _, endpoint := range machineEndpoints {
+			if endpoint.Exposure == dw.NoneEndpointExposure {
+				continue
+			}
+			if endpoint.Attributes.GetBoolean(string(controllerv1alpha1.DiscoverableAttribute), nil) {
+				discoverablePorts[int32(endpoint.TargetPort)] = true
+			}
+		}
+	}
+	return discoverablePorts
+}
+
+func getNetworkPolicyPorts(ports map[int32]bool) []networkingv1.NetworkPolicyPort {
+	var sortedPorts []int
+	for port := range ports {
+		sortedPorts = append(sortedPorts, int(port))
+	}
+	sort.Ints(sortedPorts)
+	var policyPorts []networkingv1.NetworkPolicyPort
+	for _, port := range sortedPorts {
+		protocol := corev1.ProtocolTCP
+		policyPort := intstr.FromInt(port)
+		policyPorts = append(policyPorts, networkingv1.NetworkPolicyPort{
+			Protocol: &protocol,
+			Port:     &policyPort,
+		})
+	}
+	return policyPorts
+}
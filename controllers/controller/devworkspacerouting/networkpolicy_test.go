@@ -0,0 +1,220 @@
+//
+// Copyright (c) 2019-2021 Red Hat, Inc.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package devworkspacerouting
+
+import (
+	"testing"
+
+	dw "github.com/devfile/api/v2/pkg/apis/workspaces/v1alpha2"
+	"github.com/devfile/api/v2/pkg/attributes"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	controllerv1alpha1 "github.com/devfile/devworkspace-operator/apis/controller/v1alpha1"
+	"github.com/devfile/devworkspace-operator/controllers/controller/devworkspacerouting/solvers"
+	"github.com/devfile/devworkspace-operator/pkg/common"
+	"github.com/devfile/devworkspace-operator/pkg/config"
+	"github.com/devfile/devworkspace-operator/pkg/constants"
+	"github.com/devfile/devworkspace-operator/pkg/infrastructure"
+)
+
+func setupNetworkPolicyConfig(t *testing.T, infra infrastructure.Type, policyConfig *controllerv1alpha1.NetworkPolicyConfig) {
+	infrastructure.InitializeForTesting(infra)
+	t.Cleanup(func() { infrastructure.InitializeForTesting(infrastructure.Kubernetes) })
+	config.SetConfigForTesting(&controllerv1alpha1.OperatorConfiguration{
+		Routing: &controllerv1alpha1.RoutingConfig{
+			NetworkPolicy: policyConfig,
+		},
+	})
+}
+
+func getNetworkPolicyTestRouting() *controllerv1alpha1.DevWorkspaceRouting {
+	routing := getTestRouting()
+	routing.Spec.Endpoints = map[string]controllerv1alpha1.EndpointList{
+		"tooling": {
+			{Name: "ide", TargetPort: 3100, Exposure: dw.PublicEndpointExposure},
+			{Name: "ssh", TargetPort: 22, Exposure: dw.PublicEndpointExposure, Protocol: dw.TCPEndpointProtocol},
+		},
+		"db": {
+			{
+				Name:       "postgres",
+				TargetPort: 5432,
+				Exposure:   dw.InternalEndpointExposure,
+				Attributes: attributes.Attributes{}.PutBoolean(string(controllerv1alpha1.DiscoverableAttribute), true),
+			},
+		},
+	}
+	return routing
+}
+
+func getNetworkPolicyTestRoutingObjects() solvers.RoutingObjects {
+	return solvers.RoutingObjects{
+		Services: []corev1.Service{
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "workspace-id-service"},
+				Spec: corev1.ServiceSpec{
+					Type:  corev1.ServiceTypeClusterIP,
+					Ports: []corev1.ServicePort{{Name: "ide", Port: 80, TargetPort: intstr.FromInt(3100)}},
+				},
+			},
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "workspace-id-external"},
+				Spec: corev1.ServiceSpec{
+					Type:  corev1.ServiceTypeNodePort,
+					Ports: []corev1.ServicePort{{Name: "ssh", Port: 22}},
+				},
+			},
+		},
+		Ingresses: []networkingv1.Ingress{
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "workspace-id-ide"},
+				Spec: networkingv1.IngressSpec{
+					Rules: []networkingv1.IngressRule{
+						{
+							IngressRuleValue: networkingv1.IngressRuleValue{
+								HTTP: &networkingv1.HTTPIngressRuleValue{
+									Paths: []networkingv1.HTTPIngressPath{
+										{
+											Backend: networkingv1.IngressBackend{
+												Service: &networkingv1.IngressServiceBackend{
+													Name: "workspace-id-service",
+													Port: networkingv1.ServiceBackendPort{Number: 80},
+												},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func getTestPolicyPort(port int) networkingv1.NetworkPolicyPort {
+	protocol := corev1.ProtocolTCP
+	policyPort := intstr.FromInt(port)
+	return networkingv1.NetworkPolicyPort{Protocol: &protocol, Port: &policyPort}
+}
+
+func TestNetworkPolicyDisabledByDefault(t *testing.T) {
+	setupNetworkPolicyConfig(t, infrastructure.Kubernetes, nil)
+	policies := getSpecNetworkPolicies(getNetworkPolicyTestRouting(), getNetworkPolicyTestRoutingObjects())
+	assert.Nil(t, policies, "Should not create NetworkPolicies unless enabled in config")
+}
+
+func TestNetworkPolicyRules(t *testing.T) {
+	enabled := true
+	additionalSource := networkingv1.NetworkPolicyPeer{
+		NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"monitoring": "true"}},
+	}
+	setupNetworkPolicyConfig(t, infrastructure.Kubernetes, &controllerv1alpha1.NetworkPolicyConfig{
+		Enabled:           &enabled,
+		AdditionalSources: []networkingv1.NetworkPolicyPeer{additionalSource},
+	})
+	policies := getSpecNetworkPolicies(getNetworkPolicyTestRouting(), getNetworkPolicyTestRoutingObjects())
+	if !assert.Len(t, policies, 1) {
+		return
+	}
+	policy := policies[0]
+	workspaceSelector := metav1.LabelSelector{MatchLabels: map[string]string{constants.DevWorkspaceIDLabel: testWorkspaceID}}
+	assert.Equal(t, common.NetworkPolicyName(testWorkspaceID), policy.Name)
+	assert.Equal(t, testNamespace, policy.Namespace)
+	assert.Equal(t, testWorkspaceID, policy.Labels[constants.DevWorkspaceIDLabel])
+	assert.Equal(t, workspaceSelector, policy.Spec.PodSelector, "NetworkPolicy should select workspace pods")
+	assert.Equal(t, []networkingv1.PolicyType{networkingv1.PolicyTypeIngress}, policy.Spec.PolicyTypes)
+	assert.Equal(t, []networkingv1.NetworkPolicyIngressRule{
+		{
+			From: []networkingv1.NetworkPolicyPeer{{PodSelector: &workspaceSelector}},
+		},
+		{
+			From:  []networkingv1.NetworkPolicyPeer{{NamespaceSelector: kubernetesIngressNamespaceSelector}},
+			Ports: []networkingv1.NetworkPolicyPort{getTestPolicyPort(3100)},
+		},
+		{
+			Ports: []networkingv1.NetworkPolicyPort{getTestPolicyPort(22)},
+		},
+		{
+			From:  []networkingv1.NetworkPolicyPeer{{PodSelector: &metav1.LabelSelector{}}},
+			Ports: []networkingv1.NetworkPolicyPort{getTestPolicyPort(5432)},
+		},
+		{
+			From: []networkingv1.NetworkPolicyPeer{additionalSource},
+		},
+	}, policy.Spec.Ingress)
+}
+
+func TestNetworkPolicyIngressNamespaceSelector(t *testing.T) {
+	enabled := true
+	customSelector := &metav1.LabelSelector{MatchLabels: map[string]string{"ingress": "true"}}
+	tests := []struct {
+		name             string
+		infra            infrastructure.Type
+		configSelector   *metav1.LabelSelector
+		expectedSelector *metav1.LabelSelector
+	}{
+		{
+			name:             "Kubernetes default",
+			infra:            infrastructure.Kubernetes,
+			expectedSelector: kubernetesIngressNamespaceSelector,
+		},
+		{
+			name:             "OpenShift default",
+			infra:            infrastructure.OpenShiftv4,
+			expectedSelector: openShiftIngressNamespaceSelector,
+		},
+		{
+			name:             "Configured selector",
+			infra:            infrastructure.Kubernetes,
+			configSelector:   customSelector,
+			expectedSelector: customSelector,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setupNetworkPolicyConfig(t, tt.infra, &controllerv1alpha1.NetworkPolicyConfig{
+				Enabled:                  &enabled,
+				IngressNamespaceSelector: tt.configSelector,
+			})
+			policies := getSpecNetworkPolicies(getNetworkPolicyTestRouting(), getNetworkPolicyTestRoutingObjects())
+			if !assert.Len(t, policies, 1) || !assert.GreaterOrEqual(t, len(policies[0].Spec.Ingress), 2) {
+				return
+			}
+			ingressRule := policies[0].Spec.Ingress[1]
+			if assert.Len(t, ingressRule.From, 1) {
+				assert.Equal(t, tt.expectedSelector, ingressRule.From[0].NamespaceSelector)
+			}
+		})
+	}
+}
+
+func TestNetworkPolicyOnlyAllowsWorkspaceWithoutExposedEndpoints(t *testing.T) {
+	enabled := true
+	setupNetworkPolicyConfig(t, infrastructure.Kubernetes, &controllerv1alpha1.NetworkPolicyConfig{Enabled: &enabled})
+	routing := getTestRouting()
+	policies := getSpecNetworkPolicies(routing, solvers.RoutingObjects{})
+	if !assert.Len(t, policies, 1) {
+		return
+	}
+	if assert.Len(t, policies[0].Spec.Ingress, 1, "Should only allow traffic from the workspace itself") {
+		assert.Empty(t, policies[0].Spec.Ingress[0].Ports, "Should allow all ports within the workspace")
+	}
+}
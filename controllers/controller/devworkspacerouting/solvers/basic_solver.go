@@ -38,9 +38,12 @@ var nginxIngressAnnotations = func(endpointName string) map[string]string {
 	}
 }
 
+var certManagerIssuerAnnotation = "cert-manager.io/issuer"
+var certManagerClusterIssuerAnnotation = "cert-manager.io/cluster-issuer"
+
 // Basic solver exposes endpoints without any authentication
 // According to the current cluster there is different behavior:
-// Kubernetes: use Ingresses without TLS
+// Kubernetes: use Ingresses, with TLS enabled if configured in .config.routing.tls
 // OpenShift: use Routes with TLS enabled
 type BasicSolver struct{}
 
@@ -65,11 +68,20 @@ func (s *BasicSolver) GetSpecObjects(routing *controllerv1alpha1.DevWorkspaceRou
 	spec := routing.Spec
 	services := getServicesForEndpoints(spec.Endpoints, workspaceMeta)
 	services = append(services, GetDiscoverableServicesForEndpoints(spec.Endpoints, workspaceMeta)...)
+	externalServices, err := getExternalServicesForEndpoints(spec.Endpoints, workspaceMeta)
+	if err != nil {
+		return routingObjects, err
+	}
+	services = append(services, externalServices...)
 	routingObjects.Services = services
 	if infrastructure.IsOpenShift() {
 		routingObjects.Routes = getRoutesForSpec(routingSuffix, spec.Endpoints, workspaceMeta)
 	} else {
-		routingObjects.Ingresses = getIngressesForSpec(routingSuffix, spec.Endpoints, workspaceMeta)
+		tlsConfig := config.Routing.TLS
+		if err := validateTLSConfig(tlsConfig); err != nil {
+			return routingObjects, err
+		}
+		routingObjects.Ingresses = getIngressesForSpec(routingSuffix, tlsConfig, spec.Endpoints, workspaceMeta)
 	}
 
 	return routingObjects, nil
@@ -80,3 +92,20 @@ func (s *BasicSolver) GetExposedEndpoints(
 	routingObj RoutingObjects) (exposedEndpoints map[string]controllerv1alpha1.ExposedEndpointList, ready bool, err error) {
 	return getExposedEndpoints(endpoints, routingObj)
 }
+
+// validateTLSConfig checks that at most one source for Ingress certificates is set in the TLS configuration.
+func validateTLSConfig(tlsConfig *controllerv1alpha1.RoutingTLSConfig) error {
+	if tlsConfig == nil {
+		return nil
+	}
+	configured := 0
+	for _, field := range []string{tlsConfig.SecretName, tlsConfig.Issuer, tlsConfig.ClusterIssuer} {
+		if field != "" {
+			configured++
+		}
+	}
+	if configured > 1 {
+		return &RoutingInvalid{"at most one of secretName, issuer, and clusterIssuer may be set in .config.routing.tls in operator config"}
+	}
+	return nil
+}
@@ -90,6 +90,10 @@ func (s *ClusterSolver) GetExposedEndpoints(
 			if endpoint.Exposure == dw.NoneEndpointExposure {
 				continue
 			}
+			if getDedicatedPodComponent(endpoint) != "" {
+				// Endpoints of dedicatedPod components are exposed within the cluster by the component's own service
+				continue
+			}
 			url, err := resolveServiceHostnameForEndpoint(endpoint, routingObj.Services)
 			if err != nil {
 				return nil, false, err
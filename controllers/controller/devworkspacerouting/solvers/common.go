@@ -16,10 +16,13 @@
 package solvers
 
 import (
+	"sort"
+
 	dw "github.com/devfile/api/v2/pkg/apis/workspaces/v1alpha2"
 
 	controllerv1alpha1 "github.com/devfile/devworkspace-operator/apis/controller/v1alpha1"
 	"github.com/devfile/devworkspace-operator/pkg/common"
+	"github.com/devfile/devworkspace-operator/pkg/config"
 	"github.com/devfile/devworkspace-operator/pkg/constants"
 
 	routeV1 "github.com/openshift/api/route/v1"
@@ -35,6 +38,32 @@ type DevWorkspaceMetadata struct {
 	PodSelector    map[string]string
 }
 
+// getDedicatedPodComponent returns the name of the dedicatedPod component that serves an endpoint, or an empty string
+// if the endpoint is served by the main workspace pod.
+func getDedicatedPodComponent(endpoint dw.Endpoint) string {
+	return endpoint.Attributes.GetString(constants.DedicatedPodComponentAttribute, nil)
+}
+
+// getEndpointServiceName returns the name of the Service that exposes an endpoint within the cluster. Endpoints of
+// dedicatedPod components are exposed by the Service created for the component by the DevWorkspace controller.
+func getEndpointServiceName(endpoint dw.Endpoint, meta DevWorkspaceMetadata) string {
+	if component := getDedicatedPodComponent(endpoint); component != "" {
+		return common.DedicatedPodName(meta.DevWorkspaceId, component)
+	}
+	return common.ServiceName(meta.DevWorkspaceId)
+}
+
+// getEndpointPodSelector returns the selector for the pods that serve an endpoint
+func getEndpointPodSelector(endpoint dw.Endpoint, meta DevWorkspaceMetadata) map[string]string {
+	if component := getDedicatedPodComponent(endpoint); component != "" {
+		return map[string]string{
+			constants.DevWorkspaceIDLabel:           meta.DevWorkspaceId,
+			constants.DevWorkspaceDedicatedPodLabel: component,
+		}
+	}
+	return meta.PodSelector
+}
+
 // GetDiscoverableServicesForEndpoints converts the endpoint list into a set of services, each corresponding to a single discoverable
 // endpoint from the list. Endpoints with the NoneEndpointExposure are ignored.
 func GetDiscoverableServicesForEndpoints(endpoints map[string]controllerv1alpha1.EndpointList, meta DevWorkspaceMetadata) []corev1.Service {
@@ -68,7 +97,7 @@ func GetDiscoverableServicesForEndpoints(endpoints map[string]controllerv1alpha1
 					},
 					Spec: corev1.ServiceSpec{
 						Ports:    []corev1.ServicePort{servicePort},
-						Selector: meta.PodSelector,
+						Selector: getEndpointPodSelector(endpoint, meta),
 						Type:     corev1.ServiceTypeClusterIP,
 					},
 				})
@@ -79,6 +108,7 @@ func GetDiscoverableServicesForEndpoints(endpoints map[string]controllerv1alpha1
 }
 
 // GetServiceForEndpoints returns a single service that exposes all endpoints of given exposure types, possibly also including the discoverable types.
+// Endpoints of dedicatedPod components are not included, as they are exposed by the component's own service.
 // `nil` is returned if the service would expose no ports satisfying the provided criteria.
 func GetServiceForEndpoints(endpoints map[string]controllerv1alpha1.EndpointList, meta DevWorkspaceMetadata, includeDiscoverable bool, exposureType ...dw.EndpointExposure) *corev1.Service {
 	// "set" of ports that are still left for exposure
@@ -107,6 +137,10 @@ func GetServiceForEndpoints(endpoints map[string]controllerv1alpha1.EndpointList
 				continue
 			}
 
+			if getDedicatedPodComponent(endpoint) != "" {
+				continue
+			}
+
 			if ports[endpoint.TargetPort] {
 				// make sure we don't mention the same port twice
 				ports[endpoint.TargetPort] = false
@@ -155,11 +189,106 @@ func getServicesForEndpoints(endpoints map[string]controllerv1alpha1.EndpointLis
 	}
 }
 
+// isNonHTTPEndpoint returns whether an endpoint uses a protocol that cannot be exposed through an Ingress or Route.
+func isNonHTTPEndpoint(endpoint dw.Endpoint) bool {
+	return endpoint.Protocol == dw.TCPEndpointProtocol || endpoint.Protocol == dw.UDPEndpointProtocol
+}
+
+// getPublicEndpoints returns all public endpoints in a workspace that can be exposed through an Ingress, sorted by name
+func getPublicEndpoints(endpoints map[string]controllerv1alpha1.EndpointList) []dw.Endpoint {
+	var publicEndpoints []dw.Endpoint
+	for _, machineEndpoints := range endpoints {
+		for _, endpoint := range machineEndpoints {
+			if endpoint.Exposure == dw.PublicEndpointExposure && !isNonHTTPEndpoint(endpoint) {
+				publicEndpoints = append(publicEndpoints, endpoint)
+			}
+		}
+	}
+	sort.Slice(publicEndpoints, func(i, j int) bool {
+		return publicEndpoints[i].Name < publicEndpoints[j].Name
+	})
+	return publicEndpoints
+}
+
+// getExternalServicesForEndpoints returns Services of the type configured in .config.routing.nonHTTPEndpoints that
+// expose all public endpoints using the tcp or udp protocol outside the cluster. Endpoints served by the main workspace
+// pod are exposed by one Service, and endpoints of each dedicatedPod component by a separate Service.
+func getExternalServicesForEndpoints(endpoints map[string]controllerv1alpha1.EndpointList, meta DevWorkspaceMetadata) ([]corev1.Service, error) {
+	// Ports are grouped by the dedicatedPod component serving them; the main workspace pod uses the empty string
+	ports := map[string][]corev1.ServicePort{}
+	for _, machineEndpoints := range endpoints {
+		for _, endpoint := range machineEndpoints {
+			if endpoint.Exposure != dw.PublicEndpointExposure || !isNonHTTPEndpoint(endpoint) {
+				continue
+			}
+			protocol := corev1.ProtocolTCP
+			if endpoint.Protocol == dw.UDPEndpointProtocol {
+				protocol = corev1.ProtocolUDP
+			}
+			component := getDedicatedPodComponent(endpoint)
+			ports[component] = append(ports[component], corev1.ServicePort{
+				Name:       common.EndpointName(endpoint.Name),
+				Protocol:   protocol,
+				Port:       int32(endpoint.TargetPort),
+				TargetPort: intstr.FromInt(endpoint.TargetPort),
+			})
+		}
+	}
+	if len(ports) == 0 {
+		return nil, nil
+	}
+
+	serviceType := corev1.ServiceTypeLoadBalancer
+	if nonHTTPConfig := config.Routing.NonHTTPEndpoints; nonHTTPConfig != nil && nonHTTPConfig.ServiceType != "" {
+		serviceType = nonHTTPConfig.ServiceType
+		if serviceType == corev1.ServiceTypeNodePort && nonHTTPConfig.NodePortHost == "" {
+			return nil, &RoutingInvalid{"exposing endpoints through NodePort services requires .config.routing.nonHTTPEndpoints.nodePortHost to be set in operator config"}
+		}
+	}
+
+	var components []string
+	for component := range ports {
+		components = append(components, component)
+	}
+	sort.Strings(components)
+	var services []corev1.Service
+	for _, component := range components {
+		componentPorts := ports[component]
+		sort.Slice(componentPorts, func(i, j int) bool {
+			return componentPorts[i].Name < componentPorts[j].Name
+		})
+		name := common.ExternalServiceName(meta.DevWorkspaceId)
+		selector := meta.PodSelector
+		if component != "" {
+			name = common.ExternalServiceName(common.DedicatedPodName(meta.DevWorkspaceId, component))
+			selector = map[string]string{
+				constants.DevWorkspaceIDLabel:           meta.DevWorkspaceId,
+				constants.DevWorkspaceDedicatedPodLabel: component,
+			}
+		}
+		services = append(services, corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: meta.Namespace,
+				Labels: map[string]string{
+					constants.DevWorkspaceIDLabel: meta.DevWorkspaceId,
+				},
+			},
+			Spec: corev1.ServiceSpec{
+				Selector: selector,
+				Type:     serviceType,
+				Ports:    componentPorts,
+			},
+		})
+	}
+	return services, nil
+}
+
 func getRoutesForSpec(routingSuffix string, endpoints map[string]controllerv1alpha1.EndpointList, meta DevWorkspaceMetadata) []routeV1.Route {
 	var routes []routeV1.Route
 	for _, machineEndpoints := range endpoints {
 		for _, endpoint := range machineEndpoints {
-			if endpoint.Exposure != dw.PublicEndpointExposure {
+			if endpoint.Exposure != dw.PublicEndpointExposure || isNonHTTPEndpoint(endpoint) {
 				continue
 			}
 			routes = append(routes, getRouteForEndpoint(routingSuffix, endpoint, meta))
@@ -168,14 +297,14 @@ func getRoutesForSpec(routingSuffix string, endpoints map[string]controllerv1alp
 	return routes
 }
 
-func getIngressesForSpec(routingSuffix string, endpoints map[string]controllerv1alpha1.EndpointList, meta DevWorkspaceMetadata) []networkingv1.Ingress {
+func getIngressesForSpec(routingSuffix string, tlsConfig *controllerv1alpha1.RoutingTLSConfig, endpoints map[string]controllerv1alpha1.EndpointList, meta DevWorkspaceMetadata) []networkingv1.Ingress {
 	var ingresses []networkingv1.Ingress
 	for _, machineEndpoints := range endpoints {
 		for _, endpoint := range machineEndpoints {
-			if endpoint.Exposure != dw.PublicEndpointExposure {
+			if endpoint.Exposure != dw.PublicEndpointExposure || isNonHTTPEndpoint(endpoint) {
 				continue
 			}
-			ingresses = append(ingresses, getIngressForEndpoint(routingSuffix, endpoint, meta))
+			ingresses = append(ingresses, getIngressForEndpoint(routingSuffix, tlsConfig, endpoint, meta))
 		}
 	}
 	return ingresses
@@ -202,7 +331,7 @@ func getRouteForEndpoint(routingSuffix string, endpoint dw.Endpoint, meta DevWor
 			},
 			To: routeV1.RouteTargetReference{
 				Kind: "Service",
-				Name: common.ServiceName(meta.DevWorkspaceId),
+				Name: getEndpointServiceName(endpoint, meta),
 			},
 			Port: &routeV1.RoutePort{
 				TargetPort: targetEndpoint,
@@ -211,10 +340,14 @@ func getRouteForEndpoint(routingSuffix string, endpoint dw.Endpoint, meta DevWor
 	}
 }
 
-func getIngressForEndpoint(routingSuffix string, endpoint dw.Endpoint, meta DevWorkspaceMetadata) networkingv1.Ingress {
+// getIngressForEndpoint returns an Ingress exposing the endpoint on its own hostname. If tlsConfig is not nil, the
+// Ingress is secured using the secret or cert-manager issuer it specifies.
+func getIngressForEndpoint(routingSuffix string, tlsConfig *controllerv1alpha1.RoutingTLSConfig, endpoint dw.Endpoint, meta DevWorkspaceMetadata) networkingv1.Ingress {
 	endpointName := common.EndpointName(endpoint.Name)
 	hostname := common.EndpointHostname(routingSuffix, meta.DevWorkspaceId, endpointName, endpoint.TargetPort)
 	ingressPathType := networkingv1.PathTypeImplementationSpecific
+	annotations := nginxIngressAnnotations(endpoint.Name)
+	ingressTLS := getIngressTLS(tlsConfig, hostname, common.IngressTLSSecretName(meta.DevWorkspaceId, endpointName), annotations)
 	return networkingv1.Ingress{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      common.RouteName(meta.DevWorkspaceId, endpointName),
@@ -222,9 +355,10 @@ func getIngressForEndpoint(routingSuffix string, endpoint dw.Endpoint, meta DevW
 			Labels: map[string]string{
 				constants.DevWorkspaceIDLabel: meta.DevWorkspaceId,
 			},
-			Annotations: nginxIngressAnnotations(endpoint.Name),
+			Annotations: annotations,
 		},
 		Spec: networkingv1.IngressSpec{
+			TLS: ingressTLS,
 			Rules: []networkingv1.IngressRule{
 				{
 					Host: hostname,
@@ -234,7 +368,7 @@ func getIngressForEndpoint(routingSuffix string, endpoint dw.Endpoint, meta DevW
 								{
 									Backend: networkingv1.IngressBackend{
 										Service: &networkingv1.IngressServiceBackend{
-											Name: common.ServiceName(meta.DevWorkspaceId),
+											Name: getEndpointServiceName(endpoint, meta),
 											Port: networkingv1.ServiceBackendPort{Number: int32(endpoint.TargetPort)},
 										},
 									},
@@ -249,3 +383,28 @@ func getIngressForEndpoint(routingSuffix string, endpoint dw.Endpoint, meta DevW
 		},
 	}
 }
+
+// getIngressTLS returns the TLS section for an Ingress serving hostname according to tlsConfig, adding any annotations
+// required to annotations. If a cert-manager issuer is configured, the certificate is stored in the secret issuerSecretName.
+// Returns nil if tlsConfig is nil.
+func getIngressTLS(tlsConfig *controllerv1alpha1.RoutingTLSConfig, hostname, issuerSecretName string, annotations map[string]string) []networkingv1.IngressTLS {
+	if tlsConfig == nil {
+		return nil
+	}
+	annotations["nginx.ingress.kubernetes.io/ssl-redirect"] = "true"
+	secretName := tlsConfig.SecretName
+	switch {
+	case tlsConfig.Issuer != "":
+		annotations[certManagerIssuerAnnotation] = tlsConfig.Issuer
+		secretName = issuerSecretName
+	case tlsConfig.ClusterIssuer != "":
+		annotations[certManagerClusterIssuerAnnotation] = tlsConfig.ClusterIssuer
+		secretName = issuerSecretName
+	}
+	return []networkingv1.IngressTLS{
+		{
+			Hosts:      []string{hostname},
+			SecretName: secretName,
+		},
+	}
+}
@@ -0,0 +1,349 @@
+//
+// Copyright (c) 2019-2021 Red Hat, Inc.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package solvers
+
+import (
+	"testing"
+
+	dw "github.com/devfile/api/v2/pkg/apis/workspaces/v1alpha2"
+	"github.com/devfile/api/v2/pkg/attributes"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	controllerv1alpha1 "github.com/devfile/devworkspace-operator/apis/controller/v1alpha1"
+	"github.com/devfile/devworkspace-operator/pkg/common"
+	"github.com/devfile/devworkspace-operator/pkg/config"
+	"github.com/devfile/devworkspace-operator/pkg/constants"
+	"github.com/devfile/devworkspace-operator/pkg/infrastructure"
+)
+
+const (
+	testWorkspaceID = "workspace-id"
+	testNamespace   = "test-namespace"
+)
+
+func getTestMeta() DevWorkspaceMetadata {
+	return DevWorkspaceMetadata{
+		DevWorkspaceId: testWorkspaceID,
+		Namespace:      testNamespace,
+		PodSelector: map[string]string{
+			constants.DevWorkspaceIDLabel:      testWorkspaceID,
+			constants.DevWorkspaceMainPodLabel: "true",
+		},
+	}
+}
+
+// setupSolverTest sets the infrastructure and routing configuration used by a test. The infrastructure is reset to
+// Kubernetes once the test completes.
+func setupSolverTest(t *testing.T, infra infrastructure.Type, routingConfig *controllerv1alpha1.RoutingConfig) {
+	infrastructure.InitializeForTesting(infra)
+	t.Cleanup(func() { infrastructure.InitializeForTesting(infrastructure.Kubernetes) })
+	config.SetConfigForTesting(&controllerv1alpha1.OperatorConfiguration{
+		Routing: routingConfig,
+	})
+}
+
+// getTestRouting returns a DevWorkspaceRouting for the test workspace that uses the given routing class and endpoints.
+// The DevWorkspaceRouting is owned by a DevWorkspace named "my-workspace" and records the username of its creator.
+func getTestRouting(routingClass controllerv1alpha1.DevWorkspaceRoutingClass, endpoints map[string]controllerv1alpha1.EndpointList) *controllerv1alpha1.DevWorkspaceRouting {
+	isController := true
+	routing := &controllerv1alpha1.DevWorkspaceRouting{}
+	routing.Name = testWorkspaceID
+	routing.Namespace = testNamespace
+	routing.UID = "test-routing-uid"
+	routing.Annotations = map[string]string{
+		constants.DevWorkspaceCreatorUsernameAnnotation: "oidc:user@example.com",
+	}
+	routing.OwnerReferences = []metav1.OwnerReference{
+		{
+			APIVersion: "workspace.devfile.io/v1alpha2",
+			Kind:       "DevWorkspace",
+			Name:       "my-workspace",
+			UID:        "test-uid",
+			Controller: &isController,
+		},
+	}
+	routing.Spec.DevWorkspaceId = testWorkspaceID
+	routing.Spec.RoutingClass = routingClass
+	routing.Spec.Endpoints = endpoints
+	return routing
+}
+
+func getDedicatedEndpoint(endpoint dw.Endpoint, component string) dw.Endpoint {
+	endpoint.Attributes = attributes.Attributes{}.PutString(constants.DedicatedPodComponentAttribute, component)
+	return endpoint
+}
+
+func TestDedicatedPodEndpointsUseDedicatedService(t *testing.T) {
+	meta := getTestMeta()
+	mainEndpoint := dw.Endpoint{Name: "ide", TargetPort: 3100, Exposure: dw.PublicEndpointExposure}
+	dedicatedEndpoint := getDedicatedEndpoint(dw.Endpoint{Name: "db-console", TargetPort: 8080, Exposure: dw.PublicEndpointExposure}, "db")
+	endpoints := map[string]controllerv1alpha1.EndpointList{
+		"tooling": {mainEndpoint},
+		"db":      {dedicatedEndpoint},
+	}
+
+	services := getServicesForEndpoints(endpoints, meta)
+	if assert.Len(t, services, 1) {
+		if assert.Len(t, services[0].Spec.Ports, 1, "Workspace service should not expose dedicated pod endpoints") {
+			assert.Equal(t, int32(3100), services[0].Spec.Ports[0].Port)
+		}
+	}
+
+	assert.Equal(t, "workspace-id-service", getRouteForEndpoint("test.suffix", mainEndpoint, meta).Spec.To.Name)
+	assert.Equal(t, "workspace-id-db", getRouteForEndpoint("test.suffix", dedicatedEndpoint, meta).Spec.To.Name,
+		"Route for dedicated pod endpoint should target the component's service")
+	ingress := getIngressForEndpoint("test.suffix", nil, dedicatedEndpoint, meta)
+	assert.Equal(t, "workspace-id-db", ingress.Spec.Rules[0].HTTP.Paths[0].Backend.Service.Name,
+		"Ingress for dedicated pod endpoint should target the component's service")
+}
+
+func TestDedicatedPodDiscoverableEndpointSelector(t *testing.T) {
+	meta := getTestMeta()
+	discoverable := attributes.Attributes{}.PutBoolean(string(controllerv1alpha1.DiscoverableAttribute), true)
+	endpoint := getDedicatedEndpoint(dw.Endpoint{Name: "db", TargetPort: 5432, Exposure: dw.InternalEndpointExposure}, "db")
+	endpoint.Attributes.PutBoolean(string(controllerv1alpha1.DiscoverableAttribute), true)
+	mainEndpoint := dw.Endpoint{Name: "ide", TargetPort: 3100, Exposure: dw.InternalEndpointExposure, Attributes: discoverable}
+
+	services := GetDiscoverableServicesForEndpoints(map[string]controllerv1alpha1.EndpointList{
+		"db":      {endpoint},
+		"tooling": {mainEndpoint},
+	}, meta)
+	selectors := map[string]map[string]string{}
+	for _, service := range services {
+		selectors[service.Name] = service.Spec.Selector
+	}
+	assert.Equal(t, map[string]string{
+		constants.DevWorkspaceIDLabel:           testWorkspaceID,
+		constants.DevWorkspaceDedicatedPodLabel: "db",
+	}, selectors["db"], "Discoverable service for dedicated pod endpoint should select the dedicated pod")
+	assert.Equal(t, meta.PodSelector, selectors["ide"], "Discoverable service for main pod endpoint should select the main pod")
+}
+
+func TestGetExternalServicesForEndpoints(t *testing.T) {
+	config.SetConfigForTesting(nil)
+	meta := getTestMeta()
+	endpoints := map[string]controllerv1alpha1.EndpointList{
+		"tooling": {
+			{Name: "ide", TargetPort: 3100, Exposure: dw.PublicEndpointExposure},
+			{Name: "ssh", TargetPort: 22, Exposure: dw.PublicEndpointExposure, Protocol: dw.TCPEndpointProtocol},
+			{Name: "debug", TargetPort: 5005, Exposure: dw.InternalEndpointExposure, Protocol: dw.TCPEndpointProtocol},
+		},
+		"db": {
+			getDedicatedEndpoint(dw.Endpoint{Name: "db", TargetPort: 5432, Exposure: dw.PublicEndpointExposure, Protocol: dw.TCPEndpointProtocol}, "db"),
+		},
+	}
+
+	services, err := getExternalServicesForEndpoints(endpoints, meta)
+	if !assert.NoError(t, err) || !assert.Len(t, services, 2) {
+		return
+	}
+	main, dedicated := services[0], services[1]
+	assert.Equal(t, "workspace-id-external", main.Name)
+	assert.Equal(t, corev1.ServiceTypeLoadBalancer, main.Spec.Type)
+	assert.Equal(t, meta.PodSelector, main.Spec.Selector)
+	if assert.Len(t, main.Spec.Ports, 1, "Only public tcp and udp endpoints should be exposed") {
+		assert.Equal(t, int32(22), main.Spec.Ports[0].Port)
+	}
+	assert.Equal(t, "workspace-id-db-external", dedicated.Name)
+	assert.Equal(t, map[string]string{
+		constants.DevWorkspaceIDLabel:           testWorkspaceID,
+		constants.DevWorkspaceDedicatedPodLabel: "db",
+	}, dedicated.Spec.Selector, "External service for dedicated pod endpoints should select the dedicated pod")
+
+	services, err = getExternalServicesForEndpoints(map[string]controllerv1alpha1.EndpointList{"tooling": endpoints["tooling"][:1]}, meta)
+	assert.NoError(t, err)
+	assert.Empty(t, services, "No external services should be created without tcp or udp endpoints")
+}
+
+func TestGetExternalServicesForEndpointsNodePort(t *testing.T) {
+	config.SetConfigForTesting(&controllerv1alpha1.OperatorConfiguration{
+		Routing: &controllerv1alpha1.RoutingConfig{
+			NonHTTPEndpoints: &controllerv1alpha1.NonHTTPEndpointsConfig{
+				ServiceType: corev1.ServiceTypeNodePort,
+			},
+		},
+	})
+	meta := getTestMeta()
+	endpoints := map[string]controllerv1alpha1.EndpointList{
+		"tooling": {
+			{Name: "ssh", TargetPort: 22, Exposure: dw.PublicEndpointExposure, Protocol: dw.TCPEndpointProtocol},
+			{Name: "dns", TargetPort: 53, Exposure: dw.PublicEndpointExposure, Protocol: dw.UDPEndpointProtocol},
+		},
+	}
+
+	_, err := getExternalServicesForEndpoints(endpoints, meta)
+	assert.IsType(t, &RoutingInvalid{}, err, "Should require nodePortHost when using NodePort services")
+
+	config.Routing.NonHTTPEndpoints.NodePortHost = "node.example.com"
+	services, err := getExternalServicesForEndpoints(endpoints, meta)
+	if !assert.NoError(t, err) || !assert.Len(t, services, 1) {
+		return
+	}
+	assert.Equal(t, corev1.ServiceTypeNodePort, services[0].Spec.Type)
+	assert.Equal(t, []corev1.ServicePort{
+		{Name: "dns", Protocol: corev1.ProtocolUDP, Port: 53, TargetPort: intstr.FromInt(53)},
+		{Name: "ssh", Protocol: corev1.ProtocolTCP, Port: 22, TargetPort: intstr.FromInt(22)},
+	}, services[0].Spec.Ports, "Service ports should use the endpoint's protocol")
+}
+
+func TestResolveExternalURLForEndpoint(t *testing.T) {
+	endpoint := dw.Endpoint{Name: "ssh", TargetPort: 22, Exposure: dw.PublicEndpointExposure, Protocol: dw.TCPEndpointProtocol}
+	getService := func(serviceType corev1.ServiceType, nodePort int32, lbIngress ...corev1.LoadBalancerIngress) corev1.Service {
+		service := corev1.Service{}
+		service.Name = "workspace-id-external"
+		service.Spec.Type = serviceType
+		service.Spec.Ports = []corev1.ServicePort{{Name: "ssh", Port: 22, NodePort: nodePort}}
+		service.Status.LoadBalancer.Ingress = lbIngress
+		return service
+	}
+	tests := []struct {
+		name         string
+		nodePortHost string
+		service      corev1.Service
+		expectedURL  string
+	}{
+		{
+			name:        "LoadBalancer without address",
+			service:     getService(corev1.ServiceTypeLoadBalancer, 0),
+			expectedURL: "",
+		},
+		{
+			name:        "LoadBalancer with IP",
+			service:     getService(corev1.ServiceTypeLoadBalancer, 0, corev1.LoadBalancerIngress{IP: "192.168.0.10"}),
+			expectedURL: "tcp://192.168.0.10:22",
+		},
+		{
+			name:        "LoadBalancer with hostname",
+			service:     getService(corev1.ServiceTypeLoadBalancer, 0, corev1.LoadBalancerIngress{Hostname: "lb.example.com", IP: "192.168.0.10"}),
+			expectedURL: "tcp://lb.example.com:22",
+		},
+		{
+			name:         "NodePort without allocated port",
+			nodePortHost: "node.example.com",
+			service:      getService(corev1.ServiceTypeNodePort, 0),
+			expectedURL:  "",
+		},
+		{
+			name:         "NodePort",
+			nodePortHost: "node.example.com",
+			service:      getService(corev1.ServiceTypeNodePort, 30022),
+			expectedURL:  "tcp://node.example.com:30022",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config.SetConfigForTesting(&controllerv1alpha1.OperatorConfiguration{
+				Routing: &controllerv1alpha1.RoutingConfig{
+					NonHTTPEndpoints: &controllerv1alpha1.NonHTTPEndpointsConfig{NodePortHost: tt.nodePortHost},
+				},
+			})
+			url, err := resolveExternalURLForEndpoint(endpoint, []corev1.Service{tt.service})
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedURL, url)
+		})
+	}
+
+	_, err := resolveExternalURLForEndpoint(endpoint, nil)
+	assert.Error(t, err, "Should return error if no external service exposes endpoint")
+}
+
+func TestGetIngressForEndpointTLS(t *testing.T) {
+	meta := getTestMeta()
+	endpoint := dw.Endpoint{Name: "ide", TargetPort: 3100, Exposure: dw.PublicEndpointExposure}
+	hostname := common.EndpointHostname("test.suffix", testWorkspaceID, "ide", 3100)
+	tests := []struct {
+		name                string
+		tlsConfig           *controllerv1alpha1.RoutingTLSConfig
+		expectedTLS         []networkingv1.IngressTLS
+		expectedAnnotations map[string]string
+	}{
+		{
+			name:      "No TLS",
+			tlsConfig: nil,
+			expectedAnnotations: map[string]string{
+				"nginx.ingress.kubernetes.io/ssl-redirect": "false",
+			},
+		},
+		{
+			name:      "Default certificate",
+			tlsConfig: &controllerv1alpha1.RoutingTLSConfig{},
+			expectedTLS: []networkingv1.IngressTLS{
+				{Hosts: []string{hostname}},
+			},
+			expectedAnnotations: map[string]string{
+				"nginx.ingress.kubernetes.io/ssl-redirect": "true",
+			},
+		},
+		{
+			name:      "Secret",
+			tlsConfig: &controllerv1alpha1.RoutingTLSConfig{SecretName: "wildcard-cert"},
+			expectedTLS: []networkingv1.IngressTLS{
+				{Hosts: []string{hostname}, SecretName: "wildcard-cert"},
+			},
+			expectedAnnotations: map[string]string{
+				"nginx.ingress.kubernetes.io/ssl-redirect": "true",
+			},
+		},
+		{
+			name:      "cert-manager Issuer",
+			tlsConfig: &controllerv1alpha1.RoutingTLSConfig{Issuer: "test-issuer"},
+			expectedTLS: []networkingv1.IngressTLS{
+				{Hosts: []string{hostname}, SecretName: "workspace-id-ide-tls"},
+			},
+			expectedAnnotations: map[string]string{
+				"nginx.ingress.kubernetes.io/ssl-redirect": "true",
+				"cert-manager.io/issuer":                   "test-issuer",
+			},
+		},
+		{
+			name:      "cert-manager ClusterIssuer",
+			tlsConfig: &controllerv1alpha1.RoutingTLSConfig{ClusterIssuer: "test-cluster-issuer"},
+			expectedTLS: []networkingv1.IngressTLS{
+				{Hosts: []string{hostname}, SecretName: "workspace-id-ide-tls"},
+			},
+			expectedAnnotations: map[string]string{
+				"nginx.ingress.kubernetes.io/ssl-redirect": "true",
+				"cert-manager.io/cluster-issuer":           "test-cluster-issuer",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ingress := getIngressForEndpoint("test.suffix", tt.tlsConfig, endpoint, meta)
+			assert.Equal(t, tt.expectedTLS, ingress.Spec.TLS, "Ingress TLS section should match expected")
+			for key, value := range tt.expectedAnnotations {
+				assert.Equal(t, value, ingress.Annotations[key], "Ingress should have annotation %s", key)
+			}
+			for _, annotation := range []string{"cert-manager.io/issuer", "cert-manager.io/cluster-issuer"} {
+				if _, expected := tt.expectedAnnotations[annotation]; !expected {
+					assert.NotContains(t, ingress.Annotations, annotation, "Ingress should not have annotation %s", annotation)
+				}
+			}
+		})
+	}
+}
+
+func TestValidateTLSConfig(t *testing.T) {
+	assert.NoError(t, validateTLSConfig(nil))
+	assert.NoError(t, validateTLSConfig(&controllerv1alpha1.RoutingTLSConfig{}))
+	assert.NoError(t, validateTLSConfig(&controllerv1alpha1.RoutingTLSConfig{Issuer: "test-issuer"}))
+	err := validateTLSConfig(&controllerv1alpha1.RoutingTLSConfig{SecretName: "wildcard-cert", ClusterIssuer: "test-cluster-issuer"})
+	assert.IsType(t, &RoutingInvalid{}, err, "Should not allow multiple certificate sources")
+}
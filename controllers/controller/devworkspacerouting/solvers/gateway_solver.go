@@ -0,0 +1,222 @@
+//
+// Copyright (c) 2019-2021 Red Hat, Inc.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package solvers
+
+import (
+	"fmt"
+	"strings"
+
+	dw "github.com/devfile/api/v2/pkg/apis/workspaces/v1alpha2"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	controllerv1alpha1 "github.com/devfile/devworkspace-operator/apis/controller/v1alpha1"
+	"github.com/devfile/devworkspace-operator/pkg/common"
+	"github.com/devfile/devworkspace-operator/pkg/config"
+	"github.com/devfile/devworkspace-operator/pkg/constants"
+)
+
+// HTTPRouteGVK is the GroupVersionKind of Gateway API HTTPRoutes. HTTPRoutes are handled as unstructured objects, as
+// the Gateway API is not part of core Kubernetes.
+var HTTPRouteGVK = schema.GroupVersionKind{
+	Group:   "gateway.networking.k8s.io",
+	Version: "v1",
+	Kind:    "HTTPRoute",
+}
+
+// GatewaySolver exposes endpoints through a Gateway API HTTPRoute attached to the Gateway configured in the
+// DevWorkspace Operator configuration. A single HTTPRoute is created for each workspace, using the workspace's
+// hostname and exposing each endpoint on its own path prefix.
+type GatewaySolver struct{}
+
+var _ RoutingSolver = (*GatewaySolver)(nil)
+
+func (s *GatewaySolver) FinalizerRequired(*controllerv1alpha1.DevWorkspaceRouting) bool {
+	return false
+}
+
+func (s *GatewaySolver) Finalize(*controllerv1alpha1.DevWorkspaceRouting) error {
+	return nil
+}
+
+func (s *GatewaySolver) GetSpecObjects(routing *controllerv1alpha1.DevWorkspaceRouting, workspaceMeta DevWorkspaceMetadata) (RoutingObjects, error) {
+	routingObjects := RoutingObjects{}
+
+	routingSuffix := config.Routing.ClusterHostSuffix
+	if routingSuffix == "" {
+		return routingObjects, &RoutingInvalid{"gateway routing requires .config.routing.clusterHostSuffix to be set in operator config"}
+	}
+	gateway := config.Routing.Gateway
+	if gateway == nil || gateway.Name == "" {
+		return routingObjects, &RoutingInvalid{"gateway routing requires .config.routing.gateway to be set in operator config"}
+	}
+
+	spec := routing.Spec
+	services := getServicesForEndpoints(spec.Endpoints, workspaceMeta)
+	services = append(services, GetDiscoverableServicesForEndpoints(spec.Endpoints, workspaceMeta)...)
+	externalServices, err := getExternalServicesForEndpoints(spec.Endpoints, workspaceMeta)
+	if err != nil {
+		return routingObjects, err
+	}
+	services = append(services, externalServices...)
+	routingObjects.Services = services
+	if httpRoute := getHTTPRouteForSpec(routingSuffix, gateway, spec.Endpoints, workspaceMeta); httpRoute != nil {
+		routingObjects.HTTPRoutes = []unstructured.Unstructured{*httpRoute}
+	}
+
+	return routingObjects, nil
+}
+
+func (s *GatewaySolver) GetExposedEndpoints(
+	endpoints map[string]controllerv1alpha1.EndpointList,
+	routingObj RoutingObjects) (exposedEndpoints map[string]controllerv1alpha1.ExposedEndpointList, ready bool, err error) {
+
+	exposedEndpoints = map[string]controllerv1alpha1.ExposedEndpointList{}
+	ready = true
+	secure := config.Routing.Gateway != nil && config.Routing.Gateway.TLS != nil && *config.Routing.Gateway.TLS
+
+	for machineName, machineEndpoints := range endpoints {
+		for _, endpoint := range machineEndpoints {
+			if endpoint.Exposure != dw.PublicEndpointExposure {
+				continue
+			}
+			if isNonHTTPEndpoint(endpoint) {
+				endpointUrl, err := resolveExternalURLForEndpoint(endpoint, routingObj.Services)
+				if err != nil {
+					return nil, false, err
+				}
+				if endpointUrl == "" {
+					ready = false
+				}
+				exposedEndpoints[machineName] = append(exposedEndpoints[machineName], controllerv1alpha1.ExposedEndpoint{
+					Name:       endpoint.Name,
+					Url:        endpointUrl,
+					Attributes: endpoint.Attributes,
+				})
+				continue
+			}
+			if len(routingObj.HTTPRoutes) == 0 {
+				return nil, false, fmt.Errorf("could not find HTTPRoute for endpoint '%s'", endpoint.Name)
+			}
+			httpRoute := routingObj.HTTPRoutes[0]
+			hostnames, _, err := unstructured.NestedStringSlice(httpRoute.Object, "spec", "hostnames")
+			if err != nil || len(hostnames) == 0 {
+				return nil, false, fmt.Errorf("HTTPRoute %s does not define a hostname", httpRoute.GetName())
+			}
+			endpointUrl := ""
+			if isHTTPRouteAccepted(&httpRoute) {
+				endpointUrl = getURLForEndpoint(endpoint, hostnames[0], common.EndpointPath(common.EndpointName(endpoint.Name)), secure)
+			} else {
+				ready = false
+			}
+			exposedEndpoints[machineName] = append(exposedEndpoints[machineName], controllerv1alpha1.ExposedEndpoint{
+				Name:       endpoint.Name,
+				Url:        endpointUrl,
+				Attributes: endpoint.Attributes,
+			})
+		}
+	}
+	return exposedEndpoints, ready, nil
+}
+
+// getHTTPRouteForSpec returns an HTTPRoute that exposes each public endpoint on the path returned by
+// common.EndpointPath, rewriting the path prefix to "/" before forwarding requests to the workspace's service.
+// Returns nil if there are no public endpoints.
+func getHTTPRouteForSpec(routingSuffix string, gateway *controllerv1alpha1.GatewayConfig, endpoints map[string]controllerv1alpha1.EndpointList, meta DevWorkspaceMetadata) *unstructured.Unstructured {
+	var rules []interface{}
+	// Rules are ordered by endpoint name so that the HTTPRoute does not change between reconciles
+	for _, endpoint := range getPublicEndpoints(endpoints) {
+		endpointPath := common.EndpointPath(common.EndpointName(endpoint.Name))
+		rules = append(rules, map[string]interface{}{
+			"matches": []interface{}{
+				map[string]interface{}{
+					"path": map[string]interface{}{
+						"type":  "PathPrefix",
+						"value": strings.TrimSuffix(endpointPath, "/"),
+					},
+				},
+			},
+			"filters": []interface{}{
+				map[string]interface{}{
+					"type": "URLRewrite",
+					"urlRewrite": map[string]interface{}{
+						"path": map[string]interface{}{
+							"type":               "ReplacePrefixMatch",
+							"replacePrefixMatch": "/",
+						},
+					},
+				},
+			},
+			"backendRefs": []interface{}{
+				map[string]interface{}{
+					"name": getEndpointServiceName(endpoint, meta),
+					"port": int64(endpoint.TargetPort),
+				},
+			},
+		})
+	}
+	if len(rules) == 0 {
+		return nil
+	}
+
+	parentRef := map[string]interface{}{
+		"name": gateway.Name,
+	}
+	if gateway.Namespace != "" {
+		parentRef["namespace"] = gateway.Namespace
+	}
+	if gateway.SectionName != "" {
+		parentRef["sectionName"] = gateway.SectionName
+	}
+
+	httpRoute := &unstructured.Unstructured{}
+	httpRoute.SetGroupVersionKind(HTTPRouteGVK)
+	httpRoute.SetName(common.HTTPRouteName(meta.DevWorkspaceId))
+	httpRoute.SetNamespace(meta.Namespace)
+	httpRoute.SetLabels(map[string]string{
+		constants.DevWorkspaceIDLabel: meta.DevWorkspaceId,
+	})
+	httpRoute.Object["spec"] = map[string]interface{}{
+		"parentRefs": []interface{}{parentRef},
+		"hostnames": []interface{}{
+			common.WorkspaceHostname(routingSuffix, meta.DevWorkspaceId),
+		},
+		"rules": rules,
+	}
+	return httpRoute
+}
+
+// isHTTPRouteAccepted returns whether an HTTPRoute has been accepted by at least one of its parent Gateways.
+func isHTTPRouteAccepted(httpRoute *unstructured.Unstructured) bool {
+	parents, _, _ := unstructured.NestedSlice(httpRoute.Object, "status", "parents")
+	for _, parent := range parents {
+		parentMap, ok := parent.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		conditions, _, _ := unstructured.NestedSlice(parentMap, "conditions")
+		for _, condition := range conditions {
+			conditionMap, ok := condition.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			if conditionMap["type"] == "Accepted" && conditionMap["status"] == "True" {
+				return true
+			}
+		}
+	}
+	return false
+}
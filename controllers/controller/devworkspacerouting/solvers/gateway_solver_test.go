@@ -0,0 +1,201 @@
+//
+// Copyright (c) 2019-2021 Red Hat, Inc.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package solvers
+
+import (
+	"testing"
+
+	dw "github.com/devfile/api/v2/pkg/apis/workspaces/v1alpha2"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	controllerv1alpha1 "github.com/devfile/devworkspace-operator/apis/controller/v1alpha1"
+	"github.com/devfile/devworkspace-operator/pkg/config"
+	"github.com/devfile/devworkspace-operator/pkg/constants"
+	"github.com/devfile/devworkspace-operator/pkg/infrastructure"
+)
+
+func TestGatewaySolverRequiresConfiguration(t *testing.T) {
+	solver := &GatewaySolver{}
+	routing := getTestRouting(controllerv1alpha1.DevWorkspaceRoutingGateway, map[string]controllerv1alpha1.EndpointList{
+		"tooling": {{Name: "ide", TargetPort: 3100, Exposure: dw.PublicEndpointExposure}},
+	})
+
+	config.SetConfigForTesting(nil)
+	_, err := solver.GetSpecObjects(routing, getTestMeta())
+	assert.IsType(t, &RoutingInvalid{}, err, "Should require cluster host suffix to be configured")
+
+	setupSolverTest(t, infrastructure.Kubernetes, &controllerv1alpha1.RoutingConfig{ClusterHostSuffix: "test.suffix"})
+	_, err = solver.GetSpecObjects(routing, getTestMeta())
+	assert.IsType(t, &RoutingInvalid{}, err, "Should require gateway to be configured")
+
+	setupSolverTest(t, infrastructure.Kubernetes, &controllerv1alpha1.RoutingConfig{ClusterHostSuffix: "test.suffix", Gateway: &controllerv1alpha1.GatewayConfig{}})
+	_, err = solver.GetSpecObjects(routing, getTestMeta())
+	assert.IsType(t, &RoutingInvalid{}, err, "Should require gateway name to be configured")
+}
+
+func TestGatewaySolverHTTPRoute(t *testing.T) {
+	setupSolverTest(t, infrastructure.Kubernetes, &controllerv1alpha1.RoutingConfig{
+		ClusterHostSuffix: "test.suffix",
+		Gateway: &controllerv1alpha1.GatewayConfig{
+			Name:        "test-gateway",
+			Namespace:   "gateway-namespace",
+			SectionName: "https",
+		},
+	})
+	solver := &GatewaySolver{}
+	routing := getTestRouting(controllerv1alpha1.DevWorkspaceRoutingGateway, map[string]controllerv1alpha1.EndpointList{
+		"tooling": {
+			{Name: "ide", TargetPort: 3100, Exposure: dw.PublicEndpointExposure},
+			{Name: "debug", TargetPort: 5005, Exposure: dw.InternalEndpointExposure},
+			{Name: "ssh", TargetPort: 22, Exposure: dw.PublicEndpointExposure, Protocol: dw.TCPEndpointProtocol},
+		},
+		"db": {
+			getDedicatedEndpoint(dw.Endpoint{Name: "db-console", TargetPort: 8080, Exposure: dw.PublicEndpointExposure}, "db"),
+		},
+	})
+
+	objs, err := solver.GetSpecObjects(routing, getTestMeta())
+	if !assert.NoError(t, err) || !assert.Len(t, objs.HTTPRoutes, 1, "Should create one HTTPRoute per workspace") {
+		return
+	}
+	httpRoute := objs.HTTPRoutes[0]
+	assert.Equal(t, HTTPRouteGVK, httpRoute.GroupVersionKind())
+	assert.Equal(t, testWorkspaceID, httpRoute.GetName())
+	assert.Equal(t, testNamespace, httpRoute.GetNamespace())
+	assert.Equal(t, testWorkspaceID, httpRoute.GetLabels()[constants.DevWorkspaceIDLabel])
+
+	parentRefs, _, _ := unstructured.NestedSlice(httpRoute.Object, "spec", "parentRefs")
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{
+			"name":        "test-gateway",
+			"namespace":   "gateway-namespace",
+			"sectionName": "https",
+		},
+	}, parentRefs, "HTTPRoute should be attached to configured gateway")
+	hostnames, _, _ := unstructured.NestedStringSlice(httpRoute.Object, "spec", "hostnames")
+	assert.Equal(t, []string{"workspace-id.test.suffix"}, hostnames)
+
+	rules, _, _ := unstructured.NestedSlice(httpRoute.Object, "spec", "rules")
+	backends := map[string]interface{}{}
+	for _, rule := range rules {
+		ruleMap := rule.(map[string]interface{})
+		matches := ruleMap["matches"].([]interface{})
+		pathValue, _, _ := unstructured.NestedString(matches[0].(map[string]interface{}), "path", "value")
+		backendRefs := ruleMap["backendRefs"].([]interface{})
+		backends[pathValue] = backendRefs[0]
+	}
+	assert.Equal(t, map[string]interface{}{
+		"/ide": map[string]interface{}{
+			"name": "workspace-id-service",
+			"port": int64(3100),
+		},
+		"/db-console": map[string]interface{}{
+			"name": "workspace-id-db",
+			"port": int64(8080),
+		},
+	}, backends, "HTTPRoute should only expose public http endpoints, using the service for each endpoint")
+}
+
+func TestGatewaySolverHTTPRouteRuleOrder(t *testing.T) {
+	setupSolverTest(t, infrastructure.Kubernetes, &controllerv1alpha1.RoutingConfig{
+		ClusterHostSuffix: "test.suffix",
+		Gateway:           &controllerv1alpha1.GatewayConfig{Name: "test-gateway"},
+	})
+	solver := &GatewaySolver{}
+	routing := getTestRouting(controllerv1alpha1.DevWorkspaceRoutingGateway, map[string]controllerv1alpha1.EndpointList{
+		"tooling": {
+			{Name: "ide", TargetPort: 3100, Exposure: dw.PublicEndpointExposure},
+			{Name: "docs", TargetPort: 3200, Exposure: dw.PublicEndpointExposure},
+		},
+		"web": {
+			{Name: "web-ui", TargetPort: 4200, Exposure: dw.PublicEndpointExposure},
+			{Name: "api", TargetPort: 8080, Exposure: dw.PublicEndpointExposure},
+		},
+		"db": {
+			getDedicatedEndpoint(dw.Endpoint{Name: "db-console", TargetPort: 8081, Exposure: dw.PublicEndpointExposure}, "db"),
+		},
+	})
+
+	// Map iteration order is random, so generate the HTTPRoute several times to check that rules are always ordered
+	for i := 0; i < 10; i++ {
+		objs, err := solver.GetSpecObjects(routing, getTestMeta())
+		if !assert.NoError(t, err) || !assert.Len(t, objs.HTTPRoutes, 1) {
+			return
+		}
+		rules, _, _ := unstructured.NestedSlice(objs.HTTPRoutes[0].Object, "spec", "rules")
+		var paths []string
+		for _, rule := range rules {
+			matches := rule.(map[string]interface{})["matches"].([]interface{})
+			pathValue, _, _ := unstructured.NestedString(matches[0].(map[string]interface{}), "path", "value")
+			paths = append(paths, pathValue)
+		}
+		assert.Equal(t, []string{"/api", "/db-console", "/docs", "/ide", "/web-ui"}, paths, "HTTPRoute rules should be ordered by endpoint name")
+	}
+}
+
+func TestGatewaySolverNoHTTPRouteWithoutPublicEndpoints(t *testing.T) {
+	setupSolverTest(t, infrastructure.Kubernetes, &controllerv1alpha1.RoutingConfig{ClusterHostSuffix: "test.suffix", Gateway: &controllerv1alpha1.GatewayConfig{Name: "test-gateway"}})
+	solver := &GatewaySolver{}
+	routing := getTestRouting(controllerv1alpha1.DevWorkspaceRoutingGateway, map[string]controllerv1alpha1.EndpointList{
+		"tooling": {{Name: "debug", TargetPort: 5005, Exposure: dw.InternalEndpointExposure}},
+	})
+
+	objs, err := solver.GetSpecObjects(routing, getTestMeta())
+	assert.NoError(t, err)
+	assert.Empty(t, objs.HTTPRoutes, "Should not create HTTPRoute when there are no public endpoints")
+}
+
+func TestGatewaySolverExposedEndpoints(t *testing.T) {
+	tlsEnabled := true
+	setupSolverTest(t, infrastructure.Kubernetes, &controllerv1alpha1.RoutingConfig{ClusterHostSuffix: "test.suffix", Gateway: &controllerv1alpha1.GatewayConfig{Name: "test-gateway", TLS: &tlsEnabled}})
+	solver := &GatewaySolver{}
+	secure := true
+	endpoints := map[string]controllerv1alpha1.EndpointList{
+		"tooling": {
+			{Name: "ide", TargetPort: 3100, Exposure: dw.PublicEndpointExposure, Protocol: dw.HTTPEndpointProtocol, Secure: &secure},
+			{Name: "debug", TargetPort: 5005, Exposure: dw.InternalEndpointExposure},
+		},
+	}
+	objs, err := solver.GetSpecObjects(getTestRouting(controllerv1alpha1.DevWorkspaceRoutingGateway, endpoints), getTestMeta())
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	exposed, ready, err := solver.GetExposedEndpoints(endpoints, objs)
+	assert.NoError(t, err)
+	assert.False(t, ready, "Endpoints should not be ready until HTTPRoute is accepted")
+	if assert.Len(t, exposed["tooling"], 1, "Only public endpoints should be exposed") {
+		assert.Empty(t, exposed["tooling"][0].Url)
+	}
+
+	err = unstructured.SetNestedSlice(objs.HTTPRoutes[0].Object, []interface{}{
+		map[string]interface{}{
+			"conditions": []interface{}{
+				map[string]interface{}{"type": "Accepted", "status": "True"},
+			},
+		},
+	}, "status", "parents")
+	if !assert.NoError(t, err) {
+		return
+	}
+	exposed, ready, err = solver.GetExposedEndpoints(endpoints, objs)
+	assert.NoError(t, err)
+	assert.True(t, ready, "Endpoints should be ready once HTTPRoute is accepted")
+	if assert.Len(t, exposed["tooling"], 1) {
+		assert.Equal(t, "https://workspace-id.test.suffix/ide/", exposed["tooling"][0].Url)
+	}
+}
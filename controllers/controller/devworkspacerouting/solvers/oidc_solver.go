@@ -0,0 +1,377 @@
+//
+// Copyright (c) 2019-2021 Red Hat, Inc.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package solvers
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"path"
+	"strings"
+
+	dw "github.com/devfile/api/v2/pkg/apis/workspaces/v1alpha2"
+	corev1 "k8s.io/api/core/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	controllerv1alpha1 "github.com/devfile/devworkspace-operator/apis/controller/v1alpha1"
+	"github.com/devfile/devworkspace-operator/internal/images"
+	"github.com/devfile/devworkspace-operator/pkg/common"
+	"github.com/devfile/devworkspace-operator/pkg/config"
+	"github.com/devfile/devworkspace-operator/pkg/constants"
+)
+
+const (
+	// oidcProxyBasePort is the first port used by authenticating proxy containers. Each proxied endpoint is
+	// assigned the next port not used by any endpoint in the workspace.
+	oidcProxyBasePort = 4180
+
+	oidcProxyClientSecretKey = "client-secret"
+	oidcProxyCookieSecretKey = "cookie-secret"
+	oidcProxyAllowedUserKey  = "authenticated-emails"
+	oidcDefaultUserClaim     = "sub"
+
+	oidcProxySecretVolumeName = "oidc-proxy"
+	oidcProxySecretMountPath  = "/etc/oidc-proxy"
+
+	oidcProxyMemoryRequest = "32Mi"
+	oidcProxyMemoryLimit   = "128Mi"
+	oidcProxyCPURequest    = "10m"
+)
+
+// OIDCSolver exposes endpoints through Ingresses in the same way as the BasicSolver, but requires requests to public
+// endpoints that are marked secure to be authenticated by an OpenID Connect provider. An authenticating proxy is added
+// to the workspace pod for each such endpoint, and only the creator of the workspace is allowed through.
+//
+// The OIDCSolver creates a secret for each workspace holding the secret used by the proxies to encrypt session cookies
+// and the identity of the user allowed access.
+//
+// Public endpoints that use the tcp or udp protocol cannot be authenticated by the proxy; the OIDCSolver rejects such
+// endpoints if they are marked secure.
+type OIDCSolver struct {
+	client client.Client
+}
+
+var _ RoutingSolver = (*OIDCSolver)(nil)
+
+func (s *OIDCSolver) FinalizerRequired(*controllerv1alpha1.DevWorkspaceRouting) bool {
+	return false
+}
+
+func (s *OIDCSolver) Finalize(*controllerv1alpha1.DevWorkspaceRouting) error {
+	return nil
+}
+
+func (s *OIDCSolver) GetSpecObjects(routing *controllerv1alpha1.DevWorkspaceRouting, workspaceMeta DevWorkspaceMetadata) (RoutingObjects, error) {
+	routingObjects := RoutingObjects{}
+
+	routingSuffix := config.Routing.ClusterHostSuffix
+	if routingSuffix == "" {
+		return routingObjects, &RoutingInvalid{"oidc routing requires .config.routing.clusterHostSuffix to be set in operator config"}
+	}
+	oidcConfig := config.Routing.OIDC
+	if oidcConfig == nil || oidcConfig.IssuerURL == "" || oidcConfig.ClientID == "" || oidcConfig.ClientSecretName == "" {
+		return routingObjects, &RoutingInvalid{"oidc routing requires .config.routing.oidc.issuerURL, clientID, and clientSecretName to be set in operator config"}
+	}
+	tlsConfig := config.Routing.TLS
+	if err := validateTLSConfig(tlsConfig); err != nil {
+		return routingObjects, err
+	}
+	creatorUsername := routing.Annotations[constants.DevWorkspaceCreatorUsernameAnnotation]
+	if creatorUsername == "" {
+		return routingObjects, &RoutingInvalid{fmt.Sprintf("oidc routing requires the DevWorkspace to have the %s annotation", constants.DevWorkspaceCreatorUsernameAnnotation)}
+	}
+	proxyImage := images.GetOIDCProxyImage()
+	if proxyImage == "" {
+		return routingObjects, &RoutingInvalid{"could not determine image to use for the OIDC proxy"}
+	}
+
+	spec := routing.Spec
+	// Non-HTTP endpoints are exposed directly through an external service, so they cannot be authenticated by the proxy
+	for _, machineEndpoints := range spec.Endpoints {
+		for _, endpoint := range machineEndpoints {
+			if endpoint.Exposure == dw.PublicEndpointExposure && isNonHTTPEndpoint(endpoint) && endpoint.Secure != nil && *endpoint.Secure {
+				return routingObjects, &RoutingInvalid{fmt.Sprintf("endpoint %s is marked secure but uses the %s protocol; oidc routing can only secure http endpoints", endpoint.Name, endpoint.Protocol)}
+			}
+		}
+	}
+	proxyPorts := getOIDCProxyPorts(spec.Endpoints)
+	if len(proxyPorts) > 0 {
+		allowedUser := strings.TrimPrefix(creatorUsername, oidcConfig.UsernamePrefix)
+		if err := s.syncOIDCProxySecret(routing, workspaceMeta, allowedUser); err != nil {
+			return routingObjects, err
+		}
+	}
+	services := getServicesForEndpoints(spec.Endpoints, workspaceMeta)
+	podAdditions := &controllerv1alpha1.PodAdditions{}
+	for _, endpoint := range getPublicEndpoints(spec.Endpoints) {
+		proxyPort, proxied := proxyPorts[endpoint.Name]
+		if !proxied {
+			routingObjects.Ingresses = append(routingObjects.Ingresses, getIngressForEndpoint(routingSuffix, tlsConfig, endpoint, workspaceMeta))
+			continue
+		}
+		hostname := common.EndpointHostname(routingSuffix, workspaceMeta.DevWorkspaceId, common.EndpointName(endpoint.Name), endpoint.TargetPort)
+		podAdditions.Containers = append(podAdditions.Containers,
+			getOIDCProxyContainer(proxyImage, oidcConfig, tlsConfig != nil, hostname, getOIDCProxyUpstream(endpoint, workspaceMeta), proxyPort, workspaceMeta))
+		for idx := range services {
+			if services[idx].Name == common.ServiceName(workspaceMeta.DevWorkspaceId) {
+				removeServicePort(&services[idx], endpoint, spec.Endpoints, proxyPorts)
+				services[idx].Spec.Ports = append(services[idx].Spec.Ports, corev1.ServicePort{
+					Name:       fmt.Sprintf("oidc-%d", proxyPort),
+					Protocol:   corev1.ProtocolTCP,
+					Port:       int32(proxyPort),
+					TargetPort: intstr.FromInt(proxyPort),
+				})
+			}
+		}
+		// Route traffic for the endpoint through the proxy rather than directly to the endpoint's port
+		ingress := getIngressForEndpoint(routingSuffix, tlsConfig, endpoint, workspaceMeta)
+		ingress.Spec.Rules[0].HTTP.Paths[0].Backend.Service.Name = common.ServiceName(workspaceMeta.DevWorkspaceId)
+		ingress.Spec.Rules[0].HTTP.Paths[0].Backend.Service.Port.Number = int32(proxyPort)
+		routingObjects.Ingresses = append(routingObjects.Ingresses, ingress)
+	}
+	services = append(services, GetDiscoverableServicesForEndpoints(spec.Endpoints, workspaceMeta)...)
+	externalServices, err := getExternalServicesForEndpoints(spec.Endpoints, workspaceMeta)
+	if err != nil {
+		return routingObjects, err
+	}
+	services = append(services, externalServices...)
+	routingObjects.Services = services
+	if len(podAdditions.Containers) > 0 {
+		podAdditions.Volumes = append(podAdditions.Volumes, corev1.Volume{
+			Name: oidcProxySecretVolumeName,
+			VolumeSource: corev1.VolumeSource{
+				Secret: &corev1.SecretVolumeSource{
+					SecretName: common.OIDCProxySecretName(workspaceMeta.DevWorkspaceId),
+				},
+			},
+		})
+		routingObjects.PodAdditions = podAdditions
+	}
+
+	return routingObjects, nil
+}
+
+func (s *OIDCSolver) GetExposedEndpoints(
+	endpoints map[string]controllerv1alpha1.EndpointList,
+	routingObj RoutingObjects) (exposedEndpoints map[string]controllerv1alpha1.ExposedEndpointList, ready bool, err error) {
+	return getExposedEndpoints(endpoints, routingObj)
+}
+
+// getOIDCProxyPorts assigns a port to the authenticating proxy for each public endpoint marked secure, returning a
+// map of endpoint names to proxy ports. Ports are assigned in endpoint name order, skipping any port used by an
+// endpoint in the workspace.
+func getOIDCProxyPorts(endpoints map[string]controllerv1alpha1.EndpointList) map[string]int {
+	usedPorts := map[int]bool{}
+	for _, machineEndpoints := range endpoints {
+		for _, endpoint := range machineEndpoints {
+			usedPorts[endpoint.TargetPort] = true
+		}
+	}
+	proxyPorts := map[string]int{}
+	nextPort := oidcProxyBasePort
+	for _, endpoint := range getPublicEndpoints(endpoints) {
+		if endpoint.Secure == nil || !*endpoint.Secure {
+			continue
+		}
+		for usedPorts[nextPort] {
+			nextPort++
+		}
+		proxyPorts[endpoint.Name] = nextPort
+		usedPorts[nextPort] = true
+	}
+	return proxyPorts
+}
+
+// getOIDCProxyUpstream returns the URL the authenticating proxy for an endpoint forwards requests to. Proxies run in the
+// main workspace pod, so endpoints of dedicatedPod components are reached through the component's Service.
+func getOIDCProxyUpstream(endpoint dw.Endpoint, meta DevWorkspaceMetadata) string {
+	host := "127.0.0.1"
+	if getDedicatedPodComponent(endpoint) != "" {
+		host = getEndpointServiceName(endpoint, meta)
+	}
+	return fmt.Sprintf("http://%s:%d/", host, endpoint.TargetPort)
+}
+
+// removeServicePort removes the port used by a proxied endpoint from the workspace's service, so that requests can only
+// reach the endpoint through the authenticating proxy. The port is kept if it is also used by an endpoint that is not
+// proxied.
+func removeServicePort(service *corev1.Service, proxiedEndpoint dw.Endpoint, endpoints map[string]controllerv1alpha1.EndpointList, proxyPorts map[string]int) {
+	if getDedicatedPodComponent(proxiedEndpoint) != "" {
+		return
+	}
+	for _, machineEndpoints := range endpoints {
+		for _, endpoint := range machineEndpoints {
+			if _, proxied := proxyPorts[endpoint.Name]; proxied || getDedicatedPodComponent(endpoint) != "" {
+				continue
+			}
+			if endpoint.TargetPort == proxiedEndpoint.TargetPort && endpoint.Exposure != dw.NoneEndpointExposure {
+				return
+			}
+		}
+	}
+	var ports []corev1.ServicePort
+	for _, port := range service.Spec.Ports {
+		if port.Port != int32(proxiedEndpoint.TargetPort) {
+			ports = append(ports, port)
+		}
+	}
+	service.Spec.Ports = ports
+}
+
+// syncOIDCProxySecret ensures the secret used by the authenticating proxies in a workspace exists. The secret stores
+// the secret used to encrypt session cookies, which is generated when the secret is created, and the identity of the
+// only user allowed access to the workspace's endpoints.
+func (s *OIDCSolver) syncOIDCProxySecret(routing *controllerv1alpha1.DevWorkspaceRouting, meta DevWorkspaceMetadata, allowedUser string) error {
+	clusterSecret := &corev1.Secret{}
+	namespacedName := types.NamespacedName{Name: common.OIDCProxySecretName(meta.DevWorkspaceId), Namespace: meta.Namespace}
+	err := s.client.Get(context.TODO(), namespacedName, clusterSecret)
+	if err == nil {
+		if string(clusterSecret.Data[oidcProxyAllowedUserKey]) == allowedUser+"\n" {
+			return nil
+		}
+		if clusterSecret.Data == nil {
+			clusterSecret.Data = map[string][]byte{}
+		}
+		clusterSecret.Data[oidcProxyAllowedUserKey] = []byte(allowedUser + "\n")
+		return s.client.Update(context.TODO(), clusterSecret)
+	}
+	if !k8sErrors.IsNotFound(err) {
+		return err
+	}
+
+	cookieSecret, err := generateCookieSecret()
+	if err != nil {
+		return err
+	}
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      namespacedName.Name,
+			Namespace: namespacedName.Namespace,
+			Labels: map[string]string{
+				constants.DevWorkspaceIDLabel:          meta.DevWorkspaceId,
+				constants.DevWorkspaceWatchSecretLabel: "true",
+			},
+		},
+		Data: map[string][]byte{
+			oidcProxyCookieSecretKey: cookieSecret,
+			// The proxy only allows users whose identity is listed in this file
+			oidcProxyAllowedUserKey: []byte(allowedUser + "\n"),
+		},
+		Type: corev1.SecretTypeOpaque,
+	}
+	if restrictedAccess, ok := routing.Annotations[constants.DevWorkspaceRestrictedAccessAnnotation]; ok {
+		secret.Annotations = map[string]string{
+			constants.DevWorkspaceRestrictedAccessAnnotation: restrictedAccess,
+		}
+	}
+	if err := controllerutil.SetControllerReference(routing, secret, s.client.Scheme()); err != nil {
+		return err
+	}
+	err = s.client.Create(context.TODO(), secret)
+	if k8sErrors.IsAlreadyExists(err) {
+		// Secret was created but is not yet visible to the client; retry once it is
+		return &RoutingNotReady{}
+	}
+	return err
+}
+
+// generateCookieSecret returns a random 32 byte secret suitable for encrypting the proxy's session cookies.
+func generateCookieSecret() ([]byte, error) {
+	randBytes := make([]byte, 16)
+	if _, err := rand.Read(randBytes); err != nil {
+		return nil, err
+	}
+	return []byte(hex.EncodeToString(randBytes)), nil
+}
+
+func getOIDCProxyContainer(image string, oidcConfig *controllerv1alpha1.OIDCConfig, tls bool, hostname, upstream string, proxyPort int, meta DevWorkspaceMetadata) corev1.Container {
+	scheme := "http"
+	if tls {
+		scheme = "https"
+	}
+	userClaim := oidcConfig.UserClaim
+	if userClaim == "" {
+		userClaim = oidcDefaultUserClaim
+	}
+	secretKeyRef := func(secretName, key string) *corev1.EnvVarSource {
+		return &corev1.EnvVarSource{
+			SecretKeyRef: &corev1.SecretKeySelector{
+				LocalObjectReference: corev1.LocalObjectReference{Name: secretName},
+				Key:                  key,
+			},
+		}
+	}
+	return corev1.Container{
+		Name:  fmt.Sprintf("oidc-proxy-%d", proxyPort),
+		Image: image,
+		Args: []string{
+			"--provider=oidc",
+			fmt.Sprintf("--oidc-issuer-url=%s", oidcConfig.IssuerURL),
+			fmt.Sprintf("--client-id=%s", oidcConfig.ClientID),
+			fmt.Sprintf("--http-address=0.0.0.0:%d", proxyPort),
+			fmt.Sprintf("--upstream=%s", upstream),
+			fmt.Sprintf("--redirect-url=%s://%s/oauth2/callback", scheme, hostname),
+			fmt.Sprintf("--cookie-secure=%t", tls),
+			// Only the creator of the workspace is allowed access: the claim identifying the user is used as the
+			// user's email, and the creator is the only entry in the list of allowed emails.
+			fmt.Sprintf("--oidc-email-claim=%s", userClaim),
+			fmt.Sprintf("--authenticated-emails-file=%s", path.Join(oidcProxySecretMountPath, oidcProxyAllowedUserKey)),
+			"--skip-provider-button",
+			"--reverse-proxy",
+		},
+		Env: []corev1.EnvVar{
+			{
+				Name:      "OAUTH2_PROXY_CLIENT_SECRET",
+				ValueFrom: secretKeyRef(oidcConfig.ClientSecretName, oidcProxyClientSecretKey),
+			},
+			{
+				Name:      "OAUTH2_PROXY_COOKIE_SECRET",
+				ValueFrom: secretKeyRef(common.OIDCProxySecretName(meta.DevWorkspaceId), oidcProxyCookieSecretKey),
+			},
+		},
+		VolumeMounts: []corev1.VolumeMount{
+			{
+				Name:      oidcProxySecretVolumeName,
+				MountPath: oidcProxySecretMountPath,
+				ReadOnly:  true,
+			},
+		},
+		Ports: []corev1.ContainerPort{
+			{
+				Name:          fmt.Sprintf("oidc-%d", proxyPort),
+				ContainerPort: int32(proxyPort),
+				Protocol:      corev1.ProtocolTCP,
+			},
+		},
+		Resources: corev1.ResourceRequirements{
+			Limits: corev1.ResourceList{
+				corev1.ResourceMemory: resource.MustParse(oidcProxyMemoryLimit),
+			},
+			Requests: corev1.ResourceList{
+				corev1.ResourceMemory: resource.MustParse(oidcProxyMemoryRequest),
+				corev1.ResourceCPU:    resource.MustParse(oidcProxyCPURequest),
+			},
+		},
+		ImagePullPolicy: corev1.PullPolicy(config.Workspace.ImagePullPolicy),
+	}
+}
@@ -0,0 +1,204 @@
+//
+// Copyright (c) 2019-2021 Red Hat, Inc.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package solvers
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	dw "github.com/devfile/api/v2/pkg/apis/workspaces/v1alpha2"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	controllerv1alpha1 "github.com/devfile/devworkspace-operator/apis/controller/v1alpha1"
+	"github.com/devfile/devworkspace-operator/pkg/infrastructure"
+)
+
+func getTestOIDCSolver(t *testing.T) *OIDCSolver {
+	setupSolverTest(t, infrastructure.Kubernetes, &controllerv1alpha1.RoutingConfig{
+		ClusterHostSuffix: "test.suffix",
+		OIDC: &controllerv1alpha1.OIDCConfig{
+			IssuerURL:        "https://issuer.example.com",
+			ClientID:         "devworkspaces",
+			ClientSecretName: "devworkspace-oidc",
+			UserClaim:        "email",
+			UsernamePrefix:   "oidc:",
+		},
+	})
+	if err := os.Setenv("RELATED_IMAGE_oidc_proxy", "test-image"); err != nil {
+		t.Fatalf("Failure during setup: %s", err)
+	}
+	t.Cleanup(func() { os.Unsetenv("RELATED_IMAGE_oidc_proxy") })
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		t.Fatalf("Failure during setup: %s", err)
+	}
+	if err := controllerv1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatalf("Failure during setup: %s", err)
+	}
+	return &OIDCSolver{client: fake.NewClientBuilder().WithScheme(scheme).Build()}
+}
+
+func getOIDCTestSecret(t *testing.T, solver *OIDCSolver, workspaceId string) *corev1.Secret {
+	secret := &corev1.Secret{}
+	namespacedName := types.NamespacedName{Name: workspaceId + "-oidc-proxy", Namespace: testNamespace}
+	if err := solver.client.Get(context.Background(), namespacedName, secret); err != nil {
+		t.Fatalf("Failed to get OIDC proxy secret: %s", err)
+	}
+	return secret
+}
+
+func TestOIDCSolverRequiresCreatorUsername(t *testing.T) {
+	solver := getTestOIDCSolver(t)
+	routing := getTestRouting(controllerv1alpha1.DevWorkspaceRoutingOIDC, map[string]controllerv1alpha1.EndpointList{
+		"tooling": {{Name: "ide", TargetPort: 3100, Exposure: dw.PublicEndpointExposure}},
+	})
+	routing.Annotations = nil
+
+	_, err := solver.GetSpecObjects(routing, getTestMeta())
+	assert.IsType(t, &RoutingInvalid{}, err, "Should require the creator username annotation")
+}
+
+func TestOIDCSolverProxiesSecureEndpoints(t *testing.T) {
+	solver := getTestOIDCSolver(t)
+	secure := true
+	routing := getTestRouting(controllerv1alpha1.DevWorkspaceRoutingOIDC, map[string]controllerv1alpha1.EndpointList{
+		"tooling": {
+			{Name: "ide", TargetPort: 3100, Exposure: dw.PublicEndpointExposure, Secure: &secure},
+			{Name: "docs", TargetPort: 8080, Exposure: dw.PublicEndpointExposure},
+			{Name: "debug", TargetPort: 5005, Exposure: dw.InternalEndpointExposure},
+		},
+	})
+
+	objs, err := solver.GetSpecObjects(routing, getTestMeta())
+	if !assert.NoError(t, err) || !assert.NotNil(t, objs.PodAdditions, "Should add proxy to workspace pod") {
+		return
+	}
+	if assert.Len(t, objs.PodAdditions.Containers, 1, "Should add one proxy for secure endpoint") {
+		proxy := objs.PodAdditions.Containers[0]
+		assert.Contains(t, proxy.Args, "--upstream=http://127.0.0.1:3100/")
+		assert.Contains(t, proxy.Args, "--oidc-email-claim=email", "Proxy should identify users by the configured claim")
+		assert.Contains(t, proxy.Args, "--authenticated-emails-file=/etc/oidc-proxy/authenticated-emails", "Proxy should only allow the workspace creator")
+		assert.NotContains(t, proxy.Args, "--email-domain=*", "Proxy should not allow all users")
+		for _, env := range proxy.Env {
+			if env.Name == "OAUTH2_PROXY_COOKIE_SECRET" {
+				assert.Equal(t, "workspace-id-oidc-proxy", env.ValueFrom.SecretKeyRef.Name, "Cookie secret should be read from workspace's secret")
+			}
+		}
+		if assert.Len(t, proxy.VolumeMounts, 1) {
+			assert.Equal(t, "/etc/oidc-proxy", proxy.VolumeMounts[0].MountPath)
+		}
+	}
+	if assert.Len(t, objs.PodAdditions.Volumes, 1) {
+		assert.Equal(t, "workspace-id-oidc-proxy", objs.PodAdditions.Volumes[0].Secret.SecretName)
+	}
+
+	ingressBackends := map[string]int32{}
+	for _, ingress := range objs.Ingresses {
+		ingressBackends[ingress.Name] = ingress.Spec.Rules[0].HTTP.Paths[0].Backend.Service.Port.Number
+	}
+	assert.Equal(t, map[string]int32{
+		"workspace-id-ide":  4180,
+		"workspace-id-docs": 8080,
+	}, ingressBackends, "Secure endpoints should be routed through proxy")
+
+	if assert.Len(t, objs.Services, 1) {
+		var ports []int32
+		for _, port := range objs.Services[0].Spec.Ports {
+			ports = append(ports, port.Port)
+		}
+		assert.ElementsMatch(t, []int32{8080, 5005, 4180}, ports, "Workspace service should not expose port of proxied endpoint")
+	}
+
+	secret := getOIDCTestSecret(t, solver, testWorkspaceID)
+	assert.Equal(t, "user@example.com\n", string(secret.Data["authenticated-emails"]), "Username prefix should be removed from allowed user")
+	assert.Len(t, secret.Data["cookie-secret"], 32, "Cookie secret should be 32 bytes")
+	if assert.Len(t, secret.OwnerReferences, 1) {
+		assert.Equal(t, routing.UID, secret.OwnerReferences[0].UID, "Secret should be owned by DevWorkspaceRouting")
+	}
+
+	// The cookie secret should not change once created
+	_, err = solver.GetSpecObjects(routing, getTestMeta())
+	assert.NoError(t, err)
+	assert.Equal(t, secret.Data["cookie-secret"], getOIDCTestSecret(t, solver, testWorkspaceID).Data["cookie-secret"],
+		"Cookie secret should not be regenerated")
+}
+
+func TestOIDCSolverRejectsSecureNonHTTPEndpoints(t *testing.T) {
+	solver := getTestOIDCSolver(t)
+	secure := true
+	routing := getTestRouting(controllerv1alpha1.DevWorkspaceRoutingOIDC, map[string]controllerv1alpha1.EndpointList{
+		"tooling": {
+			{Name: "ide", TargetPort: 3100, Exposure: dw.PublicEndpointExposure, Secure: &secure},
+			{Name: "ssh", TargetPort: 22, Exposure: dw.PublicEndpointExposure, Protocol: dw.TCPEndpointProtocol, Secure: &secure},
+		},
+	})
+
+	_, err := solver.GetSpecObjects(routing, getTestMeta())
+	assert.IsType(t, &RoutingInvalid{}, err, "Should not expose secure tcp endpoint without authentication")
+
+	routing.Spec.Endpoints["tooling"][1].Secure = nil
+	objs, err := solver.GetSpecObjects(routing, getTestMeta())
+	if assert.NoError(t, err, "Should expose tcp endpoint that is not marked secure") {
+		assert.Len(t, objs.Services, 2, "Should create external service for tcp endpoint")
+	}
+}
+
+func TestOIDCSolverCookieSecretIsPerWorkspace(t *testing.T) {
+	solver := getTestOIDCSolver(t)
+	secure := true
+	endpoints := map[string]controllerv1alpha1.EndpointList{
+		"tooling": {{Name: "ide", TargetPort: 3100, Exposure: dw.PublicEndpointExposure, Secure: &secure}},
+	}
+	for _, workspaceId := range []string{"workspace-a", "workspace-b"} {
+		meta := getTestMeta()
+		meta.DevWorkspaceId = workspaceId
+		routing := getTestRouting(controllerv1alpha1.DevWorkspaceRoutingOIDC, endpoints)
+		routing.Name = workspaceId
+		routing.UID = types.UID(workspaceId + "-uid")
+		routing.Spec.DevWorkspaceId = workspaceId
+		_, err := solver.GetSpecObjects(routing, meta)
+		assert.NoError(t, err)
+	}
+	assert.NotEqual(t, getOIDCTestSecret(t, solver, "workspace-a").Data["cookie-secret"], getOIDCTestSecret(t, solver, "workspace-b").Data["cookie-secret"],
+		"Each workspace should use a different cookie secret")
+}
+
+func TestOIDCSolverKeepsPortSharedWithUnproxiedEndpoint(t *testing.T) {
+	solver := getTestOIDCSolver(t)
+	secure := true
+	routing := getTestRouting(controllerv1alpha1.DevWorkspaceRoutingOIDC, map[string]controllerv1alpha1.EndpointList{
+		"tooling": {
+			{Name: "ide", TargetPort: 3100, Exposure: dw.PublicEndpointExposure, Secure: &secure},
+			{Name: "ide-internal", TargetPort: 3100, Exposure: dw.InternalEndpointExposure},
+		},
+	})
+
+	objs, err := solver.GetSpecObjects(routing, getTestMeta())
+	if !assert.NoError(t, err) || !assert.Len(t, objs.Services, 1) {
+		return
+	}
+	var ports []int32
+	for _, port := range objs.Services[0].Spec.Ports {
+		ports = append(ports, port.Port)
+	}
+	assert.Contains(t, ports, int32(3100), "Port used by endpoint that is not proxied should be kept")
+}
@@ -17,12 +17,18 @@ package solvers
 
 import (
 	"fmt"
+	"net"
 	"net/url"
+	"strconv"
 	"strings"
 
 	dw "github.com/devfile/api/v2/pkg/apis/workspaces/v1alpha2"
 
+	corev1 "k8s.io/api/core/v1"
+
 	controllerv1alpha1 "github.com/devfile/devworkspace-operator/apis/controller/v1alpha1"
+	"github.com/devfile/devworkspace-operator/pkg/common"
+	"github.com/devfile/devworkspace-operator/pkg/config"
 	"github.com/devfile/devworkspace-operator/pkg/constants"
 )
 
@@ -58,6 +64,9 @@ func getExposedEndpoints(
 func resolveURLForEndpoint(
 	endpoint dw.Endpoint,
 	routingObj RoutingObjects) (string, error) {
+	if isNonHTTPEndpoint(endpoint) {
+		return resolveExternalURLForEndpoint(endpoint, routingObj.Services)
+	}
 	for _, route := range routingObj.Routes {
 		if route.Annotations[constants.DevWorkspaceEndpointNameAnnotation] == endpoint.Name {
 			return getURLForEndpoint(endpoint, route.Spec.Host, route.Spec.Path, route.Spec.TLS != nil), nil
@@ -66,7 +75,8 @@ func resolveURLForEndpoint(
 	for _, ingress := range routingObj.Ingresses {
 		if ingress.Annotations[constants.DevWorkspaceEndpointNameAnnotation] == endpoint.Name {
 			if len(ingress.Spec.Rules) == 1 {
-				return getURLForEndpoint(endpoint, ingress.Spec.Rules[0].Host, "", false), nil // no TLS supported for ingresses yet
+				basePath := ingress.Annotations[constants.DevWorkspaceEndpointPathAnnotation]
+				return getURLForEndpoint(endpoint, ingress.Spec.Rules[0].Host, basePath, len(ingress.Spec.TLS) > 0), nil
 			} else {
 				return "", fmt.Errorf("ingress %s contains multiple rules", ingress.Name)
 			}
@@ -75,6 +85,52 @@ func resolveURLForEndpoint(
 	return "", fmt.Errorf("could not find ingress/route for endpoint '%s'", endpoint.Name)
 }
 
+// resolveExternalURLForEndpoint returns the URL of a non-HTTP endpoint exposed through a LoadBalancer or NodePort
+// service, of the form <protocol>://<host>:<port>. Returns an empty string if the host or port has not yet been
+// assigned to the service.
+func resolveExternalURLForEndpoint(endpoint dw.Endpoint, services []corev1.Service) (string, error) {
+	portName := common.EndpointName(endpoint.Name)
+	for _, service := range services {
+		for _, servicePort := range service.Spec.Ports {
+			if servicePort.Name != portName {
+				continue
+			}
+			var host string
+			var port int32
+			switch service.Spec.Type {
+			case corev1.ServiceTypeLoadBalancer:
+				for _, lbIngress := range service.Status.LoadBalancer.Ingress {
+					if lbIngress.Hostname != "" {
+						host = lbIngress.Hostname
+					} else {
+						host = lbIngress.IP
+					}
+					if host != "" {
+						break
+					}
+				}
+				port = servicePort.Port
+			case corev1.ServiceTypeNodePort:
+				if config.Routing.NonHTTPEndpoints != nil {
+					host = config.Routing.NonHTTPEndpoints.NodePortHost
+				}
+				port = servicePort.NodePort
+			default:
+				continue
+			}
+			if host == "" || port == 0 {
+				return "", nil
+			}
+			u := url.URL{
+				Scheme: string(endpoint.Protocol),
+				Host:   net.JoinHostPort(host, strconv.Itoa(int(port))),
+			}
+			return u.String(), nil
+		}
+	}
+	return "", fmt.Errorf("could not find LoadBalancer or NodePort service for endpoint '%s'", endpoint.Name)
+}
+
 func getURLForEndpoint(endpoint dw.Endpoint, host, basePath string, secure bool) string {
 	protocol := endpoint.Protocol
 	if secure && endpoint.Secure != nil && *endpoint.Secure {
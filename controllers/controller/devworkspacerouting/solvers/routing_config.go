@@ -0,0 +1,157 @@
+//
+// Copyright (c) 2019-2021 Red Hat, Inc.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package solvers
+
+import (
+	"fmt"
+	"strings"
+
+	dw "github.com/devfile/api/v2/pkg/apis/workspaces/v1alpha2"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	controllerv1alpha1 "github.com/devfile/devworkspace-operator/apis/controller/v1alpha1"
+	"github.com/devfile/devworkspace-operator/pkg/common"
+	"github.com/devfile/devworkspace-operator/pkg/config"
+	"github.com/devfile/devworkspace-operator/pkg/constants"
+)
+
+const (
+	ingressClassAnnotation = "kubernetes.io/ingress.class"
+	// reservedMetadataPrefix is the prefix of labels and annotations that are managed by the DevWorkspace Operator
+	// and cannot be overridden through configuration.
+	reservedMetadataPrefix = "controller.devfile.io/"
+)
+
+// endpointRoutingConfig stores the configuration applied to the Ingress or Route exposing a single endpoint.
+type endpointRoutingConfig struct {
+	ingressClassName string
+	annotations      map[string]string
+	labels           map[string]string
+}
+
+// ApplyRoutingConfig applies the ingress class, annotations, and labels specified in the DevWorkspace Operator
+// configuration and in endpoint attributes to the Services, Ingresses, and Routes in routingObjects. Ingresses and
+// Routes are matched to endpoints using the endpoint name annotation. Returns a RoutingInvalid error if an endpoint
+// specifies invalid attributes, or an ingress class or annotation that is not allowed by the DevWorkspace Operator
+// configuration.
+func ApplyRoutingConfig(endpoints map[string]controllerv1alpha1.EndpointList, routingObjects *RoutingObjects) error {
+	endpointConfigs := map[string]*endpointRoutingConfig{}
+	for _, machineEndpoints := range endpoints {
+		for _, endpoint := range machineEndpoints {
+			endpointConfig, err := getEndpointRoutingConfig(endpoint)
+			if err != nil {
+				return err
+			}
+			// Solvers may use either the endpoint's name or its sanitized name in the endpoint name annotation
+			endpointConfigs[endpoint.Name] = endpointConfig
+			endpointConfigs[common.EndpointName(endpoint.Name)] = endpointConfig
+		}
+	}
+
+	for idx := range routingObjects.Services {
+		applyRoutingMetadata(&routingObjects.Services[idx].ObjectMeta, config.Routing.Labels, config.Routing.Annotations)
+	}
+	for idx := range routingObjects.Ingresses {
+		ingress := &routingObjects.Ingresses[idx]
+		endpointConfig := endpointConfigs[ingress.Annotations[constants.DevWorkspaceEndpointNameAnnotation]]
+		ingressClassName := config.Routing.IngressClassName
+		if endpointConfig != nil && endpointConfig.ingressClassName != "" {
+			ingressClassName = endpointConfig.ingressClassName
+		}
+		if ingressClassName != "" {
+			delete(ingress.Annotations, ingressClassAnnotation)
+			ingress.Spec.IngressClassName = &ingressClassName
+		}
+		applyRoutingMetadata(&ingress.ObjectMeta, config.Routing.Labels, config.Routing.Annotations)
+		if endpointConfig != nil {
+			applyRoutingMetadata(&ingress.ObjectMeta, endpointConfig.labels, endpointConfig.annotations)
+		}
+	}
+	for idx := range routingObjects.Routes {
+		route := &routingObjects.Routes[idx]
+		applyRoutingMetadata(&route.ObjectMeta, config.Routing.Labels, config.Routing.Annotations)
+		if endpointConfig := endpointConfigs[route.Annotations[constants.DevWorkspaceEndpointNameAnnotation]]; endpointConfig != nil {
+			applyRoutingMetadata(&route.ObjectMeta, endpointConfig.labels, endpointConfig.annotations)
+		}
+	}
+	return nil
+}
+
+func getEndpointRoutingConfig(endpoint dw.Endpoint) (*endpointRoutingConfig, error) {
+	endpointConfig := &endpointRoutingConfig{}
+	if endpoint.Attributes == nil {
+		return endpointConfig, nil
+	}
+	if endpoint.Attributes.Exists(constants.IngressClassNameAttribute) {
+		var err error
+		endpointConfig.ingressClassName = endpoint.Attributes.GetString(constants.IngressClassNameAttribute, &err)
+		if err != nil {
+			return nil, &RoutingInvalid{fmt.Sprintf("failed to read attribute %s on endpoint %s: %s", constants.IngressClassNameAttribute, endpoint.Name, err)}
+		}
+	}
+	if endpoint.Attributes.Exists(constants.RoutingAnnotationsAttribute) {
+		if err := endpoint.Attributes.GetInto(constants.RoutingAnnotationsAttribute, &endpointConfig.annotations); err != nil {
+			return nil, &RoutingInvalid{fmt.Sprintf("failed to read attribute %s on endpoint %s: %s", constants.RoutingAnnotationsAttribute, endpoint.Name, err)}
+		}
+	}
+	if endpointConfig.ingressClassName != "" && endpointConfig.ingressClassName != config.Routing.IngressClassName &&
+		!contains(config.Routing.AllowedEndpointIngressClasses, endpointConfig.ingressClassName) {
+		return nil, &RoutingInvalid{fmt.Sprintf("ingress class %s set on endpoint %s is not allowed by the DevWorkspace Operator configuration", endpointConfig.ingressClassName, endpoint.Name)}
+	}
+	for key := range endpointConfig.annotations {
+		if !contains(config.Routing.AllowedEndpointAnnotations, key) {
+			return nil, &RoutingInvalid{fmt.Sprintf("annotation %s set on endpoint %s is not allowed by the DevWorkspace Operator configuration", key, endpoint.Name)}
+		}
+	}
+	if endpoint.Attributes.Exists(constants.RoutingLabelsAttribute) {
+		if err := endpoint.Attributes.GetInto(constants.RoutingLabelsAttribute, &endpointConfig.labels); err != nil {
+			return nil, &RoutingInvalid{fmt.Sprintf("failed to read attribute %s on endpoint %s: %s", constants.RoutingLabelsAttribute, endpoint.Name, err)}
+		}
+	}
+	return endpointConfig, nil
+}
+
+// applyRoutingMetadata adds labels and annotations to objMeta, overriding any existing values. Keys with the
+// reservedMetadataPrefix prefix are ignored.
+func applyRoutingMetadata(objMeta *metav1.ObjectMeta, labels, annotations map[string]string) {
+	for k, v := range labels {
+		if strings.HasPrefix(k, reservedMetadataPrefix) {
+			continue
+		}
+		if objMeta.Labels == nil {
+			objMeta.Labels = map[string]string{}
+		}
+		objMeta.Labels[k] = v
+	}
+	for k, v := range annotations {
+		if strings.HasPrefix(k, reservedMetadataPrefix) {
+			continue
+		}
+		if objMeta.Annotations == nil {
+			objMeta.Annotations = map[string]string{}
+		}
+		objMeta.Annotations[k] = v
+	}
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,203 @@
+//
+// Copyright (c) 2019-2021 Red Hat, Inc.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package solvers
+
+import (
+	"testing"
+
+	dw "github.com/devfile/api/v2/pkg/apis/workspaces/v1alpha2"
+	"github.com/devfile/api/v2/pkg/attributes"
+	"github.com/stretchr/testify/assert"
+
+	controllerv1alpha1 "github.com/devfile/devworkspace-operator/apis/controller/v1alpha1"
+	"github.com/devfile/devworkspace-operator/pkg/constants"
+	"github.com/devfile/devworkspace-operator/pkg/infrastructure"
+)
+
+func getRoutingConfigTestConfig() *controllerv1alpha1.RoutingConfig {
+	return &controllerv1alpha1.RoutingConfig{
+		ClusterHostSuffix: "test.suffix",
+		IngressClassName:  "config-class",
+		Annotations: map[string]string{
+			"config-annotation":                          "config-value",
+			"overridden-annotation":                      "config-value",
+			constants.DevWorkspaceEndpointNameAnnotation: "reserved",
+		},
+		Labels: map[string]string{
+			"config-label":                "config-value",
+			constants.DevWorkspaceIDLabel: "reserved",
+		},
+		AllowedEndpointIngressClasses: []string{"endpoint-class"},
+		AllowedEndpointAnnotations:    []string{"overridden-annotation"},
+	}
+}
+
+func getRoutingConfigTestEndpoints() map[string]controllerv1alpha1.EndpointList {
+	var err error
+	customEndpoint := dw.Endpoint{
+		Name:       "custom",
+		TargetPort: 8080,
+		Exposure:   dw.PublicEndpointExposure,
+		Attributes: attributes.Attributes{}.
+			PutString(constants.IngressClassNameAttribute, "endpoint-class").
+			Put(constants.RoutingAnnotationsAttribute, map[string]string{"overridden-annotation": "endpoint-value"}, &err).
+			Put(constants.RoutingLabelsAttribute, map[string]string{"endpoint-label": "endpoint-value"}, &err),
+	}
+	if err != nil {
+		panic(err)
+	}
+	return map[string]controllerv1alpha1.EndpointList{
+		"tooling": {
+			{Name: "ide", TargetPort: 3100, Exposure: dw.PublicEndpointExposure},
+			customEndpoint,
+		},
+	}
+}
+
+func TestApplyRoutingConfigToIngresses(t *testing.T) {
+	setupSolverTest(t, infrastructure.Kubernetes, getRoutingConfigTestConfig())
+	endpoints := getRoutingConfigTestEndpoints()
+	routingObjects, err := (&BasicSolver{}).GetSpecObjects(getTestRouting(controllerv1alpha1.DevWorkspaceRoutingBasic, endpoints), getTestMeta())
+	if !assert.NoError(t, err) {
+		return
+	}
+	if !assert.NoError(t, ApplyRoutingConfig(endpoints, &routingObjects)) {
+		return
+	}
+	if !assert.Len(t, routingObjects.Ingresses, 2) {
+		return
+	}
+	for _, ingress := range routingObjects.Ingresses {
+		endpointName := ingress.Annotations[constants.DevWorkspaceEndpointNameAnnotation]
+		assert.NotContains(t, ingress.Annotations, ingressClassAnnotation, "Ingress class annotation should be replaced by ingressClassName")
+		assert.Equal(t, "config-value", ingress.Annotations["config-annotation"], "Should apply annotations from config")
+		assert.Equal(t, "config-value", ingress.Labels["config-label"], "Should apply labels from config")
+		assert.Equal(t, testWorkspaceID, ingress.Labels[constants.DevWorkspaceIDLabel], "Should not override reserved labels")
+		assert.Equal(t, endpointName, ingress.Annotations[constants.DevWorkspaceEndpointNameAnnotation], "Should not override reserved annotations")
+		switch endpointName {
+		case "ide":
+			if assert.NotNil(t, ingress.Spec.IngressClassName) {
+				assert.Equal(t, "config-class", *ingress.Spec.IngressClassName, "Should use ingress class from config")
+			}
+			assert.Equal(t, "config-value", ingress.Annotations["overridden-annotation"])
+			assert.NotContains(t, ingress.Labels, "endpoint-label", "Endpoint labels should only apply to that endpoint")
+		case "custom":
+			if assert.NotNil(t, ingress.Spec.IngressClassName) {
+				assert.Equal(t, "endpoint-class", *ingress.Spec.IngressClassName, "Endpoint attribute should override ingress class from config")
+			}
+			assert.Equal(t, "endpoint-value", ingress.Annotations["overridden-annotation"], "Endpoint attribute should override annotations from config")
+			assert.Equal(t, "endpoint-value", ingress.Labels["endpoint-label"], "Should apply labels from endpoint attribute")
+		default:
+			t.Errorf("Unexpected ingress for endpoint %q", endpointName)
+		}
+	}
+	for _, service := range routingObjects.Services {
+		assert.Equal(t, "config-value", service.Annotations["config-annotation"], "Should apply annotations from config to services")
+		assert.Equal(t, "config-value", service.Labels["config-label"], "Should apply labels from config to services")
+		assert.NotContains(t, service.Labels, "endpoint-label", "Should not apply endpoint labels to services")
+	}
+}
+
+func TestApplyRoutingConfigToRoutes(t *testing.T) {
+	setupSolverTest(t, infrastructure.OpenShiftv4, getRoutingConfigTestConfig())
+	endpoints := getRoutingConfigTestEndpoints()
+	routingObjects, err := (&BasicSolver{}).GetSpecObjects(getTestRouting(controllerv1alpha1.DevWorkspaceRoutingBasic, endpoints), getTestMeta())
+	if !assert.NoError(t, err) {
+		return
+	}
+	if !assert.NoError(t, ApplyRoutingConfig(endpoints, &routingObjects)) {
+		return
+	}
+	if !assert.Len(t, routingObjects.Routes, 2) {
+		return
+	}
+	for _, route := range routingObjects.Routes {
+		assert.Equal(t, "config-value", route.Annotations["config-annotation"], "Should apply annotations from config")
+		assert.Equal(t, "config-value", route.Labels["config-label"], "Should apply labels from config")
+		switch route.Annotations[constants.DevWorkspaceEndpointNameAnnotation] {
+		case "ide":
+			assert.Equal(t, "config-value", route.Annotations["overridden-annotation"])
+			assert.NotContains(t, route.Labels, "endpoint-label", "Endpoint labels should only apply to that endpoint")
+		case "custom":
+			assert.Equal(t, "endpoint-value", route.Annotations["overridden-annotation"], "Endpoint attribute should override annotations from config")
+			assert.Equal(t, "endpoint-value", route.Labels["endpoint-label"], "Should apply labels from endpoint attribute")
+		default:
+			t.Errorf("Unexpected route for endpoint %q", route.Annotations[constants.DevWorkspaceEndpointNameAnnotation])
+		}
+	}
+}
+
+func TestApplyRoutingConfigInvalidAttribute(t *testing.T) {
+	setupSolverTest(t, infrastructure.Kubernetes, getRoutingConfigTestConfig())
+	endpoints := map[string]controllerv1alpha1.EndpointList{
+		"tooling": {
+			{
+				Name:       "ide",
+				TargetPort: 3100,
+				Exposure:   dw.PublicEndpointExposure,
+				Attributes: attributes.Attributes{}.PutString(constants.RoutingAnnotationsAttribute, "not-a-map"),
+			},
+		},
+	}
+	routingObjects, err := (&BasicSolver{}).GetSpecObjects(getTestRouting(controllerv1alpha1.DevWorkspaceRoutingBasic, endpoints), getTestMeta())
+	if !assert.NoError(t, err) {
+		return
+	}
+	err = ApplyRoutingConfig(endpoints, &routingObjects)
+	assert.IsType(t, &RoutingInvalid{}, err, "Should return RoutingInvalid for invalid endpoint attributes")
+}
+
+func TestApplyRoutingConfigDisallowedAttributes(t *testing.T) {
+	setupSolverTest(t, infrastructure.Kubernetes, getRoutingConfigTestConfig())
+	var err error
+	tests := []struct {
+		name       string
+		attributes attributes.Attributes
+	}{
+		{
+			name:       "Disallowed ingress class",
+			attributes: attributes.Attributes{}.PutString(constants.IngressClassNameAttribute, "other-class"),
+		},
+		{
+			name: "Disallowed annotation",
+			attributes: attributes.Attributes{}.
+				Put(constants.RoutingAnnotationsAttribute, map[string]string{"nginx.ingress.kubernetes.io/server-snippet": "return 200;"}, &err),
+		},
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			endpoints := map[string]controllerv1alpha1.EndpointList{
+				"tooling": {
+					{
+						Name:       "ide",
+						TargetPort: 3100,
+						Exposure:   dw.PublicEndpointExposure,
+						Attributes: tt.attributes,
+					},
+				},
+			}
+			routingObjects, err := (&BasicSolver{}).GetSpecObjects(getTestRouting(controllerv1alpha1.DevWorkspaceRoutingBasic, endpoints), getTestMeta())
+			if !assert.NoError(t, err) {
+				return
+			}
+			err = ApplyRoutingConfig(endpoints, &routingObjects)
+			assert.IsType(t, &RoutingInvalid{}, err, "Should return RoutingInvalid for endpoint attributes not allowed by config")
+		})
+	}
+}
@@ -0,0 +1,206 @@
+//
+// Copyright (c) 2019-2021 Red Hat, Inc.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package solvers
+
+import (
+	"fmt"
+	"strings"
+
+	dw "github.com/devfile/api/v2/pkg/apis/workspaces/v1alpha2"
+	routeV1 "github.com/openshift/api/route/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	controllerv1alpha1 "github.com/devfile/devworkspace-operator/apis/controller/v1alpha1"
+	"github.com/devfile/devworkspace-operator/pkg/common"
+	"github.com/devfile/devworkspace-operator/pkg/config"
+	"github.com/devfile/devworkspace-operator/pkg/constants"
+	"github.com/devfile/devworkspace-operator/pkg/infrastructure"
+)
+
+// singleHostConnectionTimeoutSeconds is the idle timeout used for connections to endpoints exposed on a single host, long
+// enough to keep websocket connections used by editors open.
+const singleHostConnectionTimeoutSeconds = 3600
+
+var singleHostRouteAnnotations = func(endpointName string) map[string]string {
+	return map[string]string{
+		"haproxy.router.openshift.io/rewrite-target": "/",
+		"haproxy.router.openshift.io/timeout":        fmt.Sprintf("%ds", singleHostConnectionTimeoutSeconds),
+		constants.DevWorkspaceEndpointNameAnnotation: endpointName,
+	}
+}
+
+var singleHostIngressAnnotations = func(endpointName, endpointPath string) map[string]string {
+	return map[string]string{
+		"kubernetes.io/ingress.class":                    "nginx",
+		"nginx.ingress.kubernetes.io/use-regex":          "true",
+		"nginx.ingress.kubernetes.io/rewrite-target":     "/$2",
+		"nginx.ingress.kubernetes.io/x-forwarded-prefix": strings.TrimSuffix(endpointPath, "/"),
+		"nginx.ingress.kubernetes.io/proxy-read-timeout": fmt.Sprintf("%d", singleHostConnectionTimeoutSeconds),
+		"nginx.ingress.kubernetes.io/proxy-send-timeout": fmt.Sprintf("%d", singleHostConnectionTimeoutSeconds),
+		"nginx.ingress.kubernetes.io/ssl-redirect":       "false",
+		constants.DevWorkspaceEndpointNameAnnotation:     endpointName,
+		constants.DevWorkspaceEndpointPathAnnotation:     endpointPath,
+	}
+}
+
+// SingleHostSolver exposes all endpoints on a single hostname, configured in the DevWorkspace Operator configuration,
+// using a path prefix of the form /<namespace>/<devworkspace-name>/<endpoint-name>/ for each endpoint. This avoids
+// the need for wildcard DNS and certificates for the cluster host suffix.
+type SingleHostSolver struct{}
+
+var _ RoutingSolver = (*SingleHostSolver)(nil)
+
+func (s *SingleHostSolver) FinalizerRequired(*controllerv1alpha1.DevWorkspaceRouting) bool {
+	return false
+}
+
+func (s *SingleHostSolver) Finalize(*controllerv1alpha1.DevWorkspaceRouting) error {
+	return nil
+}
+
+func (s *SingleHostSolver) GetSpecObjects(routing *controllerv1alpha1.DevWorkspaceRouting, workspaceMeta DevWorkspaceMetadata) (RoutingObjects, error) {
+	routingObjects := RoutingObjects{}
+
+	hostname := config.Routing.SingleHostname
+	if hostname == "" {
+		return routingObjects, &RoutingInvalid{"single-host routing requires .config.routing.singleHostname to be set in operator config"}
+	}
+	owner := metav1.GetControllerOf(routing)
+	if owner == nil {
+		return routingObjects, &RoutingInvalid{"single-host routing requires the DevWorkspaceRouting to be owned by a DevWorkspace"}
+	}
+	workspaceName := owner.Name
+
+	spec := routing.Spec
+	services := getServicesForEndpoints(spec.Endpoints, workspaceMeta)
+	services = append(services, GetDiscoverableServicesForEndpoints(spec.Endpoints, workspaceMeta)...)
+	externalServices, err := getExternalServicesForEndpoints(spec.Endpoints, workspaceMeta)
+	if err != nil {
+		return routingObjects, err
+	}
+	services = append(services, externalServices...)
+	routingObjects.Services = services
+
+	tlsConfig := config.Routing.TLS
+	if !infrastructure.IsOpenShift() {
+		if err := validateTLSConfig(tlsConfig); err != nil {
+			return routingObjects, err
+		}
+		// Requesting a certificate through cert-manager for each Ingress would request many certificates for the same
+		// hostname, quickly running into certificate authority rate limits
+		if tlsConfig != nil && (tlsConfig.Issuer != "" || tlsConfig.ClusterIssuer != "") {
+			return routingObjects, &RoutingInvalid{"single-host routing does not support .config.routing.tls.issuer or clusterIssuer; set .config.routing.tls.secretName to a certificate for the single hostname instead"}
+		}
+	}
+	for _, machineEndpoints := range spec.Endpoints {
+		for _, endpoint := range machineEndpoints {
+			if endpoint.Exposure != dw.PublicEndpointExposure || isNonHTTPEndpoint(endpoint) {
+				continue
+			}
+			endpointPath := common.SingleHostEndpointPath(workspaceMeta.Namespace, workspaceName, common.EndpointName(endpoint.Name))
+			if infrastructure.IsOpenShift() {
+				routingObjects.Routes = append(routingObjects.Routes, getSingleHostRouteForEndpoint(hostname, endpointPath, endpoint, workspaceMeta))
+			} else {
+				routingObjects.Ingresses = append(routingObjects.Ingresses, getSingleHostIngressForEndpoint(hostname, endpointPath, tlsConfig, endpoint, workspaceMeta))
+			}
+		}
+	}
+
+	return routingObjects, nil
+}
+
+func (s *SingleHostSolver) GetExposedEndpoints(
+	endpoints map[string]controllerv1alpha1.EndpointList,
+	routingObj RoutingObjects) (exposedEndpoints map[string]controllerv1alpha1.ExposedEndpointList, ready bool, err error) {
+	return getExposedEndpoints(endpoints, routingObj)
+}
+
+func getSingleHostRouteForEndpoint(hostname, endpointPath string, endpoint dw.Endpoint, meta DevWorkspaceMetadata) routeV1.Route {
+	endpointName := common.EndpointName(endpoint.Name)
+	return routeV1.Route{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      common.RouteName(meta.DevWorkspaceId, endpointName),
+			Namespace: meta.Namespace,
+			Labels: map[string]string{
+				constants.DevWorkspaceIDLabel: meta.DevWorkspaceId,
+			},
+			Annotations: singleHostRouteAnnotations(endpointName),
+		},
+		Spec: routeV1.RouteSpec{
+			Host: hostname,
+			Path: endpointPath,
+			TLS: &routeV1.TLSConfig{
+				InsecureEdgeTerminationPolicy: routeV1.InsecureEdgeTerminationPolicyRedirect,
+				Termination:                   routeV1.TLSTerminationEdge,
+			},
+			To: routeV1.RouteTargetReference{
+				Kind: "Service",
+				Name: getEndpointServiceName(endpoint, meta),
+			},
+			Port: &routeV1.RoutePort{
+				TargetPort: intstr.FromInt(endpoint.TargetPort),
+			},
+		},
+	}
+}
+
+// getSingleHostIngressForEndpoint returns an Ingress that exposes the endpoint on endpointPath, removing the path
+// prefix from requests before forwarding them to the endpoint. The original prefix is passed to the endpoint in the
+// X-Forwarded-Prefix header.
+func getSingleHostIngressForEndpoint(hostname, endpointPath string, tlsConfig *controllerv1alpha1.RoutingTLSConfig, endpoint dw.Endpoint, meta DevWorkspaceMetadata) networkingv1.Ingress {
+	endpointName := common.EndpointName(endpoint.Name)
+	ingressPathType := networkingv1.PathTypeImplementationSpecific
+	annotations := singleHostIngressAnnotations(endpoint.Name, endpointPath)
+	// Certificates are never requested for the single hostname, so no issuer secret name is needed
+	ingressTLS := getIngressTLS(tlsConfig, hostname, "", annotations)
+	return networkingv1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      common.RouteName(meta.DevWorkspaceId, endpointName),
+			Namespace: meta.Namespace,
+			Labels: map[string]string{
+				constants.DevWorkspaceIDLabel: meta.DevWorkspaceId,
+			},
+			Annotations: annotations,
+		},
+		Spec: networkingv1.IngressSpec{
+			TLS: ingressTLS,
+			Rules: []networkingv1.IngressRule{
+				{
+					Host: hostname,
+					IngressRuleValue: networkingv1.IngressRuleValue{
+						HTTP: &networkingv1.HTTPIngressRuleValue{
+							Paths: []networkingv1.HTTPIngressPath{
+								{
+									Backend: networkingv1.IngressBackend{
+										Service: &networkingv1.IngressServiceBackend{
+											Name: getEndpointServiceName(endpoint, meta),
+											Port: networkingv1.ServiceBackendPort{Number: int32(endpoint.TargetPort)},
+										},
+									},
+									PathType: &ingressPathType,
+									// Capture the remainder of the path after the prefix for the rewrite-target annotation
+									Path: fmt.Sprintf("%s(/|$)(.*)", strings.TrimSuffix(endpointPath, "/")),
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,153 @@
+//
+// Copyright (c) 2019-2021 Red Hat, Inc.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package solvers
+
+import (
+	"testing"
+
+	dw "github.com/devfile/api/v2/pkg/apis/workspaces/v1alpha2"
+	routeV1 "github.com/openshift/api/route/v1"
+	"github.com/stretchr/testify/assert"
+
+	controllerv1alpha1 "github.com/devfile/devworkspace-operator/apis/controller/v1alpha1"
+	"github.com/devfile/devworkspace-operator/pkg/config"
+	"github.com/devfile/devworkspace-operator/pkg/infrastructure"
+)
+
+func getSingleHostTestEndpoints() map[string]controllerv1alpha1.EndpointList {
+	return map[string]controllerv1alpha1.EndpointList{
+		"tooling": {
+			{Name: "ide", TargetPort: 3100, Exposure: dw.PublicEndpointExposure, Protocol: dw.HTTPSEndpointProtocol},
+			{Name: "debug", TargetPort: 5005, Exposure: dw.InternalEndpointExposure},
+			{Name: "ssh", TargetPort: 22, Exposure: dw.PublicEndpointExposure, Protocol: dw.TCPEndpointProtocol},
+		},
+		"db": {
+			getDedicatedEndpoint(dw.Endpoint{Name: "db-console", TargetPort: 8080, Exposure: dw.PublicEndpointExposure, Protocol: dw.HTTPSEndpointProtocol}, "db"),
+		},
+	}
+}
+
+func TestSingleHostSolverRequiresConfiguration(t *testing.T) {
+	setupSolverTest(t, infrastructure.Kubernetes, &controllerv1alpha1.RoutingConfig{SingleHostname: "dev.example.com"})
+	solver := &SingleHostSolver{}
+	routing := getTestRouting(controllerv1alpha1.DevWorkspaceRoutingSingleHost, getSingleHostTestEndpoints())
+
+	routing.OwnerReferences = nil
+	_, err := solver.GetSpecObjects(routing, getTestMeta())
+	assert.IsType(t, &RoutingInvalid{}, err, "Should require DevWorkspaceRouting to be owned by a DevWorkspace")
+
+	config.SetConfigForTesting(nil)
+	_, err = solver.GetSpecObjects(getTestRouting(controllerv1alpha1.DevWorkspaceRoutingSingleHost, getSingleHostTestEndpoints()), getTestMeta())
+	assert.IsType(t, &RoutingInvalid{}, err, "Should require single hostname to be configured")
+
+	for _, tlsConfig := range []*controllerv1alpha1.RoutingTLSConfig{{Issuer: "test-issuer"}, {ClusterIssuer: "test-cluster-issuer"}} {
+		setupSolverTest(t, infrastructure.Kubernetes, &controllerv1alpha1.RoutingConfig{
+			SingleHostname: "dev.example.com",
+			TLS:            tlsConfig,
+		})
+		_, err = solver.GetSpecObjects(getTestRouting(controllerv1alpha1.DevWorkspaceRoutingSingleHost, getSingleHostTestEndpoints()), getTestMeta())
+		assert.IsType(t, &RoutingInvalid{}, err, "Should not request a certificate for each endpoint on the single hostname")
+	}
+}
+
+func TestSingleHostSolverIngresses(t *testing.T) {
+	setupSolverTest(t, infrastructure.Kubernetes, &controllerv1alpha1.RoutingConfig{
+		SingleHostname: "dev.example.com",
+		TLS:            &controllerv1alpha1.RoutingTLSConfig{SecretName: "dev-cert"},
+	})
+	solver := &SingleHostSolver{}
+	endpoints := getSingleHostTestEndpoints()
+
+	objs, err := solver.GetSpecObjects(getTestRouting(controllerv1alpha1.DevWorkspaceRoutingSingleHost, endpoints), getTestMeta())
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Empty(t, objs.Routes, "Should not create Routes on Kubernetes")
+	if !assert.Len(t, objs.Ingresses, 2, "Should create Ingresses for public http endpoints only") {
+		return
+	}
+	paths := map[string]string{}
+	prefixes := map[string]string{}
+	backends := map[string]string{}
+	for _, ingress := range objs.Ingresses {
+		rule := ingress.Spec.Rules[0]
+		assert.Equal(t, "dev.example.com", rule.Host, "Ingress should use single hostname")
+		if assert.Len(t, ingress.Spec.TLS, 1) {
+			assert.Equal(t, "dev-cert", ingress.Spec.TLS[0].SecretName)
+		}
+		paths[ingress.Name] = rule.HTTP.Paths[0].Path
+		prefixes[ingress.Name] = ingress.Annotations["nginx.ingress.kubernetes.io/x-forwarded-prefix"]
+		backends[ingress.Name] = rule.HTTP.Paths[0].Backend.Service.Name
+	}
+	assert.Equal(t, map[string]string{
+		"workspace-id-ide":        "/test-namespace/my-workspace/ide(/|$)(.*)",
+		"workspace-id-db-console": "/test-namespace/my-workspace/db-console(/|$)(.*)",
+	}, paths, "Ingress paths should be of the form /<namespace>/<devworkspace-name>/<endpoint-name>")
+	assert.Equal(t, map[string]string{
+		"workspace-id-ide":        "/test-namespace/my-workspace/ide",
+		"workspace-id-db-console": "/test-namespace/my-workspace/db-console",
+	}, prefixes, "Path prefix should be passed to endpoints in X-Forwarded-Prefix header")
+	assert.Equal(t, map[string]string{
+		"workspace-id-ide":        "workspace-id-service",
+		"workspace-id-db-console": "workspace-id-db",
+	}, backends)
+
+	exposed, ready, err := solver.GetExposedEndpoints(endpoints, RoutingObjects{Ingresses: objs.Ingresses, Services: objs.Services})
+	assert.NoError(t, err)
+	assert.False(t, ready, "Endpoints should not be ready until external service has an address")
+	urls := map[string]string{}
+	for _, machineEndpoints := range exposed {
+		for _, endpoint := range machineEndpoints {
+			urls[endpoint.Name] = endpoint.Url
+		}
+	}
+	assert.Equal(t, map[string]string{
+		"ide":        "https://dev.example.com/test-namespace/my-workspace/ide/",
+		"db-console": "https://dev.example.com/test-namespace/my-workspace/db-console/",
+		"ssh":        "",
+	}, urls)
+}
+
+func TestSingleHostSolverRoutes(t *testing.T) {
+	setupSolverTest(t, infrastructure.OpenShiftv4, &controllerv1alpha1.RoutingConfig{SingleHostname: "dev.example.com"})
+	solver := &SingleHostSolver{}
+	endpoints := map[string]controllerv1alpha1.EndpointList{
+		"tooling": {{Name: "ide", TargetPort: 3100, Exposure: dw.PublicEndpointExposure, Protocol: dw.HTTPSEndpointProtocol}},
+	}
+
+	objs, err := solver.GetSpecObjects(getTestRouting(controllerv1alpha1.DevWorkspaceRoutingSingleHost, endpoints), getTestMeta())
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Empty(t, objs.Ingresses, "Should not create Ingresses on OpenShift")
+	if !assert.Len(t, objs.Routes, 1) {
+		return
+	}
+	route := objs.Routes[0]
+	assert.Equal(t, "dev.example.com", route.Spec.Host)
+	assert.Equal(t, "/test-namespace/my-workspace/ide/", route.Spec.Path)
+	assert.Equal(t, "/", route.Annotations["haproxy.router.openshift.io/rewrite-target"], "Path prefix should be removed from requests")
+	if assert.NotNil(t, route.Spec.TLS) {
+		assert.Equal(t, routeV1.TLSTerminationEdge, route.Spec.TLS.Termination)
+	}
+
+	exposed, ready, err := solver.GetExposedEndpoints(endpoints, RoutingObjects{Routes: objs.Routes})
+	assert.NoError(t, err)
+	assert.True(t, ready)
+	if assert.Len(t, exposed["tooling"], 1) {
+		assert.Equal(t, "https://dev.example.com/test-namespace/my-workspace/ide/", exposed["tooling"][0].Url)
+	}
+}
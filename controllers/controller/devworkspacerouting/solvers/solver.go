@@ -21,6 +21,7 @@ import (
 	routeV1 "github.com/openshift/api/route/v1"
 	corev1 "k8s.io/api/core/v1"
 	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
@@ -29,9 +30,12 @@ import (
 )
 
 type RoutingObjects struct {
-	Services     []corev1.Service
-	Ingresses    []networkingv1.Ingress
-	Routes       []routeV1.Route
+	Services  []corev1.Service
+	Ingresses []networkingv1.Ingress
+	Routes    []routeV1.Route
+	// HTTPRoutes are Gateway API HTTPRoutes, handled as unstructured objects. They are only synced to the cluster
+	// if the Gateway API is installed.
+	HTTPRoutes   []unstructured.Unstructured
 	PodAdditions *controllerv1alpha1.PodAdditions
 }
 
@@ -93,14 +97,17 @@ func (_ *SolverGetter) HasSolver(routingClass controllerv1alpha1.DevWorkspaceRou
 	case controllerv1alpha1.DevWorkspaceRoutingBasic,
 		controllerv1alpha1.DevWorkspaceRoutingCluster,
 		controllerv1alpha1.DevWorkspaceRoutingClusterTLS,
-		controllerv1alpha1.DevWorkspaceRoutingWebTerminal:
+		controllerv1alpha1.DevWorkspaceRoutingWebTerminal,
+		controllerv1alpha1.DevWorkspaceRoutingGateway,
+		controllerv1alpha1.DevWorkspaceRoutingOIDC,
+		controllerv1alpha1.DevWorkspaceRoutingSingleHost:
 		return true
 	default:
 		return false
 	}
 }
 
-func (_ *SolverGetter) GetSolver(_ client.Client, routingClass controllerv1alpha1.DevWorkspaceRoutingClass) (RoutingSolver, error) {
+func (_ *SolverGetter) GetSolver(client client.Client, routingClass controllerv1alpha1.DevWorkspaceRoutingClass) (RoutingSolver, error) {
 	isOpenShift := infrastructure.IsOpenShift()
 	switch routingClass {
 	case controllerv1alpha1.DevWorkspaceRoutingBasic:
@@ -112,11 +119,25 @@ func (_ *SolverGetter) GetSolver(_ client.Client, routingClass controllerv1alpha
 			return nil, fmt.Errorf("routing class %s only supported on OpenShift", routingClass)
 		}
 		return &ClusterSolver{TLS: true}, nil
+	case controllerv1alpha1.DevWorkspaceRoutingGateway:
+		if !infrastructure.SupportsGatewayAPI() {
+			return nil, fmt.Errorf("routing class %s requires the Gateway API to be installed on the cluster", routingClass)
+		}
+		return &GatewaySolver{}, nil
+	case controllerv1alpha1.DevWorkspaceRoutingOIDC:
+		return &OIDCSolver{client: client}, nil
+	case controllerv1alpha1.DevWorkspaceRoutingSingleHost:
+		return &SingleHostSolver{}, nil
 	default:
 		return nil, RoutingNotSupported
 	}
 }
 
-func (*SolverGetter) SetupControllerManager(*builder.Builder) error {
+func (*SolverGetter) SetupControllerManager(bld *builder.Builder) error {
+	if infrastructure.SupportsGatewayAPI() {
+		httpRoute := &unstructured.Unstructured{}
+		httpRoute.SetGroupVersionKind(HTTPRouteGVK)
+		bld.Owns(httpRoute)
+	}
 	return nil
 }
@@ -0,0 +1,133 @@
+//
+// Copyright (c) 2019-2021 Red Hat, Inc.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package devworkspacerouting
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+
+	controllerv1alpha1 "github.com/devfile/devworkspace-operator/apis/controller/v1alpha1"
+	"github.com/devfile/devworkspace-operator/controllers/controller/devworkspacerouting/solvers"
+	"github.com/devfile/devworkspace-operator/pkg/config"
+	"github.com/devfile/devworkspace-operator/pkg/constants"
+)
+
+const (
+	testWorkspaceID = "workspace-id"
+	testNamespace   = "test-namespace"
+)
+
+func getTestReconciler(objs ...runtime.Object) *DevWorkspaceRoutingReconciler {
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		panic(err)
+	}
+	if err := controllerv1alpha1.AddToScheme(scheme); err != nil {
+		panic(err)
+	}
+	return &DevWorkspaceRoutingReconciler{
+		Client: fake.NewClientBuilder().WithScheme(scheme).WithRuntimeObjects(objs...).Build(),
+		Log:    zap.New(),
+		Scheme: scheme,
+	}
+}
+
+func getTestRouting() *controllerv1alpha1.DevWorkspaceRouting {
+	routing := &controllerv1alpha1.DevWorkspaceRouting{}
+	routing.Name = "test-routing"
+	routing.Namespace = testNamespace
+	routing.Spec.DevWorkspaceId = testWorkspaceID
+	return routing
+}
+
+func getTestHTTPRoute(name, hostname string) *unstructured.Unstructured {
+	httpRoute := &unstructured.Unstructured{}
+	httpRoute.SetGroupVersionKind(solvers.HTTPRouteGVK)
+	httpRoute.SetName(name)
+	httpRoute.SetNamespace(testNamespace)
+	httpRoute.SetLabels(map[string]string{
+		constants.DevWorkspaceIDLabel: testWorkspaceID,
+	})
+	httpRoute.Object["spec"] = map[string]interface{}{
+		"hostnames": []interface{}{hostname},
+	}
+	return httpRoute
+}
+
+func TestSyncHTTPRoutes(t *testing.T) {
+	config.SetConfigForTesting(nil)
+	r := getTestReconciler()
+	routing := getTestRouting()
+	specHTTPRoute := getTestHTTPRoute(testWorkspaceID, "workspace-id.test.suffix")
+
+	ok, _, err := r.syncHTTPRoutes(routing, []unstructured.Unstructured{*specHTTPRoute})
+	assert.NoError(t, err)
+	assert.False(t, ok, "HTTPRoutes should not be in sync when HTTPRoute is created")
+
+	ok, clusterHTTPRoutes, err := r.syncHTTPRoutes(routing, []unstructured.Unstructured{*specHTTPRoute})
+	assert.NoError(t, err)
+	assert.True(t, ok, "HTTPRoutes should be in sync once HTTPRoute is created")
+	if assert.Len(t, clusterHTTPRoutes, 1) {
+		hostnames, _, _ := unstructured.NestedStringSlice(clusterHTTPRoutes[0].Object, "spec", "hostnames")
+		assert.Equal(t, []string{"workspace-id.test.suffix"}, hostnames)
+	}
+
+	// Changes to the spec should be applied to the HTTPRoute on the cluster
+	specHTTPRoute = getTestHTTPRoute(testWorkspaceID, "workspace-id.other.suffix")
+	ok, _, err = r.syncHTTPRoutes(routing, []unstructured.Unstructured{*specHTTPRoute})
+	assert.NoError(t, err)
+	assert.False(t, ok, "HTTPRoutes should not be in sync when HTTPRoute is updated")
+	ok, clusterHTTPRoutes, err = r.syncHTTPRoutes(routing, []unstructured.Unstructured{*specHTTPRoute})
+	assert.NoError(t, err)
+	assert.True(t, ok, "HTTPRoutes should be in sync once HTTPRoute is updated")
+	if assert.Len(t, clusterHTTPRoutes, 1) {
+		hostnames, _, _ := unstructured.NestedStringSlice(clusterHTTPRoutes[0].Object, "spec", "hostnames")
+		assert.Equal(t, []string{"workspace-id.other.suffix"}, hostnames)
+	}
+}
+
+func TestSyncHTTPRoutesDeletesUnusedHTTPRoutes(t *testing.T) {
+	staleHTTPRoute := getTestHTTPRoute("stale-route", "stale.test.suffix")
+	otherWorkspaceHTTPRoute := getTestHTTPRoute("other-workspace", "other-workspace.test.suffix")
+	otherWorkspaceHTTPRoute.SetLabels(map[string]string{
+		constants.DevWorkspaceIDLabel: "other-workspace",
+	})
+	r := getTestReconciler(staleHTTPRoute, otherWorkspaceHTTPRoute)
+	routing := getTestRouting()
+
+	ok, _, err := r.syncHTTPRoutes(routing, nil)
+	assert.NoError(t, err)
+	assert.False(t, ok, "HTTPRoutes should not be in sync when HTTPRoute is deleted")
+	clusterHTTPRoutes, err := r.getClusterHTTPRoutes(routing)
+	assert.NoError(t, err)
+	assert.Empty(t, clusterHTTPRoutes, "HTTPRoutes not in spec should be deleted")
+
+	otherRouting := getTestRouting()
+	otherRouting.Spec.DevWorkspaceId = "other-workspace"
+	clusterHTTPRoutes, err = r.getClusterHTTPRoutes(otherRouting)
+	assert.NoError(t, err)
+	assert.Len(t, clusterHTTPRoutes, 1, "HTTPRoutes for other workspaces should not be deleted")
+
+	ok, _, err = r.syncHTTPRoutes(routing, nil)
+	assert.NoError(t, err)
+	assert.True(t, ok, "HTTPRoutes should be in sync once unused HTTPRoutes are deleted")
+}
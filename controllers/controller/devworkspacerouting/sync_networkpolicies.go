@@ -0,0 +1,108 @@
+//
+// Copyright (c) 2019-2021 Red Hat, Inc.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package devworkspacerouting
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/devfile/devworkspace-operator/pkg/constants"
+	"github.com/devfile/devworkspace-operator/pkg/provision/sync"
+
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	controllerv1alpha1 "github.com/devfile/devworkspace-operator/apis/controller/v1alpha1"
+)
+
+func (r *DevWorkspaceRoutingReconciler) syncNetworkPolicies(routing *controllerv1alpha1.DevWorkspaceRouting, specNetworkPolicies []networkingv1.NetworkPolicy) (ok bool, err error) {
+	networkPoliciesInSync := true
+
+	clusterNetworkPolicies, err := r.getClusterNetworkPolicies(routing)
+	if err != nil {
+		return false, err
+	}
+
+	toDelete := getNetworkPoliciesToDelete(clusterNetworkPolicies, specNetworkPolicies)
+	for _, networkPolicy := range toDelete {
+		err := r.Delete(context.TODO(), &networkPolicy)
+		if err != nil {
+			return false, err
+		}
+		networkPoliciesInSync = false
+	}
+
+	clusterAPI := sync.ClusterAPI{
+		Client: r.Client,
+		Scheme: r.Scheme,
+		Logger: r.Log.WithValues("Request.Namespace", routing.Namespace, "Request.Name", routing.Name),
+		Ctx:    context.TODO(),
+	}
+
+	for _, specNetworkPolicy := range specNetworkPolicies {
+		_, err := sync.SyncObjectWithCluster(&specNetworkPolicy, clusterAPI)
+		switch t := err.(type) {
+		case nil:
+			break
+		case *sync.NotInSyncError:
+			networkPoliciesInSync = false
+			continue
+		case *sync.UnrecoverableSyncError:
+			return false, t.Cause
+		default:
+			return false, err
+		}
+	}
+
+	return networkPoliciesInSync, nil
+}
+
+func (r *DevWorkspaceRoutingReconciler) getClusterNetworkPolicies(routing *controllerv1alpha1.DevWorkspaceRouting) ([]networkingv1.NetworkPolicy, error) {
+	found := &networkingv1.NetworkPolicyList{}
+	labelSelector, err := labels.Parse(fmt.Sprintf("%s=%s", constants.DevWorkspaceIDLabel, routing.Spec.DevWorkspaceId))
+	if err != nil {
+		return nil, err
+	}
+	listOptions := &client.ListOptions{
+		Namespace:     routing.Namespace,
+		LabelSelector: labelSelector,
+	}
+	err = r.List(context.TODO(), found, listOptions)
+	if err != nil {
+		return nil, err
+	}
+	return found.Items, nil
+}
+
+func getNetworkPoliciesToDelete(clusterNetworkPolicies, specNetworkPolicies []networkingv1.NetworkPolicy) []networkingv1.NetworkPolicy {
+	var toDelete []networkingv1.NetworkPolicy
+	for _, clusterNetworkPolicy := range clusterNetworkPolicies {
+		if contains, _ := listContainsNetworkPolicyByName(clusterNetworkPolicy, specNetworkPolicies); !contains {
+			toDelete = append(toDelete, clusterNetworkPolicy)
+		}
+	}
+	return toDelete
+}
+
+func listContainsNetworkPolicyByName(query networkingv1.NetworkPolicy, list []networkingv1.NetworkPolicy) (exists bool, idx int) {
+	for idx, listNetworkPolicy := range list {
+		if query.GetName() == listNetworkPolicy.GetName() {
+			return true, idx
+		}
+	}
+	return false, -1
+}
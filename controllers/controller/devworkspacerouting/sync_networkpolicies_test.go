@@ -0,0 +1,124 @@
+//
+// Copyright (c) 2019-2021 Red Hat, Inc.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package devworkspacerouting
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	"github.com/devfile/devworkspace-operator/pkg/config"
+	"github.com/devfile/devworkspace-operator/pkg/constants"
+)
+
+func getTestNetworkPolicy(name string, port int) *networkingv1.NetworkPolicy {
+	policyPort := intstr.FromInt(port)
+	return &networkingv1.NetworkPolicy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: testNamespace,
+			Labels: map[string]string{
+				constants.DevWorkspaceIDLabel: testWorkspaceID,
+			},
+		},
+		Spec: networkingv1.NetworkPolicySpec{
+			PodSelector: metav1.LabelSelector{
+				MatchLabels: map[string]string{constants.DevWorkspaceIDLabel: testWorkspaceID},
+			},
+			Ingress: []networkingv1.NetworkPolicyIngressRule{
+				{
+					Ports: []networkingv1.NetworkPolicyPort{{Port: &policyPort}},
+				},
+			},
+			PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeIngress},
+		},
+	}
+}
+
+func TestSyncNetworkPolicies(t *testing.T) {
+	config.SetConfigForTesting(nil)
+	r := getTestReconciler()
+	routing := getTestRouting()
+	specNetworkPolicy := getTestNetworkPolicy(testWorkspaceID, 3100)
+
+	ok, err := r.syncNetworkPolicies(routing, []networkingv1.NetworkPolicy{*specNetworkPolicy})
+	assert.NoError(t, err)
+	assert.False(t, ok, "NetworkPolicies should not be in sync when NetworkPolicy is created")
+
+	ok, err = r.syncNetworkPolicies(routing, []networkingv1.NetworkPolicy{*specNetworkPolicy})
+	assert.NoError(t, err)
+	assert.True(t, ok, "NetworkPolicies should be in sync once NetworkPolicy is created")
+
+	// Changes to the spec should be applied to the NetworkPolicy on the cluster
+	specNetworkPolicy = getTestNetworkPolicy(testWorkspaceID, 8080)
+	ok, err = r.syncNetworkPolicies(routing, []networkingv1.NetworkPolicy{*specNetworkPolicy})
+	assert.NoError(t, err)
+	assert.False(t, ok, "NetworkPolicies should not be in sync when NetworkPolicy is updated")
+	clusterNetworkPolicy := &networkingv1.NetworkPolicy{}
+	err = r.Get(context.TODO(), types.NamespacedName{Name: testWorkspaceID, Namespace: testNamespace}, clusterNetworkPolicy)
+	if assert.NoError(t, err) {
+		assert.Equal(t, 8080, clusterNetworkPolicy.Spec.Ingress[0].Ports[0].Port.IntValue())
+	}
+}
+
+func TestSyncNetworkPoliciesIgnoresDefaultedFields(t *testing.T) {
+	config.SetConfigForTesting(nil)
+	specNetworkPolicy := getTestNetworkPolicy(testWorkspaceID, 3100)
+	// Simulate the API server defaulting the protocol of the port
+	clusterNetworkPolicy := getTestNetworkPolicy(testWorkspaceID, 3100)
+	protocol := corev1.ProtocolTCP
+	clusterNetworkPolicy.Spec.Ingress[0].Ports[0].Protocol = &protocol
+	r := getTestReconciler(clusterNetworkPolicy)
+	routing := getTestRouting()
+
+	ok, err := r.syncNetworkPolicies(routing, []networkingv1.NetworkPolicy{*specNetworkPolicy})
+	assert.NoError(t, err)
+	assert.True(t, ok, "NetworkPolicies should be in sync when cluster NetworkPolicy only differs by defaulted fields")
+
+	udpProtocol := corev1.ProtocolUDP
+	specNetworkPolicy.Spec.Ingress[0].Ports[0].Protocol = &udpProtocol
+	ok, err = r.syncNetworkPolicies(routing, []networkingv1.NetworkPolicy{*specNetworkPolicy})
+	assert.NoError(t, err)
+	assert.False(t, ok, "NetworkPolicies should not be in sync when port protocol changes")
+}
+
+func TestSyncNetworkPoliciesDeletesUnusedNetworkPolicies(t *testing.T) {
+	config.SetConfigForTesting(nil)
+	staleNetworkPolicy := getTestNetworkPolicy("stale-policy", 3100)
+	otherWorkspaceNetworkPolicy := getTestNetworkPolicy("other-workspace", 3100)
+	otherWorkspaceNetworkPolicy.Labels[constants.DevWorkspaceIDLabel] = "other-workspace-id"
+	r := getTestReconciler(staleNetworkPolicy, otherWorkspaceNetworkPolicy)
+	routing := getTestRouting()
+
+	ok, err := r.syncNetworkPolicies(routing, nil)
+	assert.NoError(t, err)
+	assert.False(t, ok, "NetworkPolicies should not be in sync when NetworkPolicy is deleted")
+
+	clusterNetworkPolicies := &networkingv1.NetworkPolicyList{}
+	if assert.NoError(t, r.List(context.TODO(), clusterNetworkPolicies)) && assert.Len(t, clusterNetworkPolicies.Items, 1) {
+		assert.Equal(t, "other-workspace", clusterNetworkPolicies.Items[0].Name, "Should not delete NetworkPolicies for other workspaces")
+	}
+
+	ok, err = r.syncNetworkPolicies(routing, nil)
+	assert.NoError(t, err)
+	assert.True(t, ok, "NetworkPolicies should be in sync once unused NetworkPolicies are deleted")
+}
@@ -74,7 +74,8 @@ func (r *DevWorkspaceRoutingReconciler) syncServices(routing *controllerv1alpha1
 
 func (r *DevWorkspaceRoutingReconciler) getClusterServices(routing *controllerv1alpha1.DevWorkspaceRouting) ([]corev1.Service, error) {
 	found := &corev1.ServiceList{}
-	labelSelector, err := labels.Parse(fmt.Sprintf("%s=%s", constants.DevWorkspaceIDLabel, routing.Spec.DevWorkspaceId))
+	// Services for dedicatedPod components are managed by the DevWorkspace controller
+	labelSelector, err := labels.Parse(fmt.Sprintf("%s=%s,!%s", constants.DevWorkspaceIDLabel, routing.Spec.DevWorkspaceId, constants.DevWorkspaceDedicatedPodLabel))
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,104 @@
+//
+// Copyright (c) 2019-2021 Red Hat, Inc.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package controllers
+
+import (
+	"fmt"
+	"time"
+
+	dw "github.com/devfile/api/v2/pkg/apis/workspaces/v1alpha2"
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/devfile/devworkspace-operator/pkg/common"
+	"github.com/devfile/devworkspace-operator/pkg/constants"
+	"github.com/devfile/devworkspace-operator/pkg/provision/storage"
+	"github.com/devfile/devworkspace-operator/pkg/provision/sync"
+)
+
+// backupWorkspace creates a VolumeSnapshot of a DevWorkspace's storage if a backup is requested via annotation or, for
+// running DevWorkspaces, is due according to the backup schedule. Once a backup is taken, the backup request annotation
+// is removed, the time of the backup is recorded in an annotation, and updated is true. Otherwise, the time remaining
+// until the next scheduled backup is returned (zero if no backup is scheduled).
+//
+// A backup that fails because VolumeSnapshots are not supported on the cluster is logged and recorded as taken, to
+// avoid retrying it on every reconcile.
+func (r *DevWorkspaceReconciler) backupWorkspace(workspace *dw.DevWorkspace, clusterAPI sync.ClusterAPI, logger logr.Logger) (updated bool, timeUntilBackup time.Duration, err error) {
+	if _, ok := workspace.Annotations[constants.DevWorkspaceStorageTypeAnnotation]; !ok {
+		// Storage has never been provisioned for this workspace
+		return false, 0, nil
+	}
+	_, requested := workspace.Annotations[constants.DevWorkspaceBackupAnnotation]
+	scheduled := false
+	if workspace.Spec.Started && workspace.Status.Phase == dw.DevWorkspaceStatusRunning {
+		scheduled, timeUntilBackup, err = checkForScheduledBackup(workspace)
+		if err != nil {
+			logger.Error(err, "Failed to check DevWorkspace backup schedule")
+		}
+	}
+	if !requested && !scheduled {
+		return false, timeUntilBackup, nil
+	}
+
+	backupTime := clock.Now().UTC()
+	snapshotName := common.WorkspaceBackupName(workspace.Status.DevWorkspaceId, backupTime.Format("20060102-150405"))
+	snapshot, err := storage.BackupWorkspaceStorage(workspace, snapshotName, clusterAPI)
+	if err != nil {
+		if _, ok := err.(*storage.ProvisioningError); !ok {
+			return false, 0, err
+		}
+		logger.Error(err, "Failed to back up DevWorkspace storage")
+	} else if snapshot != nil {
+		logger.Info("Backed up DevWorkspace storage", "volumeSnapshot", snapshot.GetName())
+	}
+
+	patch := []byte(fmt.Sprintf(`{"metadata":{"annotations":{%q:null,%q:%q}}}`,
+		constants.DevWorkspaceBackupAnnotation, constants.DevWorkspaceLastBackupAnnotation, backupTime.Format(time.RFC3339)))
+	if err := r.Client.Patch(clusterAPI.Ctx, workspace, client.RawPatch(types.MergePatchType, patch)); err != nil {
+		return false, 0, err
+	}
+	return true, 0, nil
+}
+
+// restoreStorage restores a DevWorkspace's data from the VolumeSnapshot referenced by the restore annotation on
+// clusterWorkspace, if present. Once data is restored, the annotation is replaced by one recording the snapshot that was
+// restored, and updated is true. Storage can only be restored while the DevWorkspace is not running.
+//
+// Returns storage.NotReadyError while restoring is in progress, storage.ProvisioningError if the snapshot cannot be
+// restored, and any other error if an unexpected problem arises.
+func (r *DevWorkspaceReconciler) restoreStorage(workspace, clusterWorkspace *dw.DevWorkspace, clusterAPI sync.ClusterAPI, logger logr.Logger) (updated bool, err error) {
+	snapshotName, ok := clusterWorkspace.Annotations[constants.DevWorkspaceRestoreFromAnnotation]
+	if !ok {
+		return false, nil
+	}
+	if workspace.Status.Phase == dw.DevWorkspaceStatusRunning {
+		return false, &storage.ProvisioningError{
+			Message: fmt.Sprintf("Cannot restore storage from VolumeSnapshot %s while DevWorkspace is running", snapshotName),
+		}
+	}
+	if err := storage.RestoreWorkspaceStorage(workspace, snapshotName, clusterAPI); err != nil {
+		return false, err
+	}
+	logger.Info("Restored DevWorkspace storage", "volumeSnapshot", snapshotName)
+
+	patch := []byte(fmt.Sprintf(`{"metadata":{"annotations":{%q:null,%q:%q}}}`,
+		constants.DevWorkspaceRestoreFromAnnotation, constants.DevWorkspaceRestoredFromAnnotation, snapshotName))
+	if err := r.Client.Patch(clusterAPI.Ctx, clusterWorkspace, client.RawPatch(types.MergePatchType, patch)); err != nil {
+		return false, err
+	}
+	return true, nil
+}
@@ -25,11 +25,16 @@ import (
 var conditionOrder = []dw.DevWorkspaceConditionType{
 	conditions.Started,
 	conditions.DevWorkspaceResolved,
+	conditions.StorageMigrated,
 	conditions.StorageReady,
+	conditions.KubeComponentsReady,
 	dw.DevWorkspaceRoutingReady,
 	dw.DevWorkspaceServiceAccountReady,
 	conditions.PullSecretsReady,
+	conditions.ImagesBuilt,
 	conditions.DeploymentReady,
+	conditions.EndpointsReady,
+	conditions.Restarted,
 	dw.DevWorkspaceReady,
 }
 
@@ -37,9 +37,12 @@ import (
 	"github.com/devfile/devworkspace-operator/pkg/library/flatten"
 	registry "github.com/devfile/devworkspace-operator/pkg/library/flatten/internal_registry"
 	"github.com/devfile/devworkspace-operator/pkg/library/projects"
+	"github.com/devfile/devworkspace-operator/pkg/provision/imagebuild"
+	"github.com/devfile/devworkspace-operator/pkg/provision/kubecomponents"
 	"github.com/devfile/devworkspace-operator/pkg/provision/metadata"
 	"github.com/devfile/devworkspace-operator/pkg/provision/storage"
 	wsprovision "github.com/devfile/devworkspace-operator/pkg/provision/workspace"
+	"github.com/devfile/devworkspace-operator/pkg/quota"
 	"github.com/devfile/devworkspace-operator/pkg/timing"
 
 	"github.com/go-logr/logr"
@@ -83,6 +86,8 @@ type DevWorkspaceReconciler struct {
 // +kubebuilder:rbac:groups="",resources=pods;serviceaccounts;secrets;configmaps;persistentvolumeclaims,verbs=*
 // +kubebuilder:rbac:groups="",resources=namespaces;events,verbs=get;list;watch
 // +kubebuilder:rbac:groups="batch",resources=jobs,verbs=get;create;list;watch;update;patch;delete
+// +kubebuilder:rbac:groups=snapshot.storage.k8s.io,resources=volumesnapshots,verbs=get;list;create;delete
+// +kubebuilder:rbac:groups=storage.k8s.io,resources=storageclasses,verbs=get;list;watch
 // +kubebuilder:rbac:groups=admissionregistration.k8s.io,resources=mutatingwebhookconfigurations;validatingwebhookconfigurations,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=rbac.authorization.k8s.io,resources=roles;rolebindings;clusterroles;clusterrolebindings,verbs=get;list;watch;create;update
 // +kubebuilder:rbac:groups=oauth.openshift.io,resources=oauthclients,verbs=get;list;watch;create;update;patch;delete;deletecollection
@@ -160,11 +165,60 @@ func (r *DevWorkspaceReconciler) Reconcile(ctx context.Context, req ctrl.Request
 		return reconcile.Result{Requeue: true}, nil
 	}
 
+	// Back up workspace storage if requested or scheduled
+	updated, timeUntilBackup, err := r.backupWorkspace(workspace, clusterAPI, reqLogger)
+	if err != nil {
+		return reconcile.Result{}, err
+	} else if updated {
+		return reconcile.Result{Requeue: true}, nil
+	}
+
 	// Handle stopped workspaces
 	if !workspace.Spec.Started {
+		// Restarts and endpoint status only apply to running workspaces
+		restartCleared := clearRestartAnnotations(workspace)
+		endpointStatusCleared := clearEndpointStatusAnnotation(workspace)
+		if restartCleared || endpointStatusCleared {
+			err := r.Update(ctx, workspace)
+			return reconcile.Result{Requeue: true}, err
+		}
 		timing.ClearAnnotations(workspace)
 		r.syncTimingToCluster(ctx, workspace, map[string]string{}, reqLogger)
-		return r.stopWorkspace(workspace, reqLogger)
+		if workspace.Status.Phase != dw.DevWorkspaceStatusStopped {
+			return r.stopWorkspace(workspace, clusterAPI, reqLogger)
+		}
+		// Start stopped workspaces according to their start schedule
+		startScheduled, timeUntilStart, err := checkForScheduledStart(workspace)
+		if err != nil {
+			reqLogger.Error(err, "Failed to check DevWorkspace start schedule")
+		} else if startScheduled {
+			reqLogger.Info("Starting DevWorkspace according to start schedule")
+			patch := []byte(`{"spec":{"started": true}}`)
+			err := r.Client.Patch(context.Background(), workspace, client.RawPatch(types.MergePatchType, patch))
+			if err != nil {
+				return reconcile.Result{}, err
+			}
+			return reconcile.Result{Requeue: true}, nil
+		}
+		result, err := r.stopWorkspace(workspace, clusterAPI, reqLogger)
+		if err == nil && timeUntilStart > 0 {
+			result.RequeueAfter = timeUntilStart
+		}
+		return result, err
+	}
+
+	// Stop running workspaces that are idle, have exceeded their maximum run duration, or are scheduled to stop
+	if workspace.Status.Phase == dw.DevWorkspaceStatusRunning {
+		if stopReason, _ := checkForAutomaticStop(workspace, reqLogger); stopReason != "" {
+			reqLogger.Info("Stopping DevWorkspace", "reason", stopReason)
+			patch := []byte(fmt.Sprintf(`{"metadata":{"annotations":{%q:%q}},"spec":{"started":false}}`,
+				constants.DevWorkspaceStopReasonAnnotation, stopReason))
+			err := r.Client.Patch(context.Background(), workspace, client.RawPatch(types.MergePatchType, patch))
+			if err != nil {
+				return reconcile.Result{}, err
+			}
+			return reconcile.Result{Requeue: true}, nil
+		}
 	}
 
 	// If this is the first reconcile for a starting workspace, mark it as starting now. This is done outside the regular
@@ -181,6 +235,23 @@ func (r *DevWorkspaceReconciler) Reconcile(ctx context.Context, req ctrl.Request
 				Message:            "DevWorkspace is starting",
 			},
 		}
+		// Check that the workspace does not exceed configured quotas. Quotas are only checked when a workspace is
+		// started, to avoid failing already-started workspaces when the quotas are changed.
+		msg, err := quota.CheckTotalQuota(ctx, r.Client, workspace, config.Workspace.Quotas, true)
+		if err != nil {
+			return reconcile.Result{}, err
+		}
+		if msg == "" {
+			msg, err = quota.CheckRunningQuotas(ctx, r.Client, workspace, config.Workspace.Quotas, true)
+			if err != nil {
+				return reconcile.Result{}, err
+			}
+		}
+		if msg != "" {
+			failStatus := currentStatus{}
+			result, err := r.failWorkspace(workspace, fmt.Sprintf("Cannot start DevWorkspace: %s", msg), metrics.ReasonQuotaExceeded, reqLogger, &failStatus)
+			return r.updateWorkspaceStatus(workspace, reqLogger, &failStatus, result, err)
+		}
 		err = r.Status().Update(ctx, workspace)
 		if err == nil {
 			metrics.WorkspaceStarted(workspace, reqLogger)
@@ -220,6 +291,25 @@ func (r *DevWorkspaceReconciler) Reconcile(ctx context.Context, req ctrl.Request
 		return reconcile.Result{Requeue: true}, err
 	}
 
+	// Record the time of requested restarts; this is propagated to the workspace's deployments to trigger a rollout
+	if _, ok := clusterWorkspace.Annotations[constants.DevWorkspaceRestartAnnotation]; ok {
+		reqLogger.Info("Restarting DevWorkspace")
+		delete(clusterWorkspace.Annotations, constants.DevWorkspaceRestartAnnotation)
+		clusterWorkspace.Annotations[constants.DevWorkspaceRestartedAtAnnotation] = clock.Now().UTC().Format(time.RFC3339)
+		reconcileStatus.setConditionFalse(conditions.Restarted, "Restarting DevWorkspace")
+		err = r.Update(ctx, clusterWorkspace)
+		return reconcile.Result{Requeue: true}, err
+	}
+	if restartedAt, ok := workspace.Annotations[constants.DevWorkspaceRestartedAtAnnotation]; ok {
+		// Keep reporting a completed restart; otherwise, the restart is in progress until the workspace is ready
+		restartedCondition := conditions.GetConditionByType(workspace.Status.Conditions, conditions.Restarted)
+		if restartedCondition != nil && restartedCondition.Status == corev1.ConditionTrue && restartedCondition.Message == getRestartedMessage(restartedAt) {
+			reconcileStatus.setConditionTrue(conditions.Restarted, restartedCondition.Message)
+		} else {
+			reconcileStatus.setConditionFalse(conditions.Restarted, fmt.Sprintf("Restarting DevWorkspace (requested at %s)", restartedAt))
+		}
+	}
+
 	timing.SetTime(timingInfo, timing.ComponentsCreated)
 	// TODO#185 : Temporarily do devfile flattening in main reconcile loop; this should be moved to a subcontroller.
 	flattenHelpers := flatten.ResolverTools{
@@ -264,6 +354,45 @@ func (r *DevWorkspaceReconciler) Reconcile(ctx context.Context, req ctrl.Request
 		}
 	}
 
+	// Migrate storage if the DevWorkspace's storage type was changed since it was last started
+	if updated, err := r.migrateStorage(workspace, clusterWorkspace, &reconcileStatus, clusterAPI, reqLogger); err != nil {
+		switch storageErr := err.(type) {
+		case *storage.NotReadyError:
+			reqLogger.Info(storageErr.Message)
+			reconcileStatus.setConditionFalse(conditions.StorageReady, fmt.Sprintf("Migrating storage: %s", storageErr.Message))
+			return reconcile.Result{Requeue: true, RequeueAfter: storageErr.RequeueAfter}, nil
+		case *storage.ProvisioningError:
+			return r.failWorkspace(workspace, fmt.Sprintf("Error migrating storage: %s", storageErr), metrics.ReasonInfrastructureFailure, reqLogger, &reconcileStatus)
+		default:
+			return reconcile.Result{}, storageErr
+		}
+	} else if updated {
+		return reconcile.Result{Requeue: true}, nil
+	}
+
+	// Restore storage from a VolumeSnapshot if requested
+	if updated, err := r.restoreStorage(workspace, clusterWorkspace, clusterAPI, reqLogger); err != nil {
+		switch storageErr := err.(type) {
+		case *storage.NotReadyError:
+			reqLogger.Info(storageErr.Message)
+			reconcileStatus.setConditionFalse(conditions.StorageReady, fmt.Sprintf("Restoring storage: %s", storageErr.Message))
+			return reconcile.Result{Requeue: true, RequeueAfter: storageErr.RequeueAfter}, nil
+		case *storage.ProvisioningError:
+			return r.failWorkspace(workspace, fmt.Sprintf("Error restoring storage: %s", storageErr), metrics.ReasonInfrastructureFailure, reqLogger, &reconcileStatus)
+		default:
+			return reconcile.Result{}, storageErr
+		}
+	} else if updated {
+		return reconcile.Result{Requeue: true}, nil
+	}
+
+	// Replace images in container components that reference image components with the images that are built for them
+	if imagebuild.HasImageComponents(&workspace.Spec.Template) {
+		if err := imagebuild.SubstituteBuiltImages(workspace.Status.DevWorkspaceId, &workspace.Spec.Template); err != nil {
+			return r.failWorkspace(workspace, fmt.Sprintf("Error processing image components: %s", err), metrics.ReasonBadRequest, reqLogger, &reconcileStatus)
+		}
+	}
+
 	// Add init container to clone projects
 	projects.AddProjectClonerComponent(&workspace.Spec.Template)
 
@@ -294,6 +423,24 @@ func (r *DevWorkspaceReconciler) Reconcile(ctx context.Context, req ctrl.Request
 		return reconcile.Result{Requeue: true}, rbacStatus.Err
 	}
 
+	// Apply objects defined in Kubernetes and OpenShift components to the cluster
+	if kubecomponents.HasKubernetesComponents(&workspace.Spec.Template) || kubecomponents.HasAppliedObjects(clusterWorkspace) {
+		err = kubecomponents.SyncKubernetesComponents(workspace, clusterWorkspace, kubecomponents.NewHTTPGetter(ctx), clusterAPI)
+		if err != nil {
+			switch kubeErr := err.(type) {
+			case *kubecomponents.NotReadyError:
+				reqLogger.Info(kubeErr.Message)
+				reconcileStatus.setConditionFalse(conditions.KubeComponentsReady, "Waiting for objects from Kubernetes components to be ready")
+				return reconcile.Result{Requeue: true, RequeueAfter: kubeErr.RequeueAfter}, nil
+			case *kubecomponents.ProvisioningError:
+				return r.failWorkspace(workspace, fmt.Sprintf("Error provisioning Kubernetes components: %s", kubeErr), metrics.ReasonBadRequest, reqLogger, &reconcileStatus)
+			default:
+				return reconcile.Result{}, kubeErr
+			}
+		}
+		reconcileStatus.setConditionTrue(conditions.KubeComponentsReady, "Kubernetes components ready")
+	}
+
 	// Step two: Create routing, and wait for routing to be ready
 	timing.SetTime(timingInfo, timing.RoutingCreated)
 	routingStatus := wsprovision.SyncRoutingToCluster(workspace, clusterAPI)
@@ -374,8 +521,29 @@ func (r *DevWorkspaceReconciler) Reconcile(ctx context.Context, req ctrl.Request
 		return reconcile.Result{Requeue: pullSecretStatus.Requeue}, pullSecretStatus.Err
 	}
 	allPodAdditions = append(allPodAdditions, pullSecretStatus.PodAdditions)
+	if imagebuild.HasImageComponents(&workspace.Spec.Template) {
+		allPodAdditions = append(allPodAdditions, imagebuild.GetPullSecretPodAdditions())
+	}
 	reconcileStatus.setConditionTrue(conditions.PullSecretsReady, "DevWorkspace secrets ready")
 
+	// Build images for image components before starting the workspace deployment
+	if imagebuild.HasImageComponents(&workspace.Spec.Template) {
+		err = imagebuild.BuildImages(workspace, clusterAPI)
+		if err != nil {
+			switch buildErr := err.(type) {
+			case *imagebuild.NotReadyError:
+				reqLogger.Info(buildErr.Message)
+				reconcileStatus.setConditionFalse(conditions.ImagesBuilt, buildErr.Message)
+				return reconcile.Result{Requeue: true, RequeueAfter: buildErr.RequeueAfter}, nil
+			case *imagebuild.ProvisioningError:
+				return r.failWorkspace(workspace, fmt.Sprintf("Error building images: %s", buildErr), metrics.ReasonBadRequest, reqLogger, &reconcileStatus)
+			default:
+				return reconcile.Result{}, buildErr
+			}
+		}
+		reconcileStatus.setConditionTrue(conditions.ImagesBuilt, "Images built")
+	}
+
 	// Step six: Create deployment and wait for it to be ready
 	timing.SetTime(timingInfo, timing.DeploymentCreated)
 	deploymentStatus := wsprovision.SyncDeploymentToCluster(workspace, allPodAdditions, serviceAcctName, clusterAPI)
@@ -385,7 +553,11 @@ func (r *DevWorkspaceReconciler) Reconcile(ctx context.Context, req ctrl.Request
 			return r.failWorkspace(workspace, deploymentStatus.Info(), failureReason, reqLogger, &reconcileStatus)
 		}
 		reqLogger.Info("Waiting on deployment to be ready")
-		reconcileStatus.setConditionFalse(conditions.DeploymentReady, "Waiting for workspace deployment")
+		waitingMessage := "Waiting for workspace deployment"
+		if deploymentStatus.Message != "" {
+			waitingMessage = deploymentStatus.Message
+		}
+		reconcileStatus.setConditionFalse(conditions.DeploymentReady, waitingMessage)
 		if !deploymentStatus.Requeue && deploymentStatus.Err == nil {
 			return reconcile.Result{RequeueAfter: startingWorkspaceRequeueInterval}, nil
 		}
@@ -394,22 +566,35 @@ func (r *DevWorkspaceReconciler) Reconcile(ctx context.Context, req ctrl.Request
 	reconcileStatus.setConditionTrue(conditions.DeploymentReady, "DevWorkspace deployment ready")
 	timing.SetTime(timingInfo, timing.DeploymentReady)
 
-	serverReady, err := checkServerStatus(clusterWorkspace)
+	// Step seven: Wait for endpoints to be reachable
+	endpointsReady, err := syncEndpointsReady(clusterWorkspace, routingStatus.ExposedEndpoints, &reconcileStatus, clusterAPI)
 	if err != nil {
 		return reconcile.Result{}, err
 	}
-	if !serverReady {
-		reconcileStatus.setConditionFalse(dw.DevWorkspaceReady, "Waiting for editor to start")
-		return reconcile.Result{RequeueAfter: 1 * time.Second}, nil
+	if !endpointsReady {
+		reqLogger.Info("Waiting on endpoints to be ready")
+		reconcileStatus.setConditionFalse(dw.DevWorkspaceReady, "Waiting for endpoints to be ready")
+		return reconcile.Result{RequeueAfter: getEndpointProbeBackoff(clusterWorkspace)}, nil
 	}
 	timing.SetTime(timingInfo, timing.DevWorkspaceReady)
 	timing.SummarizeStartup(clusterWorkspace)
 	reconcileStatus.setConditionTrue(dw.DevWorkspaceReady, "")
 	reconcileStatus.phase = dw.DevWorkspaceStatusRunning
-	return reconcile.Result{}, nil
+	checkStorageUsage(clusterWorkspace, &reconcileStatus)
+	if restartedAt, ok := workspace.Annotations[constants.DevWorkspaceRestartedAtAnnotation]; ok {
+		reconcileStatus.setConditionTrue(conditions.Restarted, getRestartedMessage(restartedAt))
+	}
+
+	// Requeue to check whether the workspace should be stopped due to inactivity, its maximum run duration, or its
+	// stop schedule, or should be backed up according to its backup schedule
+	_, timeUntilStop := checkForAutomaticStop(clusterWorkspace, reqLogger)
+	if timeUntilBackup > 0 && (timeUntilStop == 0 || timeUntilBackup < timeUntilStop) {
+		return reconcile.Result{RequeueAfter: timeUntilBackup}, nil
+	}
+	return reconcile.Result{RequeueAfter: timeUntilStop}, nil
 }
 
-func (r *DevWorkspaceReconciler) stopWorkspace(workspace *dw.DevWorkspace, logger logr.Logger) (reconcile.Result, error) {
+func (r *DevWorkspaceReconciler) stopWorkspace(workspace *dw.DevWorkspace, clusterAPI sync.ClusterAPI, logger logr.Logger) (reconcile.Result, error) {
 	status := currentStatus{phase: dw.DevWorkspaceStatusStopping}
 	if workspace.Status.Phase == devworkspacePhaseFailing || workspace.Status.Phase == dw.DevWorkspaceStatusFailed {
 		status.phase = workspace.Status.Phase
@@ -419,7 +604,7 @@ func (r *DevWorkspaceReconciler) stopWorkspace(workspace *dw.DevWorkspace, logge
 		}
 	}
 
-	stopped, err := r.doStop(workspace, logger)
+	stopped, err := r.doStop(workspace, clusterAPI, logger)
 	if err != nil {
 		return reconcile.Result{}, err
 	}
@@ -433,11 +618,28 @@ func (r *DevWorkspaceReconciler) stopWorkspace(workspace *dw.DevWorkspace, logge
 			status.phase = dw.DevWorkspaceStatusStopped
 			status.setConditionFalse(conditions.Started, "Workspace is stopped")
 		}
+
+		// Migrate storage if the DevWorkspace's storage type was changed since it was last started
+		if migratedCondition := conditions.GetConditionByType(workspace.Status.Conditions, conditions.StorageMigrated); migratedCondition != nil && migratedCondition.Status == corev1.ConditionTrue {
+			status.setCondition(conditions.StorageMigrated, *migratedCondition)
+		}
+		_, err := r.migrateStorage(workspace, workspace, &status, clusterAPI, logger)
+		if err != nil {
+			switch storageErr := err.(type) {
+			case *storage.NotReadyError:
+				logger.Info(storageErr.Message)
+				return r.updateWorkspaceStatus(workspace, logger, &status, reconcile.Result{Requeue: true, RequeueAfter: storageErr.RequeueAfter}, nil)
+			case *storage.ProvisioningError:
+				logger.Info(fmt.Sprintf("Failed to migrate DevWorkspace storage: %s", storageErr))
+			default:
+				return reconcile.Result{}, err
+			}
+		}
 	}
 	return r.updateWorkspaceStatus(workspace, logger, &status, reconcile.Result{}, nil)
 }
 
-func (r *DevWorkspaceReconciler) doStop(workspace *dw.DevWorkspace, logger logr.Logger) (stopped bool, err error) {
+func (r *DevWorkspaceReconciler) doStop(workspace *dw.DevWorkspace, clusterAPI sync.ClusterAPI, logger logr.Logger) (stopped bool, err error) {
 	workspaceDeployment := &appsv1.Deployment{}
 	namespaceName := types.NamespacedName{
 		Name:      common.DeploymentName(workspace.Status.DevWorkspaceId),
@@ -483,7 +685,12 @@ func (r *DevWorkspaceReconciler) doStop(workspace *dw.DevWorkspace, logger logr.
 		return false, nil
 	}
 
-	if workspaceDeployment.Status.Replicas == 0 {
+	dedicatedPodsStopped, err := wsprovision.ScaleDedicatedPodsToZero(workspace, clusterAPI)
+	if err != nil && !k8sErrors.IsConflict(err) {
+		return false, err
+	}
+
+	if workspaceDeployment.Status.Replicas == 0 && dedicatedPodsStopped {
 		return true, nil
 	}
 	return false, nil
@@ -523,6 +730,32 @@ func (r *DevWorkspaceReconciler) syncTimingToCluster(
 	}
 }
 
+func getRestartedMessage(restartedAt string) string {
+	return fmt.Sprintf("DevWorkspace restarted (requested at %s)", restartedAt)
+}
+
+// clearRestartAnnotations removes annotations used to request and track restarts from a workspace. Returns true if the
+// workspace was modified.
+func clearRestartAnnotations(workspace *dw.DevWorkspace) (modified bool) {
+	for _, annotation := range []string{constants.DevWorkspaceRestartAnnotation, constants.DevWorkspaceRestartedAtAnnotation} {
+		if _, ok := workspace.Annotations[annotation]; ok {
+			delete(workspace.Annotations, annotation)
+			modified = true
+		}
+	}
+	return modified
+}
+
+// clearEndpointStatusAnnotation removes the endpoint status annotation from a workspace, as the status of endpoints
+// is only tracked while the workspace is running. Returns true if the workspace was modified.
+func clearEndpointStatusAnnotation(workspace *dw.DevWorkspace) (modified bool) {
+	if _, ok := workspace.Annotations[constants.DevWorkspaceEndpointStatusAnnotation]; ok {
+		delete(workspace.Annotations, constants.DevWorkspaceEndpointStatusAnnotation)
+		return true
+	}
+	return false
+}
+
 func getWorkspaceId(instance *dw.DevWorkspace) (string, error) {
 	uid, err := uuid.Parse(string(instance.UID))
 	if err != nil {
@@ -0,0 +1,243 @@
+//
+// Copyright (c) 2019-2021 Red Hat, Inc.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+	gosync "sync"
+	"time"
+
+	dw "github.com/devfile/api/v2/pkg/apis/workspaces/v1alpha2"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/devfile/devworkspace-operator/apis/controller/v1alpha1"
+	"github.com/devfile/devworkspace-operator/pkg/conditions"
+	"github.com/devfile/devworkspace-operator/pkg/constants"
+	"github.com/devfile/devworkspace-operator/pkg/provision/sync"
+)
+
+const (
+	// endpointProbeTimeout is the maximum duration of a single probe of an endpoint
+	endpointProbeTimeout = 3 * time.Second
+	// endpointProbeMinBackoff and endpointProbeMaxBackoff bound the delay between probes of endpoints that are not
+	// ready. The delay grows with the time spent waiting for endpoints since the workspace deployment became ready.
+	endpointProbeMinBackoff = 1 * time.Second
+	endpointProbeMaxBackoff = 15 * time.Second
+
+	// mainEndpointHealthPath is the path probed for the endpoint of type "main" if it does not specify a health path
+	mainEndpointHealthPath = "healthz"
+
+	endpointStatusReady      = "Ready"
+	endpointStatusNotReady   = "NotReady"
+	endpointStatusNotChecked = "NotChecked"
+)
+
+// endpointStatus is the readiness of a single endpoint, as recorded in the DevWorkspaceEndpointStatusAnnotation
+// annotation. If the endpoint is not ready, Message describes why.
+type endpointStatus struct {
+	Status  string `json:"status"`
+	Message string `json:"message,omitempty"`
+}
+
+// syncEndpointsReady probes the public endpoints of a workspace that require a readiness check (see
+// needsReadinessCheck) and sets the EndpointsReady condition accordingly, listing any endpoints that are not ready in
+// the condition's message. The readiness of each endpoint is recorded in the DevWorkspaceEndpointStatusAnnotation
+// annotation on clusterWorkspace. Once a workspace is running, its endpoints are not probed again, to avoid marking a
+// workspace as starting when e.g. an application served on one of its endpoints is stopped.
+//
+// Returns ready if all probed endpoints are ready, and an error if the endpoint status annotation could not be updated.
+func syncEndpointsReady(clusterWorkspace *dw.DevWorkspace, exposedEndpoints map[string]v1alpha1.ExposedEndpointList, status *currentStatus, clusterAPI sync.ClusterAPI) (ready bool, err error) {
+	if clusterWorkspace.Status.Phase == dw.DevWorkspaceStatusRunning {
+		readyCondition := conditions.GetConditionByType(clusterWorkspace.Status.Conditions, conditions.EndpointsReady)
+		if readyCondition != nil && readyCondition.Status == corev1.ConditionTrue {
+			status.setCondition(conditions.EndpointsReady, *readyCondition)
+			return true, nil
+		}
+	}
+
+	results := probeEndpoints(exposedEndpoints)
+	if err := updateEndpointStatusAnnotation(clusterWorkspace, results, clusterAPI); err != nil {
+		return false, err
+	}
+
+	var notReady []string
+	for name, result := range results {
+		if result.Status == endpointStatusNotReady {
+			notReady = append(notReady, fmt.Sprintf("%s (%s)", name, result.Message))
+		}
+	}
+	if len(notReady) > 0 {
+		sort.Strings(notReady)
+		status.setConditionFalse(conditions.EndpointsReady, fmt.Sprintf("Waiting for endpoints to be ready: %s", strings.Join(notReady, ", ")))
+		return false, nil
+	}
+	status.setConditionTrue(conditions.EndpointsReady, "Endpoints ready")
+	return true, nil
+}
+
+// needsReadinessCheck returns whether an endpoint should be probed before the workspace enters the Running phase. All
+// public endpoints are probed, except UDP endpoints, which cannot be probed, and endpoints that opt out using the
+// SkipEndpointReadinessCheckAttribute attribute.
+func needsReadinessCheck(endpoint v1alpha1.ExposedEndpoint) bool {
+	if endpoint.Attributes.GetBoolean(constants.SkipEndpointReadinessCheckAttribute, nil) {
+		return false
+	}
+	return !strings.HasPrefix(endpoint.Url, "udp://")
+}
+
+// probeEndpoints probes all endpoints that require a readiness check in parallel, returning a map of endpoint names
+// to their status.
+func probeEndpoints(exposedEndpoints map[string]v1alpha1.ExposedEndpointList) map[string]endpointStatus {
+	results := map[string]endpointStatus{}
+	var mu gosync.Mutex
+	var wg gosync.WaitGroup
+	for _, endpoints := range exposedEndpoints {
+		for _, endpoint := range endpoints {
+			if !needsReadinessCheck(endpoint) {
+				results[endpoint.Name] = endpointStatus{Status: endpointStatusNotChecked}
+				continue
+			}
+			wg.Add(1)
+			go func(endpoint v1alpha1.ExposedEndpoint) {
+				defer wg.Done()
+				result := endpointStatus{Status: endpointStatusReady}
+				if err := probeEndpoint(endpoint); err != nil {
+					result = endpointStatus{Status: endpointStatusNotReady, Message: err.Error()}
+				}
+				mu.Lock()
+				results[endpoint.Name] = result
+				mu.Unlock()
+			}(endpoint)
+		}
+	}
+	wg.Wait()
+	return results
+}
+
+// probeEndpoint checks whether an endpoint is ready, returning an error describing why it is not. HTTP and websocket
+// endpoints are ready once a request to their health path returns any response other than a server error (5xx), as a
+// response in the 3xx or 4xx range means the endpoint is served but e.g. requires authentication or does not
+// implement the health path. TCP endpoints are ready once a connection can be opened. Endpoints using other protocols
+// cannot be probed and are always considered ready.
+func probeEndpoint(endpoint v1alpha1.ExposedEndpoint) error {
+	if endpoint.Url == "" {
+		return errors.New("URL not yet assigned")
+	}
+	endpointURL, err := url.Parse(endpoint.Url)
+	if err != nil {
+		return fmt.Errorf("invalid URL: %w", err)
+	}
+
+	switch endpointURL.Scheme {
+	case "tcp":
+		conn, err := net.DialTimeout("tcp", endpointURL.Host, endpointProbeTimeout)
+		if err != nil {
+			return getProbeError(err)
+		}
+		conn.Close()
+		return nil
+	case "http", "https":
+	case "ws":
+		endpointURL.Scheme = "http"
+	case "wss":
+		endpointURL.Scheme = "https"
+	default:
+		return nil
+	}
+
+	healthPath := endpoint.Attributes.GetString(constants.EndpointHealthPathAttribute, nil)
+	if healthPath == "" && endpoint.Attributes.GetString(string(v1alpha1.TypeEndpointAttribute), nil) == string(v1alpha1.MainEndpointType) {
+		healthPath = mainEndpointHealthPath
+	}
+	if healthPath != "" {
+		endpointURL.Path = path.Join("/", endpointURL.Path, healthPath)
+	}
+
+	resp, err := healthHttpClient.Get(endpointURL.String())
+	if err != nil {
+		return getProbeError(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= http.StatusInternalServerError {
+		return errors.New(resp.Status)
+	}
+	return nil
+}
+
+// getProbeError returns a short description of an error encountered while probing an endpoint, omitting the
+// request method and URL included in errors returned by the HTTP client.
+func getProbeError(err error) error {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		err = urlErr.Err
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return errors.New("timed out")
+	}
+	var opErr *net.OpError
+	if errors.As(err, &opErr) {
+		err = opErr.Err
+	}
+	return err
+}
+
+// updateEndpointStatusAnnotation records the status of each endpoint in the DevWorkspaceEndpointStatusAnnotation
+// annotation on clusterWorkspace, if it has changed. Updates that only change this annotation do not trigger a
+// reconcile (see predicates), so that endpoints are only probed again after getEndpointProbeBackoff.
+func updateEndpointStatusAnnotation(clusterWorkspace *dw.DevWorkspace, results map[string]endpointStatus, clusterAPI sync.ClusterAPI) error {
+	// Maps are marshalled with sorted keys, so the value is stable between reconciles
+	value, err := json.Marshal(results)
+	if err != nil {
+		return err
+	}
+	if clusterWorkspace.Annotations[constants.DevWorkspaceEndpointStatusAnnotation] == string(value) {
+		return nil
+	}
+	patch := client.MergeFrom(clusterWorkspace.DeepCopy())
+	if clusterWorkspace.Annotations == nil {
+		clusterWorkspace.Annotations = map[string]string{}
+	}
+	clusterWorkspace.Annotations[constants.DevWorkspaceEndpointStatusAnnotation] = string(value)
+	return clusterAPI.Client.Patch(clusterAPI.Ctx, clusterWorkspace, patch)
+}
+
+// getEndpointProbeBackoff returns how long to wait before probing the endpoints of a workspace again. The delay is half
+// the time that has passed since the workspace's deployment became ready, bounded by endpointProbeMinBackoff and
+// endpointProbeMaxBackoff.
+func getEndpointProbeBackoff(workspace *dw.DevWorkspace) time.Duration {
+	backoff := endpointProbeMinBackoff
+	deploymentReady := conditions.GetConditionByType(workspace.Status.Conditions, conditions.DeploymentReady)
+	if deploymentReady != nil && deploymentReady.Status == corev1.ConditionTrue {
+		backoff = clock.Since(deploymentReady.LastTransitionTime.Time) / 2
+	}
+	if backoff < endpointProbeMinBackoff {
+		return endpointProbeMinBackoff
+	}
+	if backoff > endpointProbeMaxBackoff {
+		return endpointProbeMaxBackoff
+	}
+	return backoff
+}
@@ -0,0 +1,359 @@
+//
+// Copyright (c) 2019-2021 Red Hat, Inc.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	dw "github.com/devfile/api/v2/pkg/apis/workspaces/v1alpha2"
+	"github.com/devfile/api/v2/pkg/attributes"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+
+	"github.com/devfile/devworkspace-operator/apis/controller/v1alpha1"
+	"github.com/devfile/devworkspace-operator/pkg/conditions"
+	"github.com/devfile/devworkspace-operator/pkg/constants"
+	"github.com/devfile/devworkspace-operator/pkg/provision/sync"
+)
+
+// setupEndpointTestServer starts an HTTP server for the duration of a test. Requests to /healthz return
+// healthzStatus, requests to /redirect are redirected, and all other requests return 200 OK.
+func setupEndpointTestServer(t *testing.T, healthzStatus int) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(healthzStatus)
+	})
+	mux.HandleFunc("/redirect", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "http://identity-provider.invalid/login", http.StatusFound)
+	})
+	mux.HandleFunc("/", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+// getClosedAddress returns a local address that refuses connections
+func getClosedAddress(t *testing.T) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to open listener: %s", err)
+	}
+	address := listener.Addr().String()
+	listener.Close()
+	return address
+}
+
+func getMainEndpoint(url string) v1alpha1.ExposedEndpoint {
+	return v1alpha1.ExposedEndpoint{
+		Name:       "main",
+		Url:        url,
+		Attributes: attributes.Attributes{}.PutString(string(v1alpha1.TypeEndpointAttribute), string(v1alpha1.MainEndpointType)),
+	}
+}
+
+func getHealthPathEndpoint(name, url, healthPath string) v1alpha1.ExposedEndpoint {
+	return v1alpha1.ExposedEndpoint{
+		Name:       name,
+		Url:        url,
+		Attributes: attributes.Attributes{}.PutString(constants.EndpointHealthPathAttribute, healthPath),
+	}
+}
+
+func TestProbeEndpoint(t *testing.T) {
+	healthyServer := setupEndpointTestServer(t, http.StatusOK)
+	unhealthyServer := setupEndpointTestServer(t, http.StatusServiceUnavailable)
+	unauthorizedServer := setupEndpointTestServer(t, http.StatusUnauthorized)
+	closedAddress := getClosedAddress(t)
+	tcpListener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to open listener: %s", err)
+	}
+	t.Cleanup(func() { tcpListener.Close() })
+
+	tests := []struct {
+		name          string
+		endpoint      v1alpha1.ExposedEndpoint
+		expectedError string
+	}{
+		{
+			name:     "Main endpoint is ready when healthz succeeds",
+			endpoint: getMainEndpoint(healthyServer.URL + "/"),
+		},
+		{
+			name:          "Main endpoint is not ready when healthz returns server error",
+			endpoint:      getMainEndpoint(unhealthyServer.URL + "/"),
+			expectedError: "503 Service Unavailable",
+		},
+		{
+			name:     "Main endpoint is ready when healthz requires authentication",
+			endpoint: getMainEndpoint(unauthorizedServer.URL + "/"),
+		},
+		{
+			name:     "Health path overrides healthz for main endpoint",
+			endpoint: getHealthPathEndpoint("main", unhealthyServer.URL+"/", "/api/health"),
+		},
+		{
+			name:     "Redirects are not followed",
+			endpoint: getHealthPathEndpoint("app", unhealthyServer.URL, "/redirect"),
+		},
+		{
+			name:     "Websocket endpoints are probed over HTTP",
+			endpoint: getHealthPathEndpoint("app", strings.Replace(healthyServer.URL, "http://", "ws://", 1), "/"),
+		},
+		{
+			name:          "Endpoint is not ready when connection is refused",
+			endpoint:      getHealthPathEndpoint("app", "http://"+closedAddress, "/"),
+			expectedError: "connection refused",
+		},
+		{
+			name:          "Endpoint is not ready without URL",
+			endpoint:      getHealthPathEndpoint("app", "", "/"),
+			expectedError: "URL not yet assigned",
+		},
+		{
+			name:     "TCP endpoint is ready when connection can be opened",
+			endpoint: getHealthPathEndpoint("tcp", "tcp://"+tcpListener.Addr().String(), "/"),
+		},
+		{
+			name:          "TCP endpoint is not ready when connection is refused",
+			endpoint:      getHealthPathEndpoint("tcp", "tcp://"+closedAddress, "/"),
+			expectedError: "connection refused",
+		},
+		{
+			name:     "UDP endpoints are not probed",
+			endpoint: getHealthPathEndpoint("udp", "udp://"+closedAddress, "/"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := probeEndpoint(tt.endpoint)
+			if tt.expectedError == "" {
+				assert.NoError(t, err)
+			} else if assert.Error(t, err) {
+				assert.Contains(t, err.Error(), tt.expectedError)
+			}
+		})
+	}
+}
+
+func getEndpointTestClusterAPI(t *testing.T, workspace *dw.DevWorkspace) sync.ClusterAPI {
+	scheme := runtime.NewScheme()
+	if err := dw.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	return sync.ClusterAPI{
+		Ctx:    context.Background(),
+		Client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(workspace).Build(),
+		Logger: zap.New(),
+	}
+}
+
+func getEndpointTestWorkspace() *dw.DevWorkspace {
+	return &dw.DevWorkspace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-workspace",
+			Namespace: "test-namespace",
+		},
+	}
+}
+
+func TestSyncEndpointsReadyProbesPublicEndpoints(t *testing.T) {
+	server := setupEndpointTestServer(t, http.StatusServiceUnavailable)
+	closedAddress := getClosedAddress(t)
+	workspace := getEndpointTestWorkspace()
+	clusterAPI := getEndpointTestClusterAPI(t, workspace)
+	skippedEndpoint := v1alpha1.ExposedEndpoint{
+		Name:       "skipped",
+		Url:        "http://" + closedAddress,
+		Attributes: attributes.Attributes{}.PutBoolean(constants.SkipEndpointReadinessCheckAttribute, true),
+	}
+	exposedEndpoints := map[string]v1alpha1.ExposedEndpointList{
+		"tools": {
+			getMainEndpoint(server.URL + "/"),
+			getHealthPathEndpoint("api", server.URL, "/"),
+			{Name: "app", Url: "http://" + closedAddress},
+			{Name: "udp", Url: "udp://" + closedAddress},
+			skippedEndpoint,
+		},
+	}
+
+	status := &currentStatus{}
+	ready, err := syncEndpointsReady(workspace, exposedEndpoints, status, clusterAPI)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.False(t, ready)
+	condition := status.conditions[conditions.EndpointsReady]
+	assert.Equal(t, corev1.ConditionFalse, condition.Status)
+	assert.Regexp(t, `^Waiting for endpoints to be ready: app \(.*connection refused\), main \(503 Service Unavailable\)$`, condition.Message,
+		"Condition message should list endpoints that are not ready")
+
+	clusterWorkspace := &dw.DevWorkspace{}
+	if !assert.NoError(t, clusterAPI.Client.Get(clusterAPI.Ctx, client.ObjectKeyFromObject(workspace), clusterWorkspace)) {
+		return
+	}
+	endpointStatuses := map[string]endpointStatus{}
+	if !assert.NoError(t, json.Unmarshal([]byte(clusterWorkspace.Annotations[constants.DevWorkspaceEndpointStatusAnnotation]), &endpointStatuses)) {
+		return
+	}
+	assert.Equal(t, endpointStatusNotReady, endpointStatuses["main"].Status)
+	assert.Equal(t, "503 Service Unavailable", endpointStatuses["main"].Message)
+	assert.Equal(t, endpointStatus{Status: endpointStatusReady}, endpointStatuses["api"])
+	assert.Equal(t, endpointStatusNotReady, endpointStatuses["app"].Status, "Endpoints should be probed by default")
+	assert.Equal(t, endpointStatus{Status: endpointStatusNotChecked}, endpointStatuses["udp"], "UDP endpoints should not be probed")
+	assert.Equal(t, endpointStatus{Status: endpointStatusNotChecked}, endpointStatuses["skipped"], "Endpoints that opt out should not be probed")
+
+	exposedEndpoints["tools"] = exposedEndpoints["tools"][3:]
+	status = &currentStatus{}
+	ready, err = syncEndpointsReady(clusterWorkspace, exposedEndpoints, status, clusterAPI)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.True(t, ready, "Endpoints without readiness check should not block workspace")
+	assert.Equal(t, corev1.ConditionTrue, status.conditions[conditions.EndpointsReady].Status)
+}
+
+func TestSyncEndpointsReadyOnlyUpdatesChangedStatus(t *testing.T) {
+	server := setupEndpointTestServer(t, http.StatusOK)
+	workspace := getEndpointTestWorkspace()
+	clusterAPI := getEndpointTestClusterAPI(t, workspace)
+	exposedEndpoints := map[string]v1alpha1.ExposedEndpointList{
+		"tools": {getMainEndpoint(server.URL + "/")},
+	}
+
+	clusterWorkspace := &dw.DevWorkspace{}
+	for i := 0; i < 2; i++ {
+		if !assert.NoError(t, clusterAPI.Client.Get(clusterAPI.Ctx, client.ObjectKeyFromObject(workspace), clusterWorkspace)) {
+			return
+		}
+		_, err := syncEndpointsReady(clusterWorkspace, exposedEndpoints, &currentStatus{}, clusterAPI)
+		if !assert.NoError(t, err) {
+			return
+		}
+	}
+	updatedWorkspace := &dw.DevWorkspace{}
+	if !assert.NoError(t, clusterAPI.Client.Get(clusterAPI.Ctx, client.ObjectKeyFromObject(workspace), updatedWorkspace)) {
+		return
+	}
+	assert.Equal(t, clusterWorkspace.ResourceVersion, updatedWorkspace.ResourceVersion, "Should not update workspace if endpoint status is unchanged")
+	assert.Equal(t, `{"main":{"status":"Ready"}}`, updatedWorkspace.Annotations[constants.DevWorkspaceEndpointStatusAnnotation])
+}
+
+func TestSyncEndpointsReadyDoesNotProbeRunningWorkspace(t *testing.T) {
+	closedAddress := getClosedAddress(t)
+	readyCondition := dw.DevWorkspaceCondition{
+		Type:               conditions.EndpointsReady,
+		Status:             corev1.ConditionTrue,
+		Message:            "Endpoints ready",
+		LastTransitionTime: metav1.Time{Time: testTime},
+	}
+	workspace := getEndpointTestWorkspace()
+	workspace.Status = dw.DevWorkspaceStatus{
+		Phase:      dw.DevWorkspaceStatusRunning,
+		Conditions: []dw.DevWorkspaceCondition{readyCondition},
+	}
+	exposedEndpoints := map[string]v1alpha1.ExposedEndpointList{
+		"tools": {getMainEndpoint("http://" + closedAddress)},
+	}
+
+	status := &currentStatus{}
+	ready, err := syncEndpointsReady(workspace, exposedEndpoints, status, getEndpointTestClusterAPI(t, workspace))
+	assert.NoError(t, err)
+	assert.True(t, ready, "Endpoints should not be probed again once workspace is running")
+	assert.Equal(t, readyCondition, status.conditions[conditions.EndpointsReady], "Existing condition should be kept")
+}
+
+func TestIsEndpointStatusUpdate(t *testing.T) {
+	oldWorkspace := getEndpointTestWorkspace()
+	oldWorkspace.ResourceVersion = "1"
+
+	newWorkspace := oldWorkspace.DeepCopy()
+	newWorkspace.ResourceVersion = "2"
+	newWorkspace.Annotations = map[string]string{constants.DevWorkspaceEndpointStatusAnnotation: `{"main":{"status":"Ready"}}`}
+	assert.True(t, isEndpointStatusUpdate(oldWorkspace, newWorkspace), "Should detect updates to endpoint status only")
+	assert.False(t, predicates.Update(event.UpdateEvent{ObjectOld: oldWorkspace, ObjectNew: newWorkspace}),
+		"Should not reconcile on updates to endpoint status only")
+
+	newWorkspace.Spec.Started = true
+	assert.False(t, isEndpointStatusUpdate(oldWorkspace, newWorkspace), "Should not ignore updates that change other fields")
+
+	newWorkspace = oldWorkspace.DeepCopy()
+	newWorkspace.Status.Phase = dw.DevWorkspaceStatusRunning
+	assert.False(t, isEndpointStatusUpdate(oldWorkspace, newWorkspace), "Should not ignore updates that do not change endpoint status")
+}
+
+func TestGetEndpointProbeBackoff(t *testing.T) {
+	setTestClock(t)
+	tests := []struct {
+		name            string
+		readySince      *time.Time
+		expectedBackoff time.Duration
+	}{
+		{
+			name:            "Minimum backoff before deployment is ready",
+			expectedBackoff: endpointProbeMinBackoff,
+		},
+		{
+			name:            "Minimum backoff just after deployment is ready",
+			readySince:      timePtr(testTime.Add(-1 * time.Second)),
+			expectedBackoff: endpointProbeMinBackoff,
+		},
+		{
+			name:            "Backoff is half the time since deployment is ready",
+			readySince:      timePtr(testTime.Add(-10 * time.Second)),
+			expectedBackoff: 5 * time.Second,
+		},
+		{
+			name:            "Maximum backoff",
+			readySince:      timePtr(testTime.Add(-5 * time.Minute)),
+			expectedBackoff: endpointProbeMaxBackoff,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			workspace := &dw.DevWorkspace{}
+			if tt.readySince != nil {
+				workspace.Status.Conditions = []dw.DevWorkspaceCondition{
+					{
+						Type:               conditions.DeploymentReady,
+						Status:             corev1.ConditionTrue,
+						LastTransitionTime: metav1.Time{Time: *tt.readySince},
+					},
+				}
+			}
+			assert.Equal(t, tt.expectedBackoff, getEndpointProbeBackoff(workspace))
+		})
+	}
+}
+
+func timePtr(t time.Time) *time.Time {
+	return &t
+}
@@ -28,6 +28,8 @@ import (
 	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
+	"github.com/devfile/devworkspace-operator/controllers/workspace/metrics"
+	"github.com/devfile/devworkspace-operator/pkg/config"
 	"github.com/devfile/devworkspace-operator/pkg/provision/storage"
 	wsprovision "github.com/devfile/devworkspace-operator/pkg/provision/workspace"
 )
@@ -73,12 +75,30 @@ func (r *DevWorkspaceReconciler) finalize(ctx context.Context, log logr.Logger,
 		failedStatus.setConditionTrue(dw.DevWorkspaceError, err.Error())
 		return r.updateWorkspaceStatus(workspace, r.Log, &failedStatus, reconcile.Result{}, nil)
 	}
-	err = storageProvisioner.CleanupWorkspaceStorage(workspace, sync.ClusterAPI{
+	clusterAPI := sync.ClusterAPI{
 		Ctx:    ctx,
 		Client: r.Client,
 		Scheme: r.Scheme,
 		Logger: log,
-	})
+	}
+	if config.Workspace.Backup != nil && config.Workspace.Backup.BackupOnDelete != nil && *config.Workspace.Backup.BackupOnDelete {
+		err = storage.BackupWorkspaceStorageForDeletion(workspace, clusterAPI)
+		if err != nil {
+			switch storageErr := err.(type) {
+			case *storage.NotReadyError:
+				log.Info(storageErr.Message)
+				return reconcile.Result{RequeueAfter: storageErr.RequeueAfter}, nil
+			case *storage.ProvisioningError:
+				log.Error(storageErr, "Failed to back up DevWorkspace storage")
+				failedStatus := currentStatus{phase: dw.DevWorkspaceStatusError}
+				failedStatus.setConditionTrue(dw.DevWorkspaceError, err.Error())
+				return r.updateWorkspaceStatus(workspace, r.Log, &failedStatus, reconcile.Result{}, nil)
+			default:
+				return reconcile.Result{}, storageErr
+			}
+		}
+	}
+	err = storageProvisioner.CleanupWorkspaceStorage(workspace, clusterAPI)
 	if err != nil {
 		switch storageErr := err.(type) {
 		case *storage.NotReadyError:
@@ -93,6 +113,7 @@ func (r *DevWorkspaceReconciler) finalize(ctx context.Context, log logr.Logger,
 			return reconcile.Result{}, storageErr
 		}
 	}
+	metrics.DeleteStorageUsage(workspace.Namespace, workspace.Status.DevWorkspaceId)
 	log.Info("PVC clean up successful; clearing finalizer")
 	coputil.RemoveFinalizer(workspace, storageCleanupFinalizer)
 	return reconcile.Result{}, r.Update(ctx, workspace)
@@ -26,6 +26,7 @@ const (
 	ReasonBadRequest             FailureReason = "BadRequest"
 	ReasonInfrastructureFailure  FailureReason = "InfrastructureFailure"
 	ReasonWorkspaceEngineFailure FailureReason = "WorkspaceEngineFailure"
+	ReasonQuotaExceeded          FailureReason = "QuotaExceeded"
 	ReasonUnknown                FailureReason = "Unknown"
 )
 
@@ -33,6 +34,7 @@ var devworkspaceFailureReasons = []FailureReason{
 	ReasonBadRequest,
 	ReasonInfrastructureFailure,
 	ReasonWorkspaceEngineFailure,
+	ReasonQuotaExceeded,
 	ReasonUnknown,
 }
 
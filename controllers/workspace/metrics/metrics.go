@@ -25,6 +25,9 @@ const (
 	metricSourceLabel        = "source"
 	metricsRoutingClassLabel = "routingclass"
 	metricsReasonLabel       = "reason"
+	metricsDryRunLabel       = "dry_run"
+	metricsNamespaceLabel    = "namespace"
+	metricsDevWorkspaceID    = "devworkspace_id"
 )
 
 var (
@@ -75,7 +78,42 @@ var (
 	)
 )
 
+var (
+	storageGarbageCollectedBytes = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "devworkspace",
+			Name:      "storage_gc_reclaimed_bytes_total",
+			Help:      "Total size of orphaned DevWorkspace data found on common PVCs, in bytes. Data found during dry runs is not removed",
+		},
+		[]string{
+			metricsDryRunLabel,
+		},
+	)
+	storageGarbageCollectedDirectories = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "devworkspace",
+			Name:      "storage_gc_orphaned_directories_total",
+			Help:      "Number of orphaned DevWorkspace data directories found on common PVCs. Directories found during dry runs are not removed",
+		},
+		[]string{
+			metricsDryRunLabel,
+		},
+	)
+	storageUsage = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "devworkspace",
+			Name:      "storage_usage_bytes",
+			Help:      "Disk usage of a DevWorkspace's data on the common PVC, in bytes",
+		},
+		[]string{
+			metricsNamespaceLabel,
+			metricsDevWorkspaceID,
+		},
+	)
+)
+
 func init() {
 	// Register custom metrics with the global prometheus registry
-	metrics.Registry.MustRegister(workspaceTotal, workspaceStarts, workspaceFailures, workspaceStartupTimesHist)
+	metrics.Registry.MustRegister(workspaceTotal, workspaceStarts, workspaceFailures, workspaceStartupTimesHist,
+		storageGarbageCollectedBytes, storageGarbageCollectedDirectories, storageUsage)
 }
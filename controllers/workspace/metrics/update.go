@@ -16,9 +16,12 @@
 package metrics
 
 import (
+	"strconv"
+
 	dw "github.com/devfile/api/v2/pkg/apis/workspaces/v1alpha2"
 	"github.com/go-logr/logr"
 	"github.com/prometheus/client_golang/prometheus"
+	corev1 "k8s.io/api/core/v1"
 
 	"github.com/devfile/devworkspace-operator/pkg/conditions"
 	"github.com/devfile/devworkspace-operator/pkg/config"
@@ -33,9 +36,14 @@ func WorkspaceStarted(wksp *dw.DevWorkspace, log logr.Logger) {
 // WorkspaceRunning updates metrics for workspaces entering the 'Running' phase, given a workspace. If an error is
 // encountered, the provided logger is used to log the error. This function assumes the provided workspace has
 // fully-synced conditions (i.e. the WorkspaceReady condition is present).
+//
+// The startup time of workspaces that were restarted while running is not recorded, as their Started condition
+// reflects when the workspace was originally started rather than when it was restarted.
 func WorkspaceRunning(wksp *dw.DevWorkspace, log logr.Logger) {
 	incrementMetricForWorkspace(workspaceStarts, wksp, log)
-	incrementStartTimeBucketForWorkspace(wksp, log)
+	if !isRestarted(wksp) {
+		incrementStartTimeBucketForWorkspace(wksp, log)
+	}
 }
 
 // WorkspaceFailed updates metrics for workspace entering the 'Failed' phase. If an error is encountered, the provided
@@ -44,6 +52,24 @@ func WorkspaceFailed(wksp *dw.DevWorkspace, log logr.Logger) {
 	incrementMetricForWorkspaceFailure(workspaceFailures, wksp, log)
 }
 
+// StorageGarbageCollected updates metrics for orphaned data found on the common PVC in a namespace by storage garbage
+// collection.
+func StorageGarbageCollected(orphanedBytes int64, orphanedDirectories int, dryRun bool) {
+	dryRunLabel := strconv.FormatBool(dryRun)
+	storageGarbageCollectedBytes.WithLabelValues(dryRunLabel).Add(float64(orphanedBytes))
+	storageGarbageCollectedDirectories.WithLabelValues(dryRunLabel).Add(float64(orphanedDirectories))
+}
+
+// SetStorageUsage records the disk usage of a DevWorkspace's data on the common PVC.
+func SetStorageUsage(namespace, workspaceId string, bytes int64) {
+	storageUsage.WithLabelValues(namespace, workspaceId).Set(float64(bytes))
+}
+
+// DeleteStorageUsage removes the recorded disk usage for a DevWorkspace, e.g. once it is deleted.
+func DeleteStorageUsage(namespace, workspaceId string) {
+	storageUsage.DeleteLabelValues(namespace, workspaceId)
+}
+
 func incrementMetricForWorkspace(metric *prometheus.CounterVec, wksp *dw.DevWorkspace, log logr.Logger) {
 	sourceLabel := wksp.Labels[workspaceSourceLabel]
 	if sourceLabel == "" {
@@ -73,6 +99,13 @@ func incrementMetricForWorkspaceFailure(metric *prometheus.CounterVec, wksp *dw.
 	ctr.Inc()
 }
 
+// isRestarted returns whether a workspace has been restarted since it was started, i.e. whether its Restarted
+// condition has been set in the current run of the workspace.
+func isRestarted(wksp *dw.DevWorkspace) bool {
+	restartedCondition := conditions.GetConditionByType(wksp.Status.Conditions, conditions.Restarted)
+	return restartedCondition != nil && restartedCondition.Status != corev1.ConditionUnknown
+}
+
 func incrementStartTimeBucketForWorkspace(wksp *dw.DevWorkspace, log logr.Logger) {
 	sourceLabel := wksp.Labels[workspaceSourceLabel]
 	if sourceLabel == "" {
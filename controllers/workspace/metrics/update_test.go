@@ -0,0 +1,106 @@
+//
+// Copyright (c) 2019-2021 Red Hat, Inc.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package metrics
+
+import (
+	"testing"
+	"time"
+
+	dw "github.com/devfile/api/v2/pkg/apis/workspaces/v1alpha2"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+
+	"github.com/devfile/devworkspace-operator/pkg/conditions"
+	"github.com/devfile/devworkspace-operator/pkg/config"
+)
+
+func getStartupTimeSampleCount(t *testing.T, source string) uint64 {
+	observer, err := workspaceStartupTimesHist.GetMetricWith(map[string]string{metricSourceLabel: source, metricsRoutingClassLabel: "basic"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	metric := &dto.Metric{}
+	if err := observer.(prometheus.Histogram).Write(metric); err != nil {
+		t.Fatal(err)
+	}
+	return metric.GetHistogram().GetSampleCount()
+}
+
+func getMetricsTestWorkspace(source string, restarted *corev1.ConditionStatus) *dw.DevWorkspace {
+	startTime := time.Date(2021, time.June, 1, 12, 0, 0, 0, time.UTC)
+	workspace := &dw.DevWorkspace{
+		ObjectMeta: metav1.ObjectMeta{
+			Labels: map[string]string{workspaceSourceLabel: source},
+		},
+		Spec: dw.DevWorkspaceSpec{RoutingClass: "basic"},
+		Status: dw.DevWorkspaceStatus{
+			Conditions: []dw.DevWorkspaceCondition{
+				{
+					Type:               conditions.Started,
+					Status:             corev1.ConditionTrue,
+					LastTransitionTime: metav1.Time{Time: startTime},
+				},
+				{
+					Type:               dw.DevWorkspaceReady,
+					Status:             corev1.ConditionTrue,
+					LastTransitionTime: metav1.Time{Time: startTime.Add(30 * time.Second)},
+				},
+			},
+		},
+	}
+	if restarted != nil {
+		workspace.Status.Conditions = append(workspace.Status.Conditions, dw.DevWorkspaceCondition{
+			Type:   conditions.Restarted,
+			Status: *restarted,
+		})
+	}
+	return workspace
+}
+
+func TestWorkspaceRunningRecordsStartupTime(t *testing.T) {
+	config.SetConfigForTesting(nil)
+	unknown := corev1.ConditionUnknown
+	for _, tt := range []struct {
+		source    string
+		restarted *corev1.ConditionStatus
+	}{
+		{source: "test-not-restarted"},
+		// Restarted condition is set to unknown once a restarted workspace is stopped
+		{source: "test-restarted-before-stop", restarted: &unknown},
+	} {
+		started := testutil.ToFloat64(workspaceStarts.WithLabelValues(tt.source, "basic"))
+		WorkspaceRunning(getMetricsTestWorkspace(tt.source, tt.restarted), zap.New())
+		assert.Equal(t, started+1, testutil.ToFloat64(workspaceStarts.WithLabelValues(tt.source, "basic")),
+			"Successful starts should be counted")
+		assert.Equal(t, uint64(1), getStartupTimeSampleCount(t, tt.source), "Startup time should be recorded")
+	}
+}
+
+func TestWorkspaceRunningAfterRestart(t *testing.T) {
+	config.SetConfigForTesting(nil)
+	restarted := corev1.ConditionTrue
+	source := "test-restarted"
+	WorkspaceRunning(getMetricsTestWorkspace(source, &restarted), zap.New())
+	assert.Equal(t, float64(1), testutil.ToFloat64(workspaceStarts.WithLabelValues(source, "basic")),
+		"Restarted workspaces entering the Running phase should be counted")
+	assert.Equal(t, uint64(0), getStartupTimeSampleCount(t, source),
+		"Startup time should not be recorded for restarted workspaces")
+}
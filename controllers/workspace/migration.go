@@ -0,0 +1,71 @@
+//
+// Copyright (c) 2019-2021 Red Hat, Inc.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package controllers
+
+import (
+	"fmt"
+
+	dw "github.com/devfile/api/v2/pkg/apis/workspaces/v1alpha2"
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/devfile/devworkspace-operator/pkg/conditions"
+	"github.com/devfile/devworkspace-operator/pkg/constants"
+	"github.com/devfile/devworkspace-operator/pkg/provision/storage"
+	"github.com/devfile/devworkspace-operator/pkg/provision/sync"
+)
+
+// migrateStorage migrates a DevWorkspace's data if its storage type has changed since storage was last provisioned for
+// it, as recorded in the provisioned storage type annotation on clusterWorkspace. Progress is reported in the
+// StorageMigrated condition. Once migration is complete (or if none is necessary), the annotation is updated to the
+// current storage type and updated is true. The storage type is only recorded once a DevWorkspace is started, as
+// DevWorkspaces that have never been started have no data to migrate.
+//
+// Returns storage.NotReadyError while migration is in progress, storage.ProvisioningError if migration is not possible
+// or has failed, and any other error if an unexpected problem arises.
+func (r *DevWorkspaceReconciler) migrateStorage(workspace, clusterWorkspace *dw.DevWorkspace, status *currentStatus, clusterAPI sync.ClusterAPI, logger logr.Logger) (updated bool, err error) {
+	currStorageType := storage.GetStorageType(workspace)
+	prevStorageType, ok := clusterWorkspace.Annotations[constants.DevWorkspaceStorageTypeAnnotation]
+	if ok && prevStorageType == currStorageType {
+		return false, nil
+	}
+	if !ok && !workspace.Spec.Started {
+		return false, nil
+	}
+
+	if ok {
+		if workspace.Spec.Started && workspace.Status.Phase == dw.DevWorkspaceStatusRunning {
+			err := &storage.ProvisioningError{
+				Message: fmt.Sprintf("Cannot change storage type from %s to %s while DevWorkspace is running", prevStorageType, currStorageType),
+			}
+			status.setConditionFalse(conditions.StorageMigrated, err.Error())
+			return false, err
+		}
+		if err := storage.MigrateStorage(workspace, prevStorageType, clusterAPI); err != nil {
+			status.setConditionFalse(conditions.StorageMigrated, fmt.Sprintf("Migrating storage from %s to %s: %s", prevStorageType, currStorageType, err))
+			return false, err
+		}
+		logger.Info("Migrated DevWorkspace storage", "from", prevStorageType, "to", currStorageType)
+		status.setConditionTrue(conditions.StorageMigrated, fmt.Sprintf("Migrated storage from %s to %s", prevStorageType, currStorageType))
+	}
+
+	patch := []byte(fmt.Sprintf(`{"metadata":{"annotations":{%q:%q}}}`, constants.DevWorkspaceStorageTypeAnnotation, currStorageType))
+	if err := r.Client.Patch(clusterAPI.Ctx, clusterWorkspace, client.RawPatch(types.MergePatchType, patch)); err != nil {
+		return false, err
+	}
+	return true, nil
+}
@@ -27,7 +27,8 @@ import (
 
 // predicates filters incoming events to avoid unnecessary reconciles to failed workspaces.
 // If a workspace failed and its spec is changed, we trigger reconciles to allow for fixing
-// issues in the workspace spec.
+// issues in the workspace spec. Updates that only change the endpoint status annotation are
+// ignored, as the controller requeues workspaces waiting for endpoints itself.
 var predicates = predicate.Funcs{
 	CreateFunc: func(_ event.CreateEvent) bool { return true },
 	DeleteFunc: func(_ event.DeleteEvent) bool { return true },
@@ -37,15 +38,19 @@ var predicates = predicate.Funcs{
 			return true
 		}
 
-		if newObj.Status.Phase != dw.DevWorkspaceStatusFailed {
-			return true
-		}
-
 		oldObj, ok := ev.ObjectOld.(*dw.DevWorkspace)
 		if !ok {
 			// Should never happen
 			return true
 		}
+
+		if isEndpointStatusUpdate(oldObj, newObj) {
+			return false
+		}
+
+		if newObj.Status.Phase != dw.DevWorkspaceStatusFailed {
+			return true
+		}
 		// always reconcile if resource is deleted
 		if newObj.GetDeletionTimestamp() != nil {
 			return true
@@ -57,6 +62,20 @@ var predicates = predicate.Funcs{
 	GenericFunc: func(_ event.GenericEvent) bool { return true },
 }
 
+// isEndpointStatusUpdate returns whether the only change between oldObj and newObj is to the endpoint status annotation.
+func isEndpointStatusUpdate(oldObj, newObj *dw.DevWorkspace) bool {
+	if oldObj.Annotations[constants.DevWorkspaceEndpointStatusAnnotation] == newObj.Annotations[constants.DevWorkspaceEndpointStatusAnnotation] {
+		return false
+	}
+	oldCopy, newCopy := oldObj.DeepCopy(), newObj.DeepCopy()
+	for _, workspace := range []*dw.DevWorkspace{oldCopy, newCopy} {
+		delete(workspace.Annotations, constants.DevWorkspaceEndpointStatusAnnotation)
+		workspace.ResourceVersion = ""
+		workspace.ManagedFields = nil
+	}
+	return equality.Semantic.DeepEqual(oldCopy, newCopy)
+}
+
 var podPredicates = predicate.Funcs{
 	UpdateFunc: func(ev event.UpdateEvent) bool {
 		newObj, ok := ev.ObjectNew.(*corev1.Pod)
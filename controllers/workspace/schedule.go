@@ -0,0 +1,167 @@
+//
+// Copyright (c) 2019-2021 Red Hat, Inc.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package controllers
+
+import (
+	"fmt"
+	"time"
+
+	dw "github.com/devfile/api/v2/pkg/apis/workspaces/v1alpha2"
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/devfile/devworkspace-operator/pkg/conditions"
+	"github.com/devfile/devworkspace-operator/pkg/config"
+	"github.com/devfile/devworkspace-operator/pkg/constants"
+	"github.com/devfile/devworkspace-operator/pkg/library/schedule"
+)
+
+// checkForAutomaticStop checks whether a running workspace should be stopped by the controller, either because it is
+// idle, has exceeded its maximum run duration, or is scheduled to be stopped. If the workspace should be stopped, the
+// reason is returned as stopReason. Otherwise, the time remaining until the workspace should next be checked is
+// returned (zero if no check is necessary). Errors in configuration are logged and otherwise ignored.
+func checkForAutomaticStop(workspace *dw.DevWorkspace, logger logr.Logger) (stopReason string, timeRemaining time.Duration) {
+	updateTimeRemaining := func(remaining time.Duration) {
+		if remaining > 0 && (timeRemaining == 0 || remaining < timeRemaining) {
+			timeRemaining = remaining
+		}
+	}
+
+	isIdle, idleTimeRemaining, err := checkForIdleTimeout(workspace)
+	if err != nil {
+		logger.Error(err, "Failed to check if DevWorkspace is idle")
+	} else if isIdle {
+		return constants.DevWorkspaceStopReasonInactivity, 0
+	}
+	updateTimeRemaining(idleTimeRemaining)
+
+	exceeded, runTimeRemaining, err := checkForMaxRunDuration(workspace)
+	if err != nil {
+		logger.Error(err, "Failed to check if DevWorkspace exceeded its maximum run duration")
+	} else if exceeded {
+		return constants.DevWorkspaceStopReasonMaxRunDuration, 0
+	}
+	updateTimeRemaining(runTimeRemaining)
+
+	stopScheduled, timeUntilStop, err := checkForScheduledStop(workspace)
+	if err != nil {
+		logger.Error(err, "Failed to check DevWorkspace stop schedule")
+	} else if stopScheduled {
+		return constants.DevWorkspaceStopReasonSchedule, 0
+	}
+	updateTimeRemaining(timeUntilStop)
+
+	return "", timeRemaining
+}
+
+// checkForMaxRunDuration checks whether a running workspace has been running for longer than its maximum run duration,
+// as configured by annotation or in the operator configuration. If the workspace has not exceeded its maximum run
+// duration, the time remaining is returned. Returns an error if the configured duration cannot be parsed.
+func checkForMaxRunDuration(workspace *dw.DevWorkspace) (exceeded bool, timeRemaining time.Duration, err error) {
+	maxRunDurationStr := config.Workspace.MaxRunDuration
+	if annotation, ok := workspace.Annotations[constants.DevWorkspaceMaxRunDurationAnnotation]; ok {
+		maxRunDurationStr = annotation
+	}
+	if maxRunDurationStr == "" {
+		return false, 0, nil
+	}
+	maxRunDuration, err := time.ParseDuration(maxRunDurationStr)
+	if err != nil {
+		return false, 0, fmt.Errorf("invalid duration specified for maximum run duration: %w", err)
+	}
+	if maxRunDuration <= 0 {
+		return false, 0, nil
+	}
+	runDuration := clock.Since(getWorkspaceStartTime(workspace))
+	if runDuration >= maxRunDuration {
+		return true, 0, nil
+	}
+	return false, maxRunDuration - runDuration, nil
+}
+
+// checkForScheduledStop checks whether a running workspace's stop schedule has triggered since the workspace was
+// started. If not, the time remaining until the next scheduled stop is returned. Returns an error if the schedule
+// cannot be parsed.
+func checkForScheduledStop(workspace *dw.DevWorkspace) (isScheduled bool, timeRemaining time.Duration, err error) {
+	return checkSchedule(workspace.Annotations[constants.DevWorkspaceStopScheduleAnnotation], getWorkspaceStartTime(workspace))
+}
+
+// checkForScheduledStart checks whether a stopped workspace's start schedule has triggered since the workspace was
+// stopped. If not, the time remaining until the next scheduled start is returned. Returns an error if the schedule
+// cannot be parsed.
+func checkForScheduledStart(workspace *dw.DevWorkspace) (isScheduled bool, timeRemaining time.Duration, err error) {
+	stopTime := workspace.CreationTimestamp.Time
+	if startedCondition := conditions.GetConditionByType(workspace.Status.Conditions, conditions.Started); startedCondition != nil && startedCondition.Status == corev1.ConditionFalse {
+		stopTime = startedCondition.LastTransitionTime.Time
+	}
+	return checkSchedule(workspace.Annotations[constants.DevWorkspaceStartScheduleAnnotation], stopTime)
+}
+
+// checkForScheduledBackup checks whether a running workspace's backup schedule, as configured by annotation or in the
+// operator configuration, has triggered since the workspace was started or last backed up, whichever is later. If not,
+// the time remaining until the next scheduled backup is returned. Returns an error if the schedule or the time of the
+// last backup cannot be parsed.
+func checkForScheduledBackup(workspace *dw.DevWorkspace) (isScheduled bool, timeRemaining time.Duration, err error) {
+	backupSchedule := ""
+	if config.Workspace.Backup != nil {
+		backupSchedule = config.Workspace.Backup.Schedule
+	}
+	if annotation, ok := workspace.Annotations[constants.DevWorkspaceBackupScheduleAnnotation]; ok {
+		backupSchedule = annotation
+	}
+	since := getWorkspaceStartTime(workspace)
+	if lastBackupStr, ok := workspace.Annotations[constants.DevWorkspaceLastBackupAnnotation]; ok {
+		lastBackup, err := time.Parse(time.RFC3339, lastBackupStr)
+		if err != nil {
+			return false, 0, fmt.Errorf("invalid time specified for last backup: %w", err)
+		}
+		if lastBackup.After(since) {
+			since = lastBackup
+		}
+	}
+	return checkSchedule(backupSchedule, since)
+}
+
+// checkSchedule checks whether a cron schedule has triggered since the provided time. If not, the time remaining until
+// it next triggers is returned. An empty schedule never triggers.
+func checkSchedule(cronSchedule string, since time.Time) (triggered bool, timeRemaining time.Duration, err error) {
+	if cronSchedule == "" {
+		return false, 0, nil
+	}
+	sched, err := schedule.Parse(cronSchedule)
+	if err != nil {
+		return false, 0, err
+	}
+	next := sched.Next(since)
+	if next.IsZero() {
+		return false, 0, nil
+	}
+	currTime := clock.Now()
+	if !next.After(currTime) {
+		return true, 0, nil
+	}
+	return false, next.Sub(currTime), nil
+}
+
+// getWorkspaceStartTime returns the time a running workspace was started, based on its Started condition. If the
+// condition is not set, the current time is returned.
+func getWorkspaceStartTime(workspace *dw.DevWorkspace) time.Time {
+	startedCondition := conditions.GetConditionByType(workspace.Status.Conditions, conditions.Started)
+	if startedCondition == nil || startedCondition.Status != corev1.ConditionTrue {
+		return clock.Now()
+	}
+	return startedCondition.LastTransitionTime.Time
+}
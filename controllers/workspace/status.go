@@ -20,7 +20,6 @@ import (
 	"crypto/tls"
 	"fmt"
 	"net/http"
-	"net/url"
 	"sort"
 	"time"
 
@@ -37,6 +36,7 @@ import (
 	"github.com/devfile/devworkspace-operator/controllers/workspace/metrics"
 	"github.com/devfile/devworkspace-operator/pkg/conditions"
 	"github.com/devfile/devworkspace-operator/pkg/config"
+	"github.com/devfile/devworkspace-operator/pkg/constants"
 )
 
 const (
@@ -62,11 +62,16 @@ type currentStatus struct {
 // This variable makes it easier to test conditions.
 var clock kubeclock.Clock = &kubeclock.RealClock{}
 
-// healthHttpClient is supposed to be used for performing health checks of workspace endpoints
+// healthHttpClient is supposed to be used for performing health checks of workspace endpoints. Redirects are not
+// followed, as endpoints that require authentication may redirect to an external identity provider.
 var healthHttpClient = &http.Client{
 	Transport: &http.Transport{
 		TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
 	},
+	Timeout: endpointProbeTimeout,
+	CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	},
 }
 
 // updateWorkspaceStatus updates the current workspace's status field with conditions and phase from the passed in status.
@@ -159,30 +164,6 @@ func syncWorkspaceMainURL(workspace *dw.DevWorkspace, exposedEndpoints map[strin
 	return false, err
 }
 
-func checkServerStatus(workspace *dw.DevWorkspace) (ok bool, err error) {
-	mainUrl := workspace.Status.MainUrl
-	if mainUrl == "" {
-		// Support DevWorkspaces that do not specify an mainUrl
-		return true, nil
-	}
-	healthz, err := url.Parse(mainUrl)
-	if err != nil {
-		return false, err
-	}
-	healthz.Path = healthz.Path + "healthz"
-
-	resp, err := healthHttpClient.Get(healthz.String())
-	if err != nil {
-		return false, err
-	}
-	if (resp.StatusCode / 100) == 4 {
-		// Assume endpoint is unimplemented and/or * is covered with authentication.
-		return true, nil
-	}
-	ok = (resp.StatusCode / 100) == 2
-	return ok, nil
-}
-
 func getMainUrl(exposedEndpoints map[string]v1alpha1.ExposedEndpointList) string {
 	for _, endpoints := range exposedEndpoints {
 		for _, endpoint := range endpoints {
@@ -293,3 +274,37 @@ func checkForFailingTimeout(workspace *dw.DevWorkspace) (isTimedOut bool, err er
 	}
 	return false, nil
 }
+
+// checkForIdleTimeout checks whether a running workspace has had no activity for longer than the configured idle
+// timeout. The last activity in a workspace is the later of the time stored in the last-activity annotation and the
+// time the workspace was started. If the workspace is not idle, the time remaining until it times out is returned.
+// Idle detection is disabled unless stopping idle workspaces is enabled in the operator configuration and the idle
+// timeout is a positive duration. Returns an error if the idle timeout or last-activity annotation cannot be parsed.
+func checkForIdleTimeout(workspace *dw.DevWorkspace) (isIdle bool, timeRemaining time.Duration, err error) {
+	if config.Workspace.StopIdleWorkspaces == nil || !*config.Workspace.StopIdleWorkspaces {
+		return false, 0, nil
+	}
+	timeout, err := time.ParseDuration(config.Workspace.IdleTimeout)
+	if err != nil {
+		return false, 0, fmt.Errorf("invalid duration specified for idle timeout: %w", err)
+	}
+	if timeout <= 0 {
+		return false, 0, nil
+	}
+	currTime := clock.Now()
+	lastActivity := getWorkspaceStartTime(workspace)
+	if lastActivityAnnotation, ok := workspace.Annotations[constants.DevWorkspaceLastActivityAnnotation]; ok {
+		lastActivityTime, err := time.Parse(time.RFC3339, lastActivityAnnotation)
+		if err != nil {
+			return false, 0, fmt.Errorf("invalid time specified in annotation %s: %w", constants.DevWorkspaceLastActivityAnnotation, err)
+		}
+		if lastActivityTime.After(lastActivity) {
+			lastActivity = lastActivityTime
+		}
+	}
+	idleTime := currTime.Sub(lastActivity)
+	if idleTime >= timeout {
+		return true, 0, nil
+	}
+	return false, timeout - idleTime, nil
+}
@@ -0,0 +1,192 @@
+//
+// Copyright (c) 2019-2021 Red Hat, Inc.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package controllers
+
+import (
+	"testing"
+	"time"
+
+	dw "github.com/devfile/api/v2/pkg/apis/workspaces/v1alpha2"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubeclock "k8s.io/apimachinery/pkg/util/clock"
+
+	"github.com/devfile/devworkspace-operator/apis/controller/v1alpha1"
+	"github.com/devfile/devworkspace-operator/pkg/conditions"
+	"github.com/devfile/devworkspace-operator/pkg/config"
+	"github.com/devfile/devworkspace-operator/pkg/constants"
+)
+
+var testTime = time.Date(2021, time.June, 1, 12, 0, 0, 0, time.UTC)
+
+// setTestClock sets the clock used by the controller to a fake clock at testTime for the duration of a test
+func setTestClock(t *testing.T) *kubeclock.FakeClock {
+	fakeClock := kubeclock.NewFakeClock(testTime)
+	clock = fakeClock
+	t.Cleanup(func() {
+		clock = &kubeclock.RealClock{}
+	})
+	return fakeClock
+}
+
+func getIdleTestWorkspace(startedAt time.Time) *dw.DevWorkspace {
+	return &dw.DevWorkspace{
+		Status: dw.DevWorkspaceStatus{
+			Phase: dw.DevWorkspaceStatusRunning,
+			Conditions: []dw.DevWorkspaceCondition{
+				{
+					Type:               conditions.Started,
+					Status:             corev1.ConditionTrue,
+					LastTransitionTime: metav1.Time{Time: startedAt},
+				},
+				{
+					// Ready condition can transition while workspace is running; it should not affect idling
+					Type:               dw.DevWorkspaceReady,
+					Status:             corev1.ConditionTrue,
+					LastTransitionTime: metav1.Time{Time: testTime.Add(-1 * time.Minute)},
+				},
+			},
+		},
+	}
+}
+
+func setIdleConfigForTesting(stopIdleWorkspaces bool, idleTimeout string) {
+	config.SetConfigForTesting(&v1alpha1.OperatorConfiguration{
+		Workspace: &v1alpha1.WorkspaceConfig{
+			IdleTimeout:        idleTimeout,
+			StopIdleWorkspaces: &stopIdleWorkspaces,
+		},
+	})
+}
+
+func TestIdlingIsDisabledByDefault(t *testing.T) {
+	setTestClock(t)
+	config.SetConfigForTesting(nil)
+	isIdle, timeRemaining, err := checkForIdleTimeout(getIdleTestWorkspace(testTime.Add(-24 * time.Hour)))
+	assert.NoError(t, err)
+	assert.False(t, isIdle, "Workspaces should not be idled unless enabled in configuration")
+	assert.Zero(t, timeRemaining)
+}
+
+func TestCheckForIdleTimeout(t *testing.T) {
+	tests := []struct {
+		name                  string
+		idleTimeout           string
+		startedAgo            time.Duration
+		lastActivity          string
+		expectedIdle          bool
+		expectedTimeRemaining time.Duration
+		expectedErr           string
+	}{
+		{
+			name:                  "Workspace without activity is not idle before timeout",
+			idleTimeout:           "15m",
+			startedAgo:            10 * time.Minute,
+			expectedTimeRemaining: 5 * time.Minute,
+		},
+		{
+			name:         "Workspace without activity is idle after timeout since start, regardless of Ready condition",
+			idleTimeout:  "15m",
+			startedAgo:   20 * time.Minute,
+			expectedIdle: true,
+		},
+		{
+			name:                  "Recent activity keeps workspace running",
+			idleTimeout:           "15m",
+			startedAgo:            time.Hour,
+			lastActivity:          testTime.Add(-5 * time.Minute).Format(time.RFC3339),
+			expectedTimeRemaining: 10 * time.Minute,
+		},
+		{
+			name:         "Workspace is idle if last activity is older than timeout",
+			idleTimeout:  "15m",
+			startedAgo:   time.Hour,
+			lastActivity: testTime.Add(-30 * time.Minute).Format(time.RFC3339),
+			expectedIdle: true,
+		},
+		{
+			name:                  "Activity from before workspace was started is ignored",
+			idleTimeout:           "15m",
+			startedAgo:            5 * time.Minute,
+			lastActivity:          testTime.Add(-2 * time.Hour).Format(time.RFC3339),
+			expectedTimeRemaining: 10 * time.Minute,
+		},
+		{
+			name:        "Zero timeout disables idling",
+			idleTimeout: "0s",
+			startedAgo:  time.Hour,
+		},
+		{
+			name:         "Invalid last activity annotation",
+			idleTimeout:  "15m",
+			startedAgo:   time.Hour,
+			lastActivity: "yesterday",
+			expectedErr:  "invalid time specified in annotation",
+		},
+		{
+			name:        "Invalid timeout",
+			idleTimeout: "fifteen minutes",
+			startedAgo:  time.Hour,
+			expectedErr: "invalid duration specified for idle timeout",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setTestClock(t)
+			setIdleConfigForTesting(true, tt.idleTimeout)
+			workspace := getIdleTestWorkspace(testTime.Add(-tt.startedAgo))
+			if tt.lastActivity != "" {
+				workspace.Annotations = map[string]string{constants.DevWorkspaceLastActivityAnnotation: tt.lastActivity}
+			}
+			isIdle, timeRemaining, err := checkForIdleTimeout(workspace)
+			if tt.expectedErr != "" {
+				if assert.Error(t, err) {
+					assert.Contains(t, err.Error(), tt.expectedErr)
+				}
+				return
+			}
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.Equal(t, tt.expectedIdle, isIdle)
+			assert.Equal(t, tt.expectedTimeRemaining, timeRemaining)
+		})
+	}
+}
+
+func TestRestartingWorkspaceInfoMessage(t *testing.T) {
+	status := &currentStatus{
+		workspaceConditions: workspaceConditions{conditions: map[dw.DevWorkspaceConditionType]dw.DevWorkspaceCondition{}},
+		phase:               dw.DevWorkspaceStatusStarting,
+	}
+	status.setConditionTrue(conditions.Started, "DevWorkspace is starting")
+	status.setConditionTrue(conditions.DeploymentReady, "DevWorkspace deployment ready")
+	status.setConditionFalse(conditions.Restarted, "Restarting DevWorkspace")
+	status.setConditionFalse(dw.DevWorkspaceReady, "Waiting for editor to start")
+	workspace := &dw.DevWorkspace{Status: dw.DevWorkspaceStatus{Phase: status.phase}}
+
+	assert.Equal(t, "Restarting DevWorkspace", getInfoMessage(workspace, status),
+		"Restart should be reported before other unready conditions")
+
+	syncConditions(&workspace.Status, status)
+	var conditionTypes []dw.DevWorkspaceConditionType
+	for _, condition := range workspace.Status.Conditions {
+		conditionTypes = append(conditionTypes, condition.Type)
+	}
+	assert.Equal(t, []dw.DevWorkspaceConditionType{conditions.Started, conditions.DeploymentReady, conditions.Restarted, dw.DevWorkspaceReady},
+		conditionTypes, "Restarted condition should be ordered before Ready condition")
+}
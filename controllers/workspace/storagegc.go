@@ -0,0 +1,90 @@
+//
+// Copyright (c) 2019-2021 Red Hat, Inc.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/devfile/devworkspace-operator/controllers/workspace/metrics"
+	"github.com/devfile/devworkspace-operator/pkg/config"
+	"github.com/devfile/devworkspace-operator/pkg/provision/storage"
+	"github.com/devfile/devworkspace-operator/pkg/provision/sync"
+)
+
+// StorageGarbageCollector periodically removes data left on the common PVC by DevWorkspaces that no longer exist, e.g.
+// because the DevWorkspace's storage finalizer was removed manually or storage cleanup failed. Garbage collection runs
+// a job in each namespace that contains a common PVC, according to the interval set in the operator configuration.
+type StorageGarbageCollector struct {
+	Client client.Client
+	// APIReader is used to read objects directly from the cluster, as the controller's cache does not contain all
+	// objects relevant to garbage collection (e.g. jobs and pods without DevWorkspace labels).
+	APIReader client.Reader
+	Log       logr.Logger
+	Scheme    *runtime.Scheme
+}
+
+// SetupWithManager adds the garbage collector to the manager, to be started once the manager is elected leader.
+func (gc *StorageGarbageCollector) SetupWithManager(mgr ctrl.Manager) error {
+	return mgr.Add(gc)
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable
+func (gc *StorageGarbageCollector) NeedLeaderElection() bool {
+	return true
+}
+
+// Start runs garbage collection until the context is cancelled. It implements manager.Runnable
+func (gc *StorageGarbageCollector) Start(ctx context.Context) error {
+	runPeriodically(ctx, getStorageGarbageCollectionInterval, gc.collect, gc.Log)
+	return nil
+}
+
+// collect runs garbage collection in every namespace that contains a common PVC and waits for it to complete.
+func (gc *StorageGarbageCollector) collect(ctx context.Context) {
+	forEachCommonPVCNamespace(ctx, gc.Client, gc.APIReader, gc.Scheme, gc.Log, func(namespace string, clusterAPI sync.ClusterAPI) error {
+		result, err := storage.CollectOrphanedStorage(namespace, clusterAPI)
+		if err != nil {
+			return err
+		}
+		if result != nil && len(result.OrphanedIDs) > 0 {
+			msg := "Removed orphaned DevWorkspace data from common PVC"
+			if result.DryRun {
+				msg = "Found orphaned DevWorkspace data on common PVC (dry run)"
+			}
+			clusterAPI.Logger.Info(msg, "devworkspaceIds", result.OrphanedIDs, "bytes", result.OrphanedBytes)
+			metrics.StorageGarbageCollected(result.OrphanedBytes, len(result.OrphanedIDs), result.DryRun)
+		}
+		return nil
+	})
+}
+
+func getStorageGarbageCollectionInterval() (time.Duration, error) {
+	if config.Workspace.StorageGarbageCollection == nil || config.Workspace.StorageGarbageCollection.Interval == "" {
+		return 0, nil
+	}
+	interval, err := time.ParseDuration(config.Workspace.StorageGarbageCollection.Interval)
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration specified for storage garbage collection interval: %w", err)
+	}
+	return interval, nil
+}
@@ -0,0 +1,116 @@
+//
+// Copyright (c) 2019-2021 Red Hat, Inc.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package controllers
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/devfile/devworkspace-operator/pkg/config"
+	"github.com/devfile/devworkspace-operator/pkg/provision/storage"
+	"github.com/devfile/devworkspace-operator/pkg/provision/sync"
+)
+
+const (
+	// commonPVCJobPollInterval is how often namespace-wide jobs on common PVCs are checked for completion
+	commonPVCJobPollInterval = 10 * time.Second
+	// periodicTaskDisabledInterval is how often the configuration is rechecked while a periodic task is disabled
+	periodicTaskDisabledInterval = 1 * time.Hour
+)
+
+// runPeriodically calls run according to the interval returned by getInterval until the context is cancelled. The
+// interval is re-read from the configuration after each run; an interval of zero disables the task.
+func runPeriodically(ctx context.Context, getInterval func() (time.Duration, error), run func(context.Context), log logr.Logger) {
+	for {
+		interval, err := getInterval()
+		if err != nil {
+			log.Error(err, "Invalid interval configured; task is disabled")
+		}
+		wait := interval
+		if interval <= 0 {
+			wait = periodicTaskDisabledInterval
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+		if interval > 0 {
+			run(ctx)
+		}
+	}
+}
+
+// forEachCommonPVCNamespace calls task for every namespace that contains a common PVC, retrying periodically while task
+// returns storage.NotReadyError, until it has completed in all namespaces. Reads performed through the ClusterAPI passed
+// to task use apiReader, as the controller's cache does not contain all objects relevant to namespace-wide tasks.
+func forEachCommonPVCNamespace(ctx context.Context, c client.Client, apiReader client.Reader, scheme *runtime.Scheme, log logr.Logger,
+	task func(namespace string, clusterAPI sync.ClusterAPI) error) {
+	uncachedClient, err := client.NewDelegatingClient(client.NewDelegatingClientInput{
+		CacheReader: apiReader,
+		Client:      c,
+	})
+	if err != nil {
+		log.Error(err, "Failed to set up client")
+		return
+	}
+
+	pvcs := &corev1.PersistentVolumeClaimList{}
+	err = apiReader.List(ctx, pvcs, client.MatchingFieldsSelector{
+		Selector: fields.OneTermEqualSelector("metadata.name", config.Workspace.PVCName),
+	})
+	if err != nil {
+		log.Error(err, "Failed to list common PVCs")
+		return
+	}
+	pending := map[string]bool{}
+	for _, pvc := range pvcs.Items {
+		pending[pvc.Namespace] = true
+	}
+
+	for len(pending) > 0 {
+		for namespace := range pending {
+			namespaceLog := log.WithValues("namespace", namespace)
+			clusterAPI := sync.ClusterAPI{
+				Ctx:    ctx,
+				Client: uncachedClient,
+				Scheme: scheme,
+				Logger: namespaceLog,
+			}
+			if err := task(namespace, clusterAPI); err != nil {
+				if _, ok := err.(*storage.NotReadyError); ok {
+					continue
+				}
+				namespaceLog.Error(err, "Failed to run task for common PVC")
+			}
+			delete(pending, namespace)
+		}
+		if len(pending) == 0 {
+			return
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(commonPVCJobPollInterval):
+		}
+	}
+}
@@ -0,0 +1,138 @@
+//
+// Copyright (c) 2019-2021 Red Hat, Inc.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	dw "github.com/devfile/api/v2/pkg/apis/workspaces/v1alpha2"
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/devfile/devworkspace-operator/controllers/workspace/metrics"
+	"github.com/devfile/devworkspace-operator/pkg/conditions"
+	"github.com/devfile/devworkspace-operator/pkg/config"
+	"github.com/devfile/devworkspace-operator/pkg/constants"
+	"github.com/devfile/devworkspace-operator/pkg/provision/storage"
+	"github.com/devfile/devworkspace-operator/pkg/provision/sync"
+)
+
+// storageUsageWarningThreshold is the fraction of the configured storage usage soft limit above which a DevWorkspace
+// gets the StorageUsageWarning condition.
+const storageUsageWarningThreshold = 0.9
+
+// StorageUsageMonitor periodically measures the disk usage of each DevWorkspace's data on the common PVC. Usage is
+// recorded in the DevWorkspaceStorageUsageAnnotation annotation on each DevWorkspace and exported as a metric.
+type StorageUsageMonitor struct {
+	Client client.Client
+	// APIReader is used to read objects directly from the cluster, as the controller's cache does not contain all
+	// objects relevant to measuring storage usage (e.g. jobs and pods without DevWorkspace labels).
+	APIReader client.Reader
+	Log       logr.Logger
+	Scheme    *runtime.Scheme
+}
+
+// SetupWithManager adds the storage usage monitor to the manager, to be started once the manager is elected leader.
+func (m *StorageUsageMonitor) SetupWithManager(mgr ctrl.Manager) error {
+	return mgr.Add(m)
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable
+func (m *StorageUsageMonitor) NeedLeaderElection() bool {
+	return true
+}
+
+// Start measures storage usage until the context is cancelled. It implements manager.Runnable
+func (m *StorageUsageMonitor) Start(ctx context.Context) error {
+	runPeriodically(ctx, getStorageUsageInterval, m.measure, m.Log)
+	return nil
+}
+
+// measure runs a job measuring storage usage in every namespace that contains a common PVC and records the results
+// on the corresponding DevWorkspaces.
+func (m *StorageUsageMonitor) measure(ctx context.Context) {
+	forEachCommonPVCNamespace(ctx, m.Client, m.APIReader, m.Scheme, m.Log, func(namespace string, clusterAPI sync.ClusterAPI) error {
+		usage, err := storage.MeasureStorageUsage(namespace, clusterAPI)
+		if err != nil || usage == nil {
+			return err
+		}
+		workspaces := &dw.DevWorkspaceList{}
+		if err := clusterAPI.Client.List(ctx, workspaces, client.InNamespace(namespace)); err != nil {
+			return err
+		}
+		for idx := range workspaces.Items {
+			workspace := &workspaces.Items[idx]
+			bytes, ok := usage[workspace.Status.DevWorkspaceId]
+			if !ok || workspace.DeletionTimestamp != nil {
+				continue
+			}
+			metrics.SetStorageUsage(namespace, workspace.Status.DevWorkspaceId, bytes)
+			if err := m.updateStorageUsageAnnotation(ctx, workspace, bytes); err != nil {
+				clusterAPI.Logger.Error(err, "Failed to record storage usage", "devworkspace", workspace.Name)
+			}
+		}
+		return nil
+	})
+}
+
+func (m *StorageUsageMonitor) updateStorageUsageAnnotation(ctx context.Context, workspace *dw.DevWorkspace, bytes int64) error {
+	value := strconv.FormatInt(bytes, 10)
+	if workspace.Annotations[constants.DevWorkspaceStorageUsageAnnotation] == value {
+		return nil
+	}
+	patch := client.MergeFrom(workspace.DeepCopy())
+	if workspace.Annotations == nil {
+		workspace.Annotations = map[string]string{}
+	}
+	workspace.Annotations[constants.DevWorkspaceStorageUsageAnnotation] = value
+	return m.Client.Patch(ctx, workspace, patch)
+}
+
+func getStorageUsageInterval() (time.Duration, error) {
+	if config.Workspace.StorageUsage == nil || config.Workspace.StorageUsage.Interval == "" {
+		return 0, nil
+	}
+	interval, err := time.ParseDuration(config.Workspace.StorageUsage.Interval)
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration specified for storage usage interval: %w", err)
+	}
+	return interval, nil
+}
+
+// checkStorageUsage sets the StorageUsageWarning condition on a DevWorkspace based on the storage usage recorded in its
+// DevWorkspaceStorageUsageAnnotation annotation, if a storage usage soft limit is configured.
+func checkStorageUsage(workspace *dw.DevWorkspace, status *currentStatus) {
+	usageStr, ok := workspace.Annotations[constants.DevWorkspaceStorageUsageAnnotation]
+	if !ok || config.Workspace.StorageUsage == nil || config.Workspace.StorageUsage.SoftLimit == nil {
+		return
+	}
+	usage, err := strconv.ParseInt(usageStr, 10, 64)
+	if err != nil {
+		return
+	}
+	softLimit := config.Workspace.StorageUsage.SoftLimit
+	if float64(usage) >= storageUsageWarningThreshold*float64(softLimit.Value()) {
+		status.setConditionTrue(conditions.StorageUsageWarning,
+			fmt.Sprintf("DevWorkspace is using %d bytes of storage, approaching the limit of %s", usage, softLimit.String()))
+	} else {
+		status.setConditionFalse(conditions.StorageUsageWarning, "")
+	}
+}
@@ -43,6 +43,8 @@ const (
 	asyncStorageServerImageEnvVar  = "RELATED_IMAGE_async_storage_server"
 	asyncStorageSidecarImageEnvVar = "RELATED_IMAGE_async_storage_sidecar"
 	projectCloneImageEnvVar        = "RELATED_IMAGE_project_clone"
+	imageBuilderImageEnvVar        = "RELATED_IMAGE_image_builder"
+	oidcProxyImageEnvVar           = "RELATED_IMAGE_oidc_proxy"
 )
 
 // GetWebhookServerImage returns the image reference for the webhook server image. Returns
@@ -116,6 +118,28 @@ func GetProjectClonerImage() string {
 	return val
 }
 
+// GetImageBuilderImage returns the image reference for the image used to build image components in
+// DevWorkspaces. Returns the empty string if environment variable RELATED_IMAGE_image_builder is not defined
+func GetImageBuilderImage() string {
+	val, ok := os.LookupEnv(imageBuilderImageEnvVar)
+	if !ok {
+		log.Error(fmt.Errorf("environment variable %s is not set", imageBuilderImageEnvVar), "Could not get image builder image")
+		return ""
+	}
+	return val
+}
+
+// GetOIDCProxyImage returns the image reference for the authenticating proxy used by the oidc routing class.
+// Returns the empty string if environment variable RELATED_IMAGE_oidc_proxy is not defined
+func GetOIDCProxyImage() string {
+	val, ok := os.LookupEnv(oidcProxyImageEnvVar)
+	if !ok {
+		log.Error(fmt.Errorf("environment variable %s is not set", oidcProxyImageEnvVar), "Could not get OIDC proxy image")
+		return ""
+	}
+	return val
+}
+
 // FillPluginEnvVars replaces plugin devworkspaceTemplate .spec.components[].container.image environment
 // variables of the form ${RELATED_IMAGE_*} with values from environment variables with the same name.
 //
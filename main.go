@@ -150,6 +150,24 @@ func main() {
 		setupLog.Error(err, "unable to create controller", "controller", "DevWorkspace")
 		os.Exit(1)
 	}
+	if err = (&workspacecontroller.StorageGarbageCollector{
+		Client:    mgr.GetClient(),
+		APIReader: mgr.GetAPIReader(),
+		Log:       ctrl.Log.WithName("controllers").WithName("StorageGarbageCollector"),
+		Scheme:    mgr.GetScheme(),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "StorageGarbageCollector")
+		os.Exit(1)
+	}
+	if err = (&workspacecontroller.StorageUsageMonitor{
+		Client:    mgr.GetClient(),
+		APIReader: mgr.GetAPIReader(),
+		Log:       ctrl.Log.WithName("controllers").WithName("StorageUsageMonitor"),
+		Scheme:    mgr.GetScheme(),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "StorageUsageMonitor")
+		os.Exit(1)
+	}
 	// +kubebuilder:scaffold:builder
 
 	// Get a config to talk to the apiserver
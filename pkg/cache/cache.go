@@ -68,6 +68,9 @@ func GetCacheFunc() (cache.NewCacheFunc, error) {
 		&networkingv1.Ingress{}: {
 			Label: devworkspaceObjectSelector,
 		},
+		&networkingv1.NetworkPolicy{}: {
+			Label: devworkspaceObjectSelector,
+		},
 		&corev1.ConfigMap{}: {
 			Label: configmapObjectSelector,
 		},
@@ -40,6 +40,11 @@ func ServiceName(workspaceId string) string {
 	return fmt.Sprintf("%s-%s", workspaceId, "service")
 }
 
+// ExternalServiceName returns the name of the LoadBalancer or NodePort service used to expose non-HTTP endpoints
+func ExternalServiceName(workspaceId string) string {
+	return fmt.Sprintf("%s-%s", workspaceId, "external")
+}
+
 func ServiceAccountName(workspaceId string) string {
 	return fmt.Sprintf("%s-%s", workspaceId, "sa")
 }
@@ -66,14 +71,46 @@ func EndpointPath(endpointName string) string {
 	return "/" + endpointName + "/"
 }
 
+// SingleHostEndpointPath returns the path an endpoint is exposed on when all endpoints are exposed on a single host
+func SingleHostEndpointPath(namespace, workspaceName, endpointName string) string {
+	return fmt.Sprintf("/%s/%s/%s/", namespace, workspaceName, endpointName)
+}
+
 func RouteName(workspaceId, endpointName string) string {
 	return fmt.Sprintf("%s-%s", workspaceId, endpointName)
 }
 
+// IngressTLSSecretName returns the name of the secret cert-manager stores the certificate for an endpoint's Ingress in
+func IngressTLSSecretName(workspaceId, endpointName string) string {
+	return fmt.Sprintf("%s-%s-tls", workspaceId, endpointName)
+}
+
+// HTTPRouteName returns the name of the Gateway API HTTPRoute that exposes all endpoints of a workspace
+func OIDCProxySecretName(workspaceId string) string {
+	return fmt.Sprintf("%s-oidc-proxy", workspaceId)
+}
+
+func HTTPRouteName(workspaceId string) string {
+	return workspaceId
+}
+
+func NetworkPolicyName(workspaceId string) string {
+	return workspaceId
+}
+
 func DeploymentName(workspaceId string) string {
 	return workspaceId
 }
 
+// DedicatedPodName returns the name used for the deployment and service for a dedicatedPod container component.
+func DedicatedPodName(workspaceId, componentName string) string {
+	name := fmt.Sprintf("%s-%s", workspaceId, componentName)
+	if len(name) > 63 {
+		name = strings.TrimSuffix(name[:63], "-")
+	}
+	return name
+}
+
 func ServingCertVolumeName(serviceName string) string {
 	return fmt.Sprintf("devworkspace-serving-cert-%s", serviceName)
 }
@@ -82,6 +119,50 @@ func PVCCleanupJobName(workspaceId string) string {
 	return fmt.Sprintf("cleanup-%s", workspaceId)
 }
 
+// StorageMigrationJobName returns the name of the job used to migrate a workspace's data when its storage type
+// is changed.
+func StorageMigrationJobName(workspaceId string) string {
+	return fmt.Sprintf("migrate-storage-%s", workspaceId)
+}
+
+// StorageGarbageCollectionJobName returns the name of the job used to remove orphaned workspace data from the common
+// PVC in a namespace.
+func StorageGarbageCollectionJobName() string {
+	return "devworkspace-storage-gc"
+}
+
+// StorageUsageJobName returns the name of the job used to measure the disk usage of each workspace's data on the
+// common PVC in a namespace.
+func StorageUsageJobName() string {
+	return "devworkspace-storage-usage"
+}
+
+// WorkspaceBackupName returns the name of a VolumeSnapshot used to back up a workspace's storage.
+func WorkspaceBackupName(workspaceId, suffix string) string {
+	return fmt.Sprintf("%s-backup-%s", workspaceId, suffix)
+}
+
+// StorageRestoreName returns the name of the temporary PVC and job used to restore a workspace's storage from a
+// VolumeSnapshot.
+func StorageRestoreName(workspaceId string) string {
+	return fmt.Sprintf("restore-%s", workspaceId)
+}
+
+// PerWorkspacePVCName returns the name of the PVC used for a workspace's storage when the per-workspace storage
+// strategy is used.
+func PerWorkspacePVCName(workspaceId string) string {
+	return fmt.Sprintf("storage-%s", workspaceId)
+}
+
+// ImageBuildJobName returns the name of the job used to build the image for an image component.
+func ImageBuildJobName(workspaceId, componentName string) string {
+	name := fmt.Sprintf("%s-build-%s", workspaceId, componentName)
+	if len(name) > 63 {
+		name = strings.TrimSuffix(name[:63], "-")
+	}
+	return name
+}
+
 func MetadataConfigMapName(workspaceId string) string {
 	return fmt.Sprintf("%s-metadata", workspaceId)
 }
@@ -21,9 +21,15 @@ const (
 	Started              dw.DevWorkspaceConditionType = "Started"
 	PullSecretsReady     dw.DevWorkspaceConditionType = "PullSecretsReady"
 	DevWorkspaceResolved dw.DevWorkspaceConditionType = "DevWorkspaceResolved"
+	StorageMigrated      dw.DevWorkspaceConditionType = "StorageMigrated"
 	StorageReady         dw.DevWorkspaceConditionType = "StorageReady"
+	KubeComponentsReady  dw.DevWorkspaceConditionType = "KubeComponentsReady"
+	ImagesBuilt          dw.DevWorkspaceConditionType = "ImagesBuilt"
 	DeploymentReady      dw.DevWorkspaceConditionType = "DeploymentReady"
+	EndpointsReady       dw.DevWorkspaceConditionType = "EndpointsReady"
+	Restarted            dw.DevWorkspaceConditionType = "Restarted"
 	DevWorkspaceWarning  dw.DevWorkspaceConditionType = "DevWorkspaceWarning"
+	StorageUsageWarning  dw.DevWorkspaceConditionType = "StorageUsageWarning"
 )
 
 func GetConditionByType(conditions []dw.DevWorkspaceCondition, t dw.DevWorkspaceConditionType) *dw.DevWorkspaceCondition {
@@ -17,6 +17,8 @@ package config
 
 import "github.com/devfile/devworkspace-operator/apis/controller/v1alpha1"
 
+var storageGarbageCollectionDryRun = false
+
 // DefaultConfig represents the default configuration for the DevWorkspace Operator.
 var DefaultConfig = &v1alpha1.OperatorConfiguration{
 	Routing: &v1alpha1.RoutingConfig{
@@ -28,5 +30,12 @@ var DefaultConfig = &v1alpha1.OperatorConfiguration{
 		PVCName:         "claim-devworkspace",
 		IdleTimeout:     "15m",
 		ProgressTimeout: "5m",
+		StorageGarbageCollection: &v1alpha1.StorageGarbageCollectionConfig{
+			Interval: "24h",
+			DryRun:   &storageGarbageCollectionDryRun,
+		},
+		StorageUsage: &v1alpha1.StorageUsageConfig{
+			Interval: "1h",
+		},
 	},
 }
@@ -86,6 +86,25 @@ func SetupControllerConfig(client crclient.Client) error {
 	return nil
 }
 
+// GetOperatorConfigFromCluster reads the DevWorkspaceOperatorConfig from the cluster and returns it merged with the
+// default configuration. This is intended for components that do not run the controller (e.g. the webhook server), and
+// so cannot rely on the configuration being kept in sync.
+func GetOperatorConfigFromCluster(client crclient.Client) (*controller.OperatorConfiguration, error) {
+	namespace, err := infrastructure.GetNamespace()
+	if err != nil {
+		return nil, err
+	}
+	clusterConfig, err := getClusterConfig(namespace, client)
+	if err != nil {
+		return nil, err
+	}
+	config := DefaultConfig.DeepCopy()
+	if clusterConfig != nil {
+		mergeConfig(clusterConfig.Config, config)
+	}
+	return config, nil
+}
+
 func ExperimentalFeaturesEnabled() bool {
 	if internalConfig.EnableExperimentalFeatures == nil {
 		return false
@@ -190,6 +209,45 @@ func mergeConfig(from, to *controller.OperatorConfiguration) {
 		if from.Routing.ClusterHostSuffix != "" {
 			to.Routing.ClusterHostSuffix = from.Routing.ClusterHostSuffix
 		}
+		if from.Routing.SingleHostname != "" {
+			to.Routing.SingleHostname = from.Routing.SingleHostname
+		}
+		if from.Routing.IngressClassName != "" {
+			to.Routing.IngressClassName = from.Routing.IngressClassName
+		}
+		if from.Routing.AllowedEndpointIngressClasses != nil {
+			to.Routing.AllowedEndpointIngressClasses = append([]string{}, from.Routing.AllowedEndpointIngressClasses...)
+		}
+		if from.Routing.AllowedEndpointAnnotations != nil {
+			to.Routing.AllowedEndpointAnnotations = append([]string{}, from.Routing.AllowedEndpointAnnotations...)
+		}
+		if from.Routing.Annotations != nil {
+			to.Routing.Annotations = map[string]string{}
+			for k, v := range from.Routing.Annotations {
+				to.Routing.Annotations[k] = v
+			}
+		}
+		if from.Routing.Labels != nil {
+			to.Routing.Labels = map[string]string{}
+			for k, v := range from.Routing.Labels {
+				to.Routing.Labels[k] = v
+			}
+		}
+		if from.Routing.NetworkPolicy != nil {
+			to.Routing.NetworkPolicy = from.Routing.NetworkPolicy.DeepCopy()
+		}
+		if from.Routing.NonHTTPEndpoints != nil {
+			to.Routing.NonHTTPEndpoints = from.Routing.NonHTTPEndpoints.DeepCopy()
+		}
+		if from.Routing.Gateway != nil {
+			to.Routing.Gateway = from.Routing.Gateway.DeepCopy()
+		}
+		if from.Routing.TLS != nil {
+			to.Routing.TLS = from.Routing.TLS.DeepCopy()
+		}
+		if from.Routing.OIDC != nil {
+			to.Routing.OIDC = from.Routing.OIDC.DeepCopy()
+		}
 	}
 	if from.Workspace != nil {
 		if to.Workspace == nil {
@@ -201,18 +259,99 @@ func mergeConfig(from, to *controller.OperatorConfiguration) {
 		if from.Workspace.PVCName != "" {
 			to.Workspace.PVCName = from.Workspace.PVCName
 		}
+		if from.Workspace.CommonPVCSize != nil {
+			commonPVCSize := from.Workspace.CommonPVCSize.DeepCopy()
+			to.Workspace.CommonPVCSize = &commonPVCSize
+		}
+		if from.Workspace.ComputeCommonPVCSize != nil {
+			to.Workspace.ComputeCommonPVCSize = from.Workspace.ComputeCommonPVCSize
+		}
 		if from.Workspace.ImagePullPolicy != "" {
 			to.Workspace.ImagePullPolicy = from.Workspace.ImagePullPolicy
 		}
 		if from.Workspace.IdleTimeout != "" {
 			to.Workspace.IdleTimeout = from.Workspace.IdleTimeout
 		}
+		if from.Workspace.StopIdleWorkspaces != nil {
+			to.Workspace.StopIdleWorkspaces = from.Workspace.StopIdleWorkspaces
+		}
 		if from.Workspace.ProgressTimeout != "" {
 			to.Workspace.ProgressTimeout = from.Workspace.ProgressTimeout
 		}
+		if from.Workspace.MaxRunDuration != "" {
+			to.Workspace.MaxRunDuration = from.Workspace.MaxRunDuration
+		}
 		if from.Workspace.IgnoredUnrecoverableEvents != nil {
 			to.Workspace.IgnoredUnrecoverableEvents = from.Workspace.IgnoredUnrecoverableEvents
 		}
+		if from.Workspace.ImageBuild != nil {
+			if to.Workspace.ImageBuild == nil {
+				to.Workspace.ImageBuild = &controller.ImageBuildConfig{}
+			}
+			if from.Workspace.ImageBuild.Registry != "" {
+				to.Workspace.ImageBuild.Registry = from.Workspace.ImageBuild.Registry
+			}
+			if from.Workspace.ImageBuild.PushSecret != "" {
+				to.Workspace.ImageBuild.PushSecret = from.Workspace.ImageBuild.PushSecret
+			}
+			if from.Workspace.ImageBuild.AllowRootBuilds != nil {
+				to.Workspace.ImageBuild.AllowRootBuilds = from.Workspace.ImageBuild.AllowRootBuilds
+			}
+		}
+		if from.Workspace.Quotas != nil {
+			if to.Workspace.Quotas == nil {
+				to.Workspace.Quotas = &controller.WorkspaceQuotaConfig{}
+			}
+			if from.Workspace.Quotas.MaxRunningPerUser != nil {
+				to.Workspace.Quotas.MaxRunningPerUser = from.Workspace.Quotas.MaxRunningPerUser
+			}
+			if from.Workspace.Quotas.MaxRunningPerNamespace != nil {
+				to.Workspace.Quotas.MaxRunningPerNamespace = from.Workspace.Quotas.MaxRunningPerNamespace
+			}
+			if from.Workspace.Quotas.MaxWorkspacesPerUser != nil {
+				to.Workspace.Quotas.MaxWorkspacesPerUser = from.Workspace.Quotas.MaxWorkspacesPerUser
+			}
+		}
+		if from.Workspace.Backup != nil {
+			if to.Workspace.Backup == nil {
+				to.Workspace.Backup = &controller.BackupConfig{}
+			}
+			if from.Workspace.Backup.VolumeSnapshotClassName != nil {
+				to.Workspace.Backup.VolumeSnapshotClassName = from.Workspace.Backup.VolumeSnapshotClassName
+			}
+			if from.Workspace.Backup.Schedule != "" {
+				to.Workspace.Backup.Schedule = from.Workspace.Backup.Schedule
+			}
+			if from.Workspace.Backup.BackupOnDelete != nil {
+				to.Workspace.Backup.BackupOnDelete = from.Workspace.Backup.BackupOnDelete
+			}
+			if from.Workspace.Backup.MaxBackups != nil {
+				to.Workspace.Backup.MaxBackups = from.Workspace.Backup.MaxBackups
+			}
+		}
+		if from.Workspace.StorageGarbageCollection != nil {
+			if to.Workspace.StorageGarbageCollection == nil {
+				to.Workspace.StorageGarbageCollection = &controller.StorageGarbageCollectionConfig{}
+			}
+			if from.Workspace.StorageGarbageCollection.Interval != "" {
+				to.Workspace.StorageGarbageCollection.Interval = from.Workspace.StorageGarbageCollection.Interval
+			}
+			if from.Workspace.StorageGarbageCollection.DryRun != nil {
+				to.Workspace.StorageGarbageCollection.DryRun = from.Workspace.StorageGarbageCollection.DryRun
+			}
+		}
+		if from.Workspace.StorageUsage != nil {
+			if to.Workspace.StorageUsage == nil {
+				to.Workspace.StorageUsage = &controller.StorageUsageConfig{}
+			}
+			if from.Workspace.StorageUsage.Interval != "" {
+				to.Workspace.StorageUsage.Interval = from.Workspace.StorageUsage.Interval
+			}
+			if from.Workspace.StorageUsage.SoftLimit != nil {
+				softLimit := from.Workspace.StorageUsage.SoftLimit.DeepCopy()
+				to.Workspace.StorageUsage.SoftLimit = &softLimit
+			}
+		}
 	}
 }
 
@@ -229,6 +368,52 @@ func formatCurrentConfig() string {
 		if Routing.DefaultRoutingClass != DefaultConfig.Routing.DefaultRoutingClass {
 			config = append(config, fmt.Sprintf("routing.defaultRoutingClass=%s", Routing.DefaultRoutingClass))
 		}
+		if Routing.SingleHostname != "" {
+			config = append(config, fmt.Sprintf("routing.singleHostname=%s", Routing.SingleHostname))
+		}
+		if Routing.IngressClassName != "" {
+			config = append(config, fmt.Sprintf("routing.ingressClassName=%s", Routing.IngressClassName))
+		}
+		if len(Routing.AllowedEndpointIngressClasses) > 0 {
+			config = append(config, fmt.Sprintf("routing.allowedEndpointIngressClasses=%v", Routing.AllowedEndpointIngressClasses))
+		}
+		if len(Routing.Annotations) > 0 {
+			config = append(config, fmt.Sprintf("routing.annotations=%v", Routing.Annotations))
+		}
+		if len(Routing.AllowedEndpointAnnotations) > 0 {
+			config = append(config, fmt.Sprintf("routing.allowedEndpointAnnotations=%v", Routing.AllowedEndpointAnnotations))
+		}
+		if len(Routing.Labels) > 0 {
+			config = append(config, fmt.Sprintf("routing.labels=%v", Routing.Labels))
+		}
+		if Routing.NetworkPolicy != nil && Routing.NetworkPolicy.Enabled != nil && *Routing.NetworkPolicy.Enabled {
+			config = append(config, "routing.networkPolicy.enabled=true")
+		}
+		if Routing.NonHTTPEndpoints != nil && Routing.NonHTTPEndpoints.ServiceType != "" {
+			config = append(config, fmt.Sprintf("routing.nonHTTPEndpoints.serviceType=%s", Routing.NonHTTPEndpoints.ServiceType))
+		}
+		if Routing.Gateway != nil {
+			gateway := Routing.Gateway.Name
+			if Routing.Gateway.Namespace != "" {
+				gateway = fmt.Sprintf("%s/%s", Routing.Gateway.Namespace, gateway)
+			}
+			config = append(config, fmt.Sprintf("routing.gateway=%s", gateway))
+		}
+		if Routing.TLS != nil {
+			switch {
+			case Routing.TLS.Issuer != "":
+				config = append(config, fmt.Sprintf("routing.tls.issuer=%s", Routing.TLS.Issuer))
+			case Routing.TLS.ClusterIssuer != "":
+				config = append(config, fmt.Sprintf("routing.tls.clusterIssuer=%s", Routing.TLS.ClusterIssuer))
+			case Routing.TLS.SecretName != "":
+				config = append(config, fmt.Sprintf("routing.tls.secretName=%s", Routing.TLS.SecretName))
+			default:
+				config = append(config, "routing.tls=default")
+			}
+		}
+		if Routing.OIDC != nil {
+			config = append(config, fmt.Sprintf("routing.oidc.issuerURL=%s", Routing.OIDC.IssuerURL))
+		}
 	}
 	if Workspace != nil {
 		if Workspace.ImagePullPolicy != DefaultConfig.Workspace.ImagePullPolicy {
@@ -240,13 +425,77 @@ func formatCurrentConfig() string {
 		if Workspace.StorageClassName != nil && Workspace.StorageClassName != DefaultConfig.Workspace.StorageClassName {
 			config = append(config, fmt.Sprintf("workspace.storageClassName=%s", *Workspace.StorageClassName))
 		}
+		if Workspace.CommonPVCSize != nil {
+			config = append(config, fmt.Sprintf("workspace.commonPVCSize=%s", Workspace.CommonPVCSize.String()))
+		}
+		if Workspace.ComputeCommonPVCSize != nil && *Workspace.ComputeCommonPVCSize {
+			config = append(config, "workspace.computeCommonPVCSize=true")
+		}
 		if Workspace.IdleTimeout != DefaultConfig.Workspace.IdleTimeout {
 			config = append(config, fmt.Sprintf("workspace.idleTimeout=%s", Workspace.IdleTimeout))
 		}
+		if Workspace.StopIdleWorkspaces != nil && *Workspace.StopIdleWorkspaces {
+			config = append(config, "workspace.stopIdleWorkspaces=true")
+		}
+		if Workspace.MaxRunDuration != DefaultConfig.Workspace.MaxRunDuration {
+			config = append(config, fmt.Sprintf("workspace.maxRunDuration=%s", Workspace.MaxRunDuration))
+		}
 		if Workspace.IgnoredUnrecoverableEvents != nil {
 			config = append(config, fmt.Sprintf("workspace.ignoredUnrecoverableEvents=%s",
 				strings.Join(Workspace.IgnoredUnrecoverableEvents, ";")))
 		}
+		if Workspace.ImageBuild != nil {
+			if Workspace.ImageBuild.Registry != "" {
+				config = append(config, fmt.Sprintf("workspace.imageBuild.registry=%s", Workspace.ImageBuild.Registry))
+			}
+			if Workspace.ImageBuild.PushSecret != "" {
+				config = append(config, fmt.Sprintf("workspace.imageBuild.pushSecret=%s", Workspace.ImageBuild.PushSecret))
+			}
+			if Workspace.ImageBuild.AllowRootBuilds != nil && *Workspace.ImageBuild.AllowRootBuilds {
+				config = append(config, "workspace.imageBuild.allowRootBuilds=true")
+			}
+		}
+		if Workspace.Quotas != nil {
+			if Workspace.Quotas.MaxRunningPerUser != nil {
+				config = append(config, fmt.Sprintf("workspace.quotas.maxRunningPerUser=%d", *Workspace.Quotas.MaxRunningPerUser))
+			}
+			if Workspace.Quotas.MaxRunningPerNamespace != nil {
+				config = append(config, fmt.Sprintf("workspace.quotas.maxRunningPerNamespace=%d", *Workspace.Quotas.MaxRunningPerNamespace))
+			}
+			if Workspace.Quotas.MaxWorkspacesPerUser != nil {
+				config = append(config, fmt.Sprintf("workspace.quotas.maxWorkspacesPerUser=%d", *Workspace.Quotas.MaxWorkspacesPerUser))
+			}
+		}
+		if Workspace.Backup != nil {
+			if Workspace.Backup.VolumeSnapshotClassName != nil {
+				config = append(config, fmt.Sprintf("workspace.backup.volumeSnapshotClassName=%s", *Workspace.Backup.VolumeSnapshotClassName))
+			}
+			if Workspace.Backup.Schedule != "" {
+				config = append(config, fmt.Sprintf("workspace.backup.schedule=%s", Workspace.Backup.Schedule))
+			}
+			if Workspace.Backup.BackupOnDelete != nil {
+				config = append(config, fmt.Sprintf("workspace.backup.backupOnDelete=%t", *Workspace.Backup.BackupOnDelete))
+			}
+			if Workspace.Backup.MaxBackups != nil {
+				config = append(config, fmt.Sprintf("workspace.backup.maxBackups=%d", *Workspace.Backup.MaxBackups))
+			}
+		}
+		if Workspace.StorageGarbageCollection != nil {
+			if Workspace.StorageGarbageCollection.Interval != DefaultConfig.Workspace.StorageGarbageCollection.Interval {
+				config = append(config, fmt.Sprintf("workspace.storageGarbageCollection.interval=%s", Workspace.StorageGarbageCollection.Interval))
+			}
+			if Workspace.StorageGarbageCollection.DryRun != nil && *Workspace.StorageGarbageCollection.DryRun != *DefaultConfig.Workspace.StorageGarbageCollection.DryRun {
+				config = append(config, fmt.Sprintf("workspace.storageGarbageCollection.dryRun=%t", *Workspace.StorageGarbageCollection.DryRun))
+			}
+		}
+		if Workspace.StorageUsage != nil {
+			if Workspace.StorageUsage.Interval != DefaultConfig.Workspace.StorageUsage.Interval {
+				config = append(config, fmt.Sprintf("workspace.storageUsage.interval=%s", Workspace.StorageUsage.Interval))
+			}
+			if Workspace.StorageUsage.SoftLimit != nil {
+				config = append(config, fmt.Sprintf("workspace.storageUsage.softLimit=%s", Workspace.StorageUsage.SoftLimit.String()))
+			}
+		}
 	}
 	if internalConfig.EnableExperimentalFeatures != nil && *internalConfig.EnableExperimentalFeatures {
 		config = append(config, "enableExperimentalFeatures=true")
@@ -241,3 +241,45 @@ func TestMergeConfigLooksAtAllFields(t *testing.T) {
 	mergeConfig(expectedConfig, actualConfig)
 	assert.Equal(t, expectedConfig, actualConfig, "merging configs should merge all fields")
 }
+
+func TestSyncConfigMergesRoutingMetadata(t *testing.T) {
+	setupForTest(t)
+	internalConfig = DefaultConfig.DeepCopy()
+	config := buildConfig(&v1alpha1.OperatorConfiguration{
+		Routing: &v1alpha1.RoutingConfig{
+			IngressClassName: "test-ingress-class",
+			Annotations: map[string]string{
+				"test-annotation": "test-value",
+			},
+			Labels: map[string]string{
+				"test-label": "test-value",
+			},
+			AllowedEndpointIngressClasses: []string{"test-endpoint-class"},
+			AllowedEndpointAnnotations:    []string{"test-endpoint-annotation"},
+		},
+	})
+	syncConfigFrom(config)
+	assert.Equal(t, "test-ingress-class", Routing.IngressClassName, "Should update ingressClassName from config")
+	assert.Equal(t, map[string]string{"test-annotation": "test-value"}, Routing.Annotations, "Should update annotations from config")
+	assert.Equal(t, map[string]string{"test-label": "test-value"}, Routing.Labels, "Should update labels from config")
+	assert.Equal(t, []string{"test-endpoint-class"}, Routing.AllowedEndpointIngressClasses, "Should update allowedEndpointIngressClasses from config")
+	assert.Equal(t, []string{"test-endpoint-annotation"}, Routing.AllowedEndpointAnnotations, "Should update allowedEndpointAnnotations from config")
+	assert.Equal(t, DefaultConfig.Routing.DefaultRoutingClass, Routing.DefaultRoutingClass, "Should keep defaults for unset fields")
+
+	config.Config.Routing.Annotations["test-annotation"] = "changed-value"
+	assert.Equal(t, "test-value", Routing.Annotations["test-annotation"], "Merged annotations should not share storage with cluster config")
+	config.Config.Routing.AllowedEndpointAnnotations[0] = "changed-annotation"
+	assert.Equal(t, "test-endpoint-annotation", Routing.AllowedEndpointAnnotations[0], "Merged allowlists should not share storage with cluster config")
+
+	config.Config.Routing.Annotations = map[string]string{"other-annotation": "other-value"}
+	syncConfigFrom(config)
+	assert.Equal(t, map[string]string{"other-annotation": "other-value"}, Routing.Annotations, "Annotations from config should replace previous annotations")
+
+	config.Config.Routing = &v1alpha1.RoutingConfig{}
+	syncConfigFrom(config)
+	assert.Empty(t, Routing.IngressClassName, "Should restore default ingressClassName when removed from config")
+	assert.Empty(t, Routing.Annotations, "Should restore default annotations when removed from config")
+	assert.Empty(t, Routing.Labels, "Should restore default labels when removed from config")
+	assert.Empty(t, Routing.AllowedEndpointIngressClasses, "Should restore default allowedEndpointIngressClasses when removed from config")
+	assert.Empty(t, Routing.AllowedEndpointAnnotations, "Should restore default allowedEndpointAnnotations when removed from config")
+}
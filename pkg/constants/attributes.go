@@ -23,8 +23,23 @@ const (
 	// - "common": Create one PVC per namespace, and store data for all workspaces in that namespace in that PVC
 	// - "async" : Create one PVC per namespace, and create a remote server that syncs data from workspaces to the PVC.
 	//             All volumeMounts used for devworkspaces are emptyDir
+	// - "ephemeral": Use emptyDir volumes for all volumes in the workspace; data is lost when the workspace is stopped
+	// - "per-workspace": Create one PVC per workspace, and store all data for that workspace in that PVC
+	// If the storage type of a workspace is changed, its data is migrated to the new storage type when it is stopped.
 	DevWorkspaceStorageTypeAttribute = "controller.devfile.io/storage-type"
 
+	// EphemeralVolumeMediumAttribute can be applied to a volume component to configure the storage medium backing the
+	// emptyDir volume used when the volume is ephemeral (i.e. marked as ephemeral, or when the "ephemeral" or "async"
+	// storage types are used). The only supported value is "Memory", which backs the volume with a tmpfs. Data written
+	// to a memory-backed volume counts against the memory limits of the containers that write it.
+	EphemeralVolumeMediumAttribute = "controller.devfile.io/ephemeral-medium"
+
+	// EphemeralVolumeStorageClassAttribute can be applied to a volume component to provide the volume through a generic
+	// ephemeral volume using the specified storage class instead of an emptyDir volume, when the volume is ephemeral.
+	// The PVC for the volume is sized according to the volume's size (or 1Gi if unset) and is deleted along with the
+	// workspace's pod. This attribute cannot be combined with EphemeralVolumeMediumAttribute.
+	EphemeralVolumeStorageClassAttribute = "controller.devfile.io/ephemeral-storage-class"
+
 	// WorkspaceEnvAttribute is an attribute that specifies a set of environment variables provided by a component
 	// that should be added to all workspace containers. The structure of the attribute value should be a list of
 	// Devfile 2.0 EnvVar, e.g.
@@ -50,6 +65,40 @@ const (
 	// or parent imported it)
 	PluginSourceAttribute = "controller.devfile.io/imported-by"
 
+	// DedicatedPodComponentAttribute is an attribute added by the DevWorkspace controller to endpoints of container
+	// components that set dedicatedPod: true when they are passed to the DevWorkspaceRouting. Its value is the name of
+	// the component, and signifies that the endpoint is served by the component's own Service and pod rather than the
+	// main workspace pod. Values set on endpoints in the DevWorkspace are ignored.
+	DedicatedPodComponentAttribute = "controller.devfile.io/dedicated-pod-component"
+
+	// IngressClassNameAttribute can be applied to an endpoint to set the IngressClass used for the Ingress that
+	// exposes the endpoint, overriding the ingress class set in the DevWorkspace Operator configuration.
+	IngressClassNameAttribute = "controller.devfile.io/ingress-class-name"
+
+	// RoutingAnnotationsAttribute can be applied to an endpoint to specify additional annotations for the Ingress or
+	// Route that exposes the endpoint. The value of the attribute should be a map of annotation keys to values, e.g.
+	//
+	//   attributes:
+	//     controller.devfile.io/routing-annotations:
+	//       traefik.ingress.kubernetes.io/router.middlewares: default-strip-prefix@kubernetescrd
+	//
+	// Annotations specified through this attribute override those set in the DevWorkspace Operator configuration.
+	RoutingAnnotationsAttribute = "controller.devfile.io/routing-annotations"
+
+	// RoutingLabelsAttribute can be applied to an endpoint to specify additional labels for the Ingress or Route that
+	// exposes the endpoint, as a map of label keys to values.
+	RoutingLabelsAttribute = "controller.devfile.io/routing-labels"
+
+	// EndpointHealthPathAttribute can be applied to a public endpoint to specify the path, relative to the endpoint's
+	// URL, that is probed to determine whether the endpoint is ready before the DevWorkspace enters the Running phase.
+	// If unset, the endpoint's URL is probed directly (or the "healthz" path, for the endpoint of type "main").
+	EndpointHealthPathAttribute = "controller.devfile.io/health-path"
+
+	// SkipEndpointReadinessCheckAttribute can be applied to a public endpoint with the value "true" to exclude it from
+	// readiness checks. This is useful for endpoints served by applications that are started manually after the
+	// DevWorkspace is running.
+	SkipEndpointReadinessCheckAttribute = "controller.devfile.io/skip-readiness-check"
+
 	// EndpointURLAttribute is an attribute added to endpoints to denote the endpoint on the cluster that
 	// was created to route to this endpoint
 	EndpointURLAttribute = "controller.devfile.io/endpoint-url"
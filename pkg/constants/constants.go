@@ -73,6 +73,10 @@ const (
 	// EphemeralStorageClassType defines the 'ephemeral' storage policy: all volumes are allocated as emptyDir volumes and
 	// so do not require cleanup. When a DevWorkspace is stopped, all local changes are lost.
 	EphemeralStorageClassType = "ephemeral"
+	// PerWorkspaceStorageClassType defines the 'per-workspace' storage policy -- one PVC is provisioned for each devworkspace,
+	// sized according to the devworkspace's volumes, and all devworkspace storage is mounted in it on subpaths according to
+	// volume name. The PVC is deleted along with the devworkspace.
+	PerWorkspaceStorageClassType = "per-workspace"
 
 	// Constants describing configuration for automatic project cloning
 
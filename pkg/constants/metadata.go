@@ -23,9 +23,21 @@ const (
 	// DevWorkspaceCreatorLabel is the label key for storing the UID of the user who created the workspace
 	DevWorkspaceCreatorLabel = "controller.devfile.io/creator"
 
+	// DevWorkspaceCreatorUsernameAnnotation is the annotation key for storing the username of the user who created the
+	// workspace
+	DevWorkspaceCreatorUsernameAnnotation = "controller.devfile.io/creator-username"
+
 	// DevWorkspaceNameLabel is the label key to store workspace name
 	DevWorkspaceNameLabel = "controller.devfile.io/devworkspace_name"
 
+	// DevWorkspaceDedicatedPodLabel is the label key applied to deployments, pods, and services created for container
+	// components that set dedicatedPod: true. Its value is the name of the component.
+	DevWorkspaceDedicatedPodLabel = "controller.devfile.io/dedicated-pod"
+
+	// DevWorkspaceMainPodLabel is applied to the main workspace pod when a DevWorkspace has dedicatedPod components, to
+	// allow services to select the main workspace pod only.
+	DevWorkspaceMainPodLabel = "controller.devfile.io/main-pod"
+
 	// DevWorkspaceWatchConfigMapLabel marks a configmap so that it is watched by the controller. This label is required on all
 	// configmaps that should be seen by the controller
 	DevWorkspaceWatchConfigMapLabel = "controller.devfile.io/watch-configmap"
@@ -76,6 +88,90 @@ const (
 	// this annotation will be cleared
 	DevWorkspaceStopReasonAnnotation = "controller.devfile.io/stopped-by"
 
+	// DevWorkspaceStopReasonInactivity is the value of the DevWorkspaceStopReasonAnnotation annotation applied to
+	// DevWorkspaces that are stopped by the controller because they were idle for longer than the configured idle timeout.
+	DevWorkspaceStopReasonInactivity = "inactivity"
+
+	// DevWorkspaceLastActivityAnnotation stores the time (in RFC3339 format) of the last user activity in a
+	// DevWorkspace. Editors, agents, or the routing layer can update this annotation to report activity; if stopping
+	// idle workspaces is enabled, running DevWorkspaces with no reported activity for longer than the configured idle
+	// timeout are stopped by the controller.
+	DevWorkspaceLastActivityAnnotation = "controller.devfile.io/last-activity"
+
+	// DevWorkspaceMaxRunDurationAnnotation configures the maximum duration (e.g. "8h") a DevWorkspace can run before it
+	// is automatically stopped by the controller. If set, it overrides the maxRunDuration configured for the operator.
+	DevWorkspaceMaxRunDurationAnnotation = "controller.devfile.io/max-run-duration"
+
+	// DevWorkspaceStopReasonMaxRunDuration is the value of the DevWorkspaceStopReasonAnnotation annotation applied to
+	// DevWorkspaces that are stopped by the controller because they exceeded their maximum run duration.
+	DevWorkspaceStopReasonMaxRunDuration = "max-run-duration"
+
+	// DevWorkspaceStopScheduleAnnotation configures a cron-style schedule (e.g. "0 20 * * *") at which a running
+	// DevWorkspace is automatically stopped. Schedules are evaluated in UTC unless a timezone is specified via a
+	// "CRON_TZ=<timezone>" prefix.
+	DevWorkspaceStopScheduleAnnotation = "controller.devfile.io/stop-schedule"
+
+	// DevWorkspaceStartScheduleAnnotation configures a cron-style schedule (e.g. "30 7 * * 1-5") at which a stopped
+	// DevWorkspace is automatically started. Schedules are evaluated in UTC unless a timezone is specified via a
+	// "CRON_TZ=<timezone>" prefix.
+	DevWorkspaceStartScheduleAnnotation = "controller.devfile.io/start-schedule"
+
+	// DevWorkspaceStopReasonSchedule is the value of the DevWorkspaceStopReasonAnnotation annotation applied to
+	// DevWorkspaces that are stopped by the controller according to their stop schedule.
+	DevWorkspaceStopReasonSchedule = "schedule"
+
+	// DevWorkspaceRestartAnnotation requests a restart of a running DevWorkspace when applied with any value. The
+	// controller removes this annotation and records the time of the restart in the DevWorkspaceRestartedAtAnnotation
+	// annotation.
+	DevWorkspaceRestartAnnotation = "controller.devfile.io/restart"
+
+	// DevWorkspaceRestartedAtAnnotation stores the time (in RFC3339 format) of the last requested restart of a running
+	// DevWorkspace. It is propagated to the pod templates of the DevWorkspace's deployments to trigger a rollout, and is
+	// removed when the DevWorkspace is stopped.
+	DevWorkspaceRestartedAtAnnotation = "controller.devfile.io/restarted-at"
+
+	// DevWorkspaceKubeComponentObjectsAnnotation records the objects created on the cluster from the Kubernetes and
+	// OpenShift components of a DevWorkspace, as a JSON list of apiVersion, kind, and name. It is managed by the
+	// controller and is used to delete objects that are removed from the DevWorkspace's components.
+	DevWorkspaceKubeComponentObjectsAnnotation = "controller.devfile.io/kubernetes-component-objects"
+
+	// DevWorkspaceStorageTypeAnnotation records the storage type for which storage was last provisioned for a
+	// DevWorkspace. It is managed by the controller and is used to detect changes to the storage-type attribute, in
+	// which case the DevWorkspace's data is migrated to the new storage type.
+	DevWorkspaceStorageTypeAnnotation = "controller.devfile.io/provisioned-storage-type"
+
+	// DevWorkspaceBackupAnnotation requests a backup of a DevWorkspace's storage when applied with any value. The
+	// controller creates a VolumeSnapshot of the DevWorkspace's storage, removes this annotation, and records the time
+	// of the backup in the DevWorkspaceLastBackupAnnotation annotation.
+	DevWorkspaceBackupAnnotation = "controller.devfile.io/backup"
+
+	// DevWorkspaceBackupScheduleAnnotation defines a cron schedule (e.g. "0 2 * * *") on which a running DevWorkspace
+	// is backed up, overriding the schedule set in the DevWorkspace Operator configuration. Schedules are evaluated in
+	// UTC unless a timezone is specified via a "CRON_TZ=<timezone>" prefix.
+	DevWorkspaceBackupScheduleAnnotation = "controller.devfile.io/backup-schedule"
+
+	// DevWorkspaceLastBackupAnnotation stores the time (in RFC3339 format) that a DevWorkspace's storage was last backed up.
+	DevWorkspaceLastBackupAnnotation = "controller.devfile.io/last-backup"
+
+	// DevWorkspaceRestoreFromAnnotation can be applied to a new DevWorkspace to restore its storage from a VolumeSnapshot
+	// created as a backup of a DevWorkspace in the same namespace. The value of the annotation is the name of the
+	// VolumeSnapshot. Storage is restored before the DevWorkspace is first started, after which this annotation is
+	// replaced by the DevWorkspaceRestoredFromAnnotation annotation.
+	DevWorkspaceRestoreFromAnnotation = "controller.devfile.io/restore-from"
+
+	// DevWorkspaceRestoredFromAnnotation stores the name of the VolumeSnapshot a DevWorkspace's storage was restored from.
+	DevWorkspaceRestoredFromAnnotation = "controller.devfile.io/restored-from"
+
+	// DevWorkspaceStorageUsageAnnotation stores the disk usage, in bytes, of a DevWorkspace's data on the common PVC, as
+	// last measured by the controller. It is only applied to DevWorkspaces that use the common or async storage types.
+	DevWorkspaceStorageUsageAnnotation = "controller.devfile.io/storage-usage"
+
+	// DevWorkspaceEndpointStatusAnnotation stores the readiness of each public endpoint of a starting or running
+	// DevWorkspace, as last checked by the controller. The value is a JSON object mapping endpoint names to an object
+	// with a "status" field, one of "Ready", "NotReady", or "NotChecked", and a "message" field describing why an
+	// endpoint is not ready. It is removed when the DevWorkspace is stopped.
+	DevWorkspaceEndpointStatusAnnotation = "controller.devfile.io/endpoint-status"
+
 	// DevWorkspaceDebugStartAnnotation enables debugging workspace startup if set to "true". If a workspace with this annotation
 	// fails to start (i.e. enters the "Failed" phase), its deployment will not be scaled down in order to allow viewing logs, etc.
 	DevWorkspaceDebugStartAnnotation = "controller.devfile.io/debug-start"
@@ -90,6 +186,10 @@ const (
 	// DevWorkspaceEndpointNameAnnotation is the annotation key for storing an endpoint's name from the devfile representation
 	DevWorkspaceEndpointNameAnnotation = "controller.devfile.io/endpoint_name"
 
+	// DevWorkspaceEndpointPathAnnotation is the annotation key for storing the path an endpoint is exposed on, for
+	// ingresses that expose endpoints on a path other than the root of their host
+	DevWorkspaceEndpointPathAnnotation = "controller.devfile.io/endpoint_path"
+
 	// DevWorkspaceDiscoverableServiceAnnotation marks a service in a devworkspace as created for a discoverable endpoint,
 	// as opposed to a service created to support the devworkspace itself.
 	DevWorkspaceDiscoverableServiceAnnotation = "controller.devfile.io/discoverable-service"
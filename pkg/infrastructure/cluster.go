@@ -35,15 +35,20 @@ const (
 
 var (
 	// current is the infrastructure that we're currently running on.
-	current     Type
+	current Type
+	// gatewayAPI is whether the Kubernetes Gateway API is installed on the cluster.
+	gatewayAPI  bool
 	initialized = false
 )
 
+// gatewayAPIGroup is the API group of the Kubernetes Gateway API
+const gatewayAPIGroup = "gateway.networking.k8s.io"
+
 // Initialize attempts to determine the type of cluster its currently running on (OpenShift or Kubernetes). This function
 // *must* be called before others; otherwise the call will panic.
 func Initialize() error {
 	var err error
-	current, err = detect()
+	current, gatewayAPI, err = detect()
 	if err != nil {
 		return err
 	}
@@ -60,6 +65,11 @@ func InitializeForTesting(currentInfrastructure Type) {
 	initialized = true
 }
 
+// InitializeGatewayAPIForTesting is used to mock whether the Gateway API is installed on the cluster in testing code.
+func InitializeGatewayAPIForTesting(installed bool) {
+	gatewayAPI = installed
+}
+
 // IsOpenShift returns true if the current cluster is an OpenShift (v4.x) cluster.
 func IsOpenShift() bool {
 	if !initialized {
@@ -68,26 +78,35 @@ func IsOpenShift() bool {
 	return current == OpenShiftv4
 }
 
-func detect() (Type, error) {
+// SupportsGatewayAPI returns true if the Kubernetes Gateway API is installed on the current cluster.
+func SupportsGatewayAPI() bool {
+	if !initialized {
+		panic("Attempting to determine information about the cluster without initializing first")
+	}
+	return gatewayAPI
+}
+
+func detect() (infraType Type, gatewayAPI bool, err error) {
 	kubeCfg, err := config.GetConfig()
 	if err != nil {
-		return Unsupported, fmt.Errorf("could not get kube config: %w", err)
+		return Unsupported, false, fmt.Errorf("could not get kube config: %w", err)
 	}
 	discoveryClient, err := discovery.NewDiscoveryClientForConfig(kubeCfg)
 	if err != nil {
-		return Unsupported, fmt.Errorf("could not get discovery client: %w", err)
+		return Unsupported, false, fmt.Errorf("could not get discovery client: %w", err)
 	}
 	apiList, err := discoveryClient.ServerGroups()
 	if err != nil {
-		return Unsupported, fmt.Errorf("could not read API groups: %w", err)
+		return Unsupported, false, fmt.Errorf("could not read API groups: %w", err)
 	}
+	gatewayAPI = findAPIGroup(apiList.Groups, gatewayAPIGroup) != nil
 	if findAPIGroup(apiList.Groups, "route.openshift.io") == nil {
-		return Kubernetes, nil
+		return Kubernetes, gatewayAPI, nil
 	} else {
 		if findAPIGroup(apiList.Groups, "config.openshift.io") == nil {
-			return Unsupported, nil
+			return Unsupported, gatewayAPI, nil
 		} else {
-			return OpenShiftv4, nil
+			return OpenShiftv4, gatewayAPI, nil
 		}
 	}
 }
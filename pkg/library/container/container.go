@@ -70,6 +70,10 @@ func GetKubeContainersFromDevfile(workspace *dw.DevWorkspaceTemplateSpec) (*v1al
 		return nil, err
 	}
 
+	if err := lifecycle.AddPreStopLifecycleHooks(workspace, podAdditions.Containers); err != nil {
+		return nil, err
+	}
+
 	for _, container := range initContainers {
 		k8sContainer, err := convertContainerToK8s(container)
 		if err != nil {
@@ -15,78 +15,210 @@ package lifecycle
 
 import (
 	"fmt"
+	"regexp"
 	"strings"
 
 	dw "github.com/devfile/api/v2/pkg/apis/workspaces/v1alpha2"
 	corev1 "k8s.io/api/core/v1"
 )
 
+// envVarNameRegexp matches environment variable names that can be exported by a shell
+var envVarNameRegexp = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// envVarReferenceRegexp matches a reference to an environment variable in the forms $NAME or ${NAME}, which are expanded
+// in working directories (e.g. ${PROJECTS_ROOT}).
+var envVarReferenceRegexp = regexp.MustCompile(`^\$([A-Za-z_][A-Za-z0-9_]*|\{[A-Za-z_][A-Za-z0-9_]*\})`)
+
+// AddPostStartLifecycleHooks adds postStart lifecycle hooks to containers for all commands referenced by the devfile's
+// postStart events. Commands may be exec commands or composite commands that refer to exec commands. All commands that
+// target the same container are combined into a single script, which runs commands in the order they are specified
+// and stops at the first failure; commands that are part of a parallel composite command are run in the background.
 func AddPostStartLifecycleHooks(wksp *dw.DevWorkspaceTemplateSpec, containers []corev1.Container) error {
 	if wksp.Events == nil || len(wksp.Events.PostStart) == 0 {
 		return nil
 	}
 
-	usedContainers := map[string]bool{}
+	componentScripts := map[string][]string{}
 	for _, commandName := range wksp.Events.PostStart {
 		command, err := getCommandByKey(commandName, wksp.Commands)
 		if err != nil {
 			return fmt.Errorf("could not resolve command for postStart event '%s': %w", commandName, err)
 		}
-		cmdType, err := getCommandType(*command)
+		scripts, err := getPostStartScripts(*command, wksp.Commands, containers, map[string]bool{})
 		if err != nil {
-			return fmt.Errorf("could not determine command type for '%s': %w", command.Key(), err)
+			return fmt.Errorf("failed to process postStart event %s: %w", commandName, err)
 		}
-		if cmdType != dw.ExecCommandType {
-			return fmt.Errorf("can not use %s-type command in postStart lifecycle event", cmdType)
+		for component, script := range scripts {
+			componentScripts[component] = append(componentScripts[component], script)
 		}
+	}
 
-		execCmd := command.Exec
-		if usedContainers[execCmd.Component] {
-			return fmt.Errorf("component %s has multiple postStart events attached to it", command.Exec.Component)
+	for idx, container := range containers {
+		scripts, ok := componentScripts[container.Name]
+		if !ok {
+			continue
 		}
+		if containers[idx].Lifecycle == nil {
+			containers[idx].Lifecycle = &corev1.Lifecycle{}
+		}
+		containers[idx].Lifecycle.PostStart = &corev1.Handler{
+			Exec: &corev1.ExecAction{
+				Command: []string{"/bin/sh", "-c", sequentialScript(scripts)},
+			},
+		}
+	}
 
-		cmdContainer, err := getContainerWithName(execCmd.Component, containers)
+	return nil
+}
+
+// getPostStartScripts converts a postStart command into a map of component names to the script that should be run
+// in that component. Composite commands are resolved recursively; visited is used to detect composite commands that
+// reference themselves.
+func getPostStartScripts(command dw.Command, commands []dw.Command, containers []corev1.Container, visited map[string]bool) (map[string]string, error) {
+	cmdType, err := getCommandType(command)
+	if err != nil {
+		return nil, fmt.Errorf("could not determine command type for '%s': %w", command.Key(), err)
+	}
+	switch cmdType {
+	case dw.ExecCommandType:
+		if _, err := getContainerWithName(command.Exec.Component, containers); err != nil {
+			return nil, err
+		}
+		script, err := getExecScript(command.Exec)
 		if err != nil {
-			return fmt.Errorf("failed to process postStart event %s: %w", commandName, err)
+			return nil, fmt.Errorf("invalid command %s: %w", command.Key(), err)
+		}
+		return map[string]string{command.Exec.Component: script}, nil
+	case dw.CompositeCommandType:
+		if visited[command.Key()] {
+			return nil, fmt.Errorf("composite command %s references itself", command.Key())
+		}
+		visited[command.Key()] = true
+		defer delete(visited, command.Key())
+
+		subScripts := map[string][]string{}
+		for _, subCommandName := range command.Composite.Commands {
+			subCommand, err := getCommandByKey(subCommandName, commands)
+			if err != nil {
+				return nil, fmt.Errorf("could not resolve command referenced by composite command %s: %w", command.Key(), err)
+			}
+			scripts, err := getPostStartScripts(*subCommand, commands, containers, visited)
+			if err != nil {
+				return nil, err
+			}
+			for component, script := range scripts {
+				subScripts[component] = append(subScripts[component], script)
+			}
 		}
 
-		postStartHandler, err := processCommandForPostStart(execCmd)
-		if err != nil {
-			return fmt.Errorf("failed to process postStart event %s: %w", commandName, err)
+		parallel := command.Composite.Parallel != nil && *command.Composite.Parallel
+		result := map[string]string{}
+		for component, scripts := range subScripts {
+			if parallel {
+				result[component] = parallelScript(scripts)
+			} else {
+				result[component] = sequentialScript(scripts)
+			}
 		}
+		return result, nil
+	default:
+		return nil, fmt.Errorf("can not use %s-type command in postStart lifecycle event", cmdType)
+	}
+}
 
-		if cmdContainer.Lifecycle == nil {
-			cmdContainer.Lifecycle = &corev1.Lifecycle{}
+// getExecScript returns the script used to run an exec command, exporting its env vars and changing to its
+// working directory if necessary. Env var values are quoted so that they are not expanded by the shell; references to
+// env vars in the working directory are expanded, but other shell syntax is not. Returns an error if an env var name
+// is not a valid shell variable name.
+func getExecScript(command *dw.ExecCommand) (string, error) {
+	var lines []string
+	for _, env := range command.Env {
+		if !envVarNameRegexp.MatchString(env.Name) {
+			return "", fmt.Errorf("invalid env var name '%s'", env.Name)
 		}
-		cmdContainer.Lifecycle.PostStart = postStartHandler
+		lines = append(lines, fmt.Sprintf("export %s=%s", env.Name, singleQuote(env.Value)))
+	}
+	if command.WorkingDir != "" {
+		lines = append(lines, fmt.Sprintf("cd %s", quoteWorkingDir(command.WorkingDir)))
+	}
+	lines = append(lines, command.CommandLine)
+	return strings.Join(lines, "\n"), nil
+}
 
-		usedContainers[execCmd.Component] = true
+// sequentialScript combines scripts into one script that runs each in a subshell, in order, exiting on the first
+// failure. If only one script is provided, it is returned unmodified.
+func sequentialScript(scripts []string) string {
+	if len(scripts) == 1 {
+		return scripts[0]
 	}
+	lines := []string{"set -e"}
+	for _, script := range scripts {
+		lines = append(lines, fmt.Sprintf("(\n%s\n)", script))
+	}
+	return strings.Join(lines, "\n")
+}
 
-	return nil
+// parallelScript combines scripts into one script that runs each in a background subshell and waits for all of
+// them to complete, even if some fail. The combined script exits with the status of the last script to fail, or zero
+// if all scripts succeed.
+func parallelScript(scripts []string) string {
+	if len(scripts) == 1 {
+		return scripts[0]
+	}
+	var lines []string
+	for idx, script := range scripts {
+		lines = append(lines, fmt.Sprintf("(\n%s\n) &\npid_%d=$!", script, idx))
+	}
+	lines = append(lines, "exit_code=0")
+	for idx := range scripts {
+		lines = append(lines, fmt.Sprintf("wait $pid_%d || exit_code=$?", idx))
+	}
+	lines = append(lines, "exit $exit_code")
+	return strings.Join(lines, "\n")
 }
 
-func processCommandForPostStart(command *dw.ExecCommand) (*corev1.Handler, error) {
-	cmd := []string{"/bin/sh", "-c"}
+// singleQuote quotes value for use as a single word in a shell script, without any expansion
+func singleQuote(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
 
-	if len(command.Env) > 0 {
-		return nil, fmt.Errorf("env vars in postStart command are unsupported")
+// quoteWorkingDir quotes a working directory for use as a single word in a shell script. References to env vars of
+// the form $NAME or ${NAME} are expanded; all other characters (including command substitutions) are taken literally.
+func quoteWorkingDir(workingDir string) string {
+	var quoted strings.Builder
+	quoted.WriteString(`"`)
+	for i := 0; i < len(workingDir); i++ {
+		switch c := workingDir[i]; c {
+		case '$':
+			if ref := envVarReferenceRegexp.FindString(workingDir[i:]); ref != "" {
+				quoted.WriteString(ref)
+				i += len(ref) - 1
+				continue
+			}
+			quoted.WriteString(`\$`)
+		case '\\', '"', '`':
+			quoted.WriteByte('\\')
+			quoted.WriteByte(c)
+		default:
+			quoted.WriteByte(c)
+		}
 	}
+	quoted.WriteString(`"`)
+	return quoted.String()
+}
 
-	var fullCmd []string
-	if command.WorkingDir != "" {
-		fullCmd = append(fullCmd, fmt.Sprintf("cd %s", command.WorkingDir))
+// processCommandForHook converts an exec command into a handler that can be used as a container lifecycle hook,
+// using the same script as for postStart commands.
+func processCommandForHook(command *dw.ExecCommand) (*corev1.Handler, error) {
+	script, err := getExecScript(command)
+	if err != nil {
+		return nil, err
 	}
-	fullCmd = append(fullCmd, command.CommandLine)
-
-	cmd = append(cmd, strings.Join(fullCmd, "\n"))
-
-	handler := &corev1.Handler{
+	return &corev1.Handler{
 		Exec: &corev1.ExecAction{
-			Command: cmd,
+			Command: []string{"/bin/sh", "-c", script},
 		},
-	}
-	return handler, nil
+	}, nil
 }
 
 func getContainerWithName(name string, containers []corev1.Container) (*corev1.Container, error) {
@@ -0,0 +1,68 @@
+// Copyright (c) 2019-2021 Red Hat, Inc.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lifecycle
+
+import (
+	"fmt"
+
+	dw "github.com/devfile/api/v2/pkg/apis/workspaces/v1alpha2"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// AddPreStopLifecycleHooks adds preStop lifecycle hooks to containers for each exec command referenced by the
+// devfile's preStop events. As with postStart events, each container may have at most one preStop command.
+func AddPreStopLifecycleHooks(wksp *dw.DevWorkspaceTemplateSpec, containers []corev1.Container) error {
+	if wksp.Events == nil || len(wksp.Events.PreStop) == 0 {
+		return nil
+	}
+
+	usedContainers := map[string]bool{}
+	for _, commandName := range wksp.Events.PreStop {
+		command, err := getCommandByKey(commandName, wksp.Commands)
+		if err != nil {
+			return fmt.Errorf("could not resolve command for preStop event '%s': %w", commandName, err)
+		}
+		cmdType, err := getCommandType(*command)
+		if err != nil {
+			return fmt.Errorf("could not determine command type for '%s': %w", command.Key(), err)
+		}
+		if cmdType != dw.ExecCommandType {
+			return fmt.Errorf("can not use %s-type command in preStop lifecycle event", cmdType)
+		}
+
+		execCmd := command.Exec
+		if usedContainers[execCmd.Component] {
+			return fmt.Errorf("component %s has multiple preStop events attached to it", command.Exec.Component)
+		}
+
+		cmdContainer, err := getContainerWithName(execCmd.Component, containers)
+		if err != nil {
+			return fmt.Errorf("failed to process preStop event %s: %w", commandName, err)
+		}
+
+		preStopHandler, err := processCommandForHook(execCmd)
+		if err != nil {
+			return fmt.Errorf("failed to process preStop event %s: %w", commandName, err)
+		}
+
+		if cmdContainer.Lifecycle == nil {
+			cmdContainer.Lifecycle = &corev1.Lifecycle{}
+		}
+		cmdContainer.Lifecycle.PreStop = preStopHandler
+
+		usedContainers[execCmd.Component] = true
+	}
+
+	return nil
+}
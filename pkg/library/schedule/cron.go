@@ -0,0 +1,212 @@
+//
+// Copyright (c) 2019-2021 Red Hat, Inc.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+// Package schedule parses cron-style schedules used to automatically start and stop DevWorkspaces.
+package schedule
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	// Embed timezone data so that schedules can specify a timezone even if the operator image does not include it.
+	_ "time/tzdata"
+)
+
+// maxSearchYears bounds the search for the next scheduled time, to avoid looping forever on schedules that can never
+// be satisfied (e.g. "0 0 30 2 *")
+const maxSearchYears = 5
+
+// Schedule is a parsed cron schedule
+type Schedule struct {
+	minute     uint64
+	hour       uint64
+	dayOfMonth uint64
+	month      uint64
+	dayOfWeek  uint64
+	// restrictedDays is true if both day-of-month and day-of-week are restricted, in which case a day matches if
+	// it matches either field (as in standard cron).
+	restrictedDays bool
+	location       *time.Location
+}
+
+type fieldBounds struct {
+	name     string
+	min, max int
+}
+
+var (
+	minuteBounds     = fieldBounds{"minute", 0, 59}
+	hourBounds       = fieldBounds{"hour", 0, 23}
+	dayOfMonthBounds = fieldBounds{"day of month", 1, 31}
+	monthBounds      = fieldBounds{"month", 1, 12}
+	// Both 0 and 7 represent Sunday
+	dayOfWeekBounds = fieldBounds{"day of week", 0, 7}
+)
+
+// Parse parses a standard five-field cron schedule ("minute hour day-of-month month day-of-week"). Each field
+// may be '*', a number, a range ("1-5"), a list ("1,3,5"), or a step ("*/15", "0-30/10"). Schedules are evaluated in
+// UTC unless prefixed with a timezone, e.g. "CRON_TZ=Europe/Paris 0 20 * * *".
+func Parse(spec string) (*Schedule, error) {
+	location := time.UTC
+	spec = strings.TrimSpace(spec)
+	if strings.HasPrefix(spec, "CRON_TZ=") || strings.HasPrefix(spec, "TZ=") {
+		idx := strings.Index(spec, " ")
+		if idx == -1 {
+			return nil, fmt.Errorf("invalid schedule %q: missing fields", spec)
+		}
+		tzName := spec[strings.Index(spec, "=")+1 : idx]
+		loc, err := time.LoadLocation(tzName)
+		if err != nil {
+			return nil, fmt.Errorf("invalid timezone %q in schedule: %w", tzName, err)
+		}
+		location = loc
+		spec = strings.TrimSpace(spec[idx:])
+	}
+
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("invalid schedule %q: expected 5 fields, found %d", spec, len(fields))
+	}
+	schedule := &Schedule{location: location}
+	var err error
+	if schedule.minute, err = parseField(fields[0], minuteBounds); err != nil {
+		return nil, err
+	}
+	if schedule.hour, err = parseField(fields[1], hourBounds); err != nil {
+		return nil, err
+	}
+	if schedule.dayOfMonth, err = parseField(fields[2], dayOfMonthBounds); err != nil {
+		return nil, err
+	}
+	if schedule.month, err = parseField(fields[3], monthBounds); err != nil {
+		return nil, err
+	}
+	if schedule.dayOfWeek, err = parseField(fields[4], dayOfWeekBounds); err != nil {
+		return nil, err
+	}
+	if schedule.dayOfWeek&(1<<7) != 0 {
+		schedule.dayOfWeek |= 1 << 0
+	}
+	schedule.restrictedDays = fields[2] != "*" && fields[4] != "*"
+	return schedule, nil
+}
+
+// Next returns the first time matching the schedule that is strictly after t. If no such time exists within
+// the next few years, the zero time is returned.
+func (s *Schedule) Next(t time.Time) time.Time {
+	t = t.In(s.location).Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(maxSearchYears, 0, 0)
+	for t.Before(limit) {
+		if s.month&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, s.location)
+			continue
+		}
+		if !s.matchesDay(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, s.location)
+			continue
+		}
+		if s.hour&(1<<uint(t.Hour())) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, s.location)
+			continue
+		}
+		if s.minute&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+func (s *Schedule) matchesDay(t time.Time) bool {
+	domMatch := s.dayOfMonth&(1<<uint(t.Day())) != 0
+	dowMatch := s.dayOfWeek&(1<<uint(t.Weekday())) != 0
+	if s.restrictedDays {
+		return domMatch || dowMatch
+	}
+	return domMatch && dowMatch
+}
+
+// parseField parses a single cron field into a bitset of the values it matches
+func parseField(field string, bounds fieldBounds) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		partBits, err := parseFieldPart(part, bounds)
+		if err != nil {
+			return 0, err
+		}
+		bits |= partBits
+	}
+	return bits, nil
+}
+
+func parseFieldPart(part string, bounds fieldBounds) (uint64, error) {
+	rangePart, step := part, 1
+	if idx := strings.Index(part, "/"); idx != -1 {
+		rangePart = part[:idx]
+		var err error
+		step, err = strconv.Atoi(part[idx+1:])
+		if err != nil || step <= 0 {
+			return 0, fmt.Errorf("invalid step in %s field: %q", bounds.name, part)
+		}
+	}
+
+	var start, end int
+	switch {
+	case rangePart == "*":
+		start, end = bounds.min, bounds.max
+	case strings.Contains(rangePart, "-"):
+		idx := strings.Index(rangePart, "-")
+		var err error
+		if start, err = parseValue(rangePart[:idx], bounds); err != nil {
+			return 0, err
+		}
+		if end, err = parseValue(rangePart[idx+1:], bounds); err != nil {
+			return 0, err
+		}
+		if start > end {
+			return 0, fmt.Errorf("invalid range in %s field: %q", bounds.name, part)
+		}
+	default:
+		value, err := parseValue(rangePart, bounds)
+		if err != nil {
+			return 0, err
+		}
+		start, end = value, value
+		if step != 1 {
+			// As in standard cron, "N/step" means "N-max/step"
+			end = bounds.max
+		}
+	}
+
+	var bits uint64
+	for i := start; i <= end; i += step {
+		bits |= 1 << uint(i)
+	}
+	return bits, nil
+}
+
+func parseValue(value string, bounds fieldBounds) (int, error) {
+	i, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value in %s field: %q", bounds.name, value)
+	}
+	if i < bounds.min || i > bounds.max {
+		return 0, fmt.Errorf("value %d in %s field is out of range (%d-%d)", i, bounds.name, bounds.min, bounds.max)
+	}
+	return i, nil
+}
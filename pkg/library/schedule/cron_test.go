@@ -0,0 +1,125 @@
+//
+// Copyright (c) 2019-2021 Red Hat, Inc.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package schedule
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScheduleNext(t *testing.T) {
+	tests := []struct {
+		name     string
+		schedule string
+		from     string
+		expected string
+	}{
+		{
+			name:     "Every night at 20:00",
+			schedule: "0 20 * * *",
+			from:     "2021-10-04T20:00:00Z",
+			expected: "2021-10-05T20:00:00Z",
+		},
+		{
+			name:     "Weekdays at 07:30 skips weekend",
+			schedule: "30 7 * * 1-5",
+			from:     "2021-10-08T09:00:00Z", // Friday
+			expected: "2021-10-11T07:30:00Z", // Monday
+		},
+		{
+			name:     "Steps",
+			schedule: "*/15 * * * *",
+			from:     "2021-10-04T10:16:30Z",
+			expected: "2021-10-04T10:30:00Z",
+		},
+		{
+			name:     "Lists and month rollover",
+			schedule: "0 0 1,15 * *",
+			from:     "2021-12-20T00:00:00Z",
+			expected: "2022-01-01T00:00:00Z",
+		},
+		{
+			name:     "Sunday as 7",
+			schedule: "0 12 * * 7",
+			from:     "2021-10-04T00:00:00Z",
+			expected: "2021-10-10T12:00:00Z",
+		},
+		{
+			name:     "Day of month or day of week when both are restricted",
+			schedule: "0 0 13 * 5",
+			from:     "2021-10-04T00:00:00Z",
+			expected: "2021-10-08T00:00:00Z",
+		},
+		{
+			name:     "Timezone",
+			schedule: "CRON_TZ=Europe/Paris 0 20 * * *",
+			from:     "2021-10-04T12:00:00Z",
+			expected: "2021-10-04T18:00:00Z",
+		},
+		{
+			name:     "Unsatisfiable schedule",
+			schedule: "0 0 30 2 *",
+			from:     "2021-10-04T00:00:00Z",
+			expected: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedule, err := Parse(tt.schedule)
+			if !assert.NoError(t, err, "Should parse schedule") {
+				return
+			}
+			from, err := time.Parse(time.RFC3339, tt.from)
+			if !assert.NoError(t, err) {
+				return
+			}
+			next := schedule.Next(from)
+			if tt.expected == "" {
+				assert.True(t, next.IsZero(), "Should not find next scheduled time")
+				return
+			}
+			expected, err := time.Parse(time.RFC3339, tt.expected)
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.True(t, expected.Equal(next), "Expected next scheduled time %s, got %s", expected, next)
+		})
+	}
+}
+
+func TestScheduleParseErrors(t *testing.T) {
+	tests := []struct {
+		schedule  string
+		errRegexp string
+	}{
+		{schedule: "0 20 * *", errRegexp: "expected 5 fields, found 4"},
+		{schedule: "60 20 * * *", errRegexp: "value 60 in minute field is out of range"},
+		{schedule: "0 20 * * mon", errRegexp: "invalid value in day of week field"},
+		{schedule: "0 5-1 * * *", errRegexp: "invalid range in hour field"},
+		{schedule: "*/0 * * * *", errRegexp: "invalid step in minute field"},
+		{schedule: "CRON_TZ=Not/AZone 0 20 * * *", errRegexp: "invalid timezone \"Not/AZone\""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.schedule, func(t *testing.T) {
+			_, err := Parse(tt.schedule)
+			if assert.Error(t, err) {
+				assert.Regexp(t, tt.errRegexp, err.Error())
+			}
+		})
+	}
+}
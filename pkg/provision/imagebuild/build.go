@@ -0,0 +1,362 @@
+//
+// Copyright (c) 2019-2021 Red Hat, Inc.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+// Package imagebuild handles building images for image components in a DevWorkspace. Each image component is built
+// by a Job that runs buildah and pushes the resulting image to the registry configured in the DevWorkspace Operator
+// configuration.
+package imagebuild
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	dw "github.com/devfile/api/v2/pkg/apis/workspaces/v1alpha2"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	"github.com/devfile/devworkspace-operator/internal/images"
+	"github.com/devfile/devworkspace-operator/pkg/common"
+	"github.com/devfile/devworkspace-operator/pkg/config"
+	"github.com/devfile/devworkspace-operator/pkg/constants"
+	"github.com/devfile/devworkspace-operator/pkg/provision/sync"
+	wsprovision "github.com/devfile/devworkspace-operator/pkg/provision/workspace"
+)
+
+const (
+	buildJobRequeueInterval = 5 * time.Second
+	buildContextPath        = "/tmp/build-context"
+	pushSecretMountPath     = "/tmp/push-secret"
+	pushSecretVolumeName    = "push-secret"
+	buildContextVolumeName  = "build-context"
+
+	// buildSpecHashAnnotation stores a hash of the image component and build configuration on the pod template of a
+	// build job. As the job is recreated when its spec changes, this ensures images are rebuilt when the image
+	// component is changed.
+	buildSpecHashAnnotation = "controller.devfile.io/image-build-hash"
+)
+
+var (
+	buildJobCompletions  = int32(1)
+	buildJobBackoffLimit = int32(0)
+)
+
+// BuildImages syncs a build job for each image component in a flattened DevWorkspace to the cluster and checks
+// whether all builds have completed.
+//
+// Returns NotReadyError if any image is still being built, ProvisioningError if an image component is invalid or its
+// build failed, or a generic error if an unexpected error is encountered.
+func BuildImages(workspace *dw.DevWorkspace, clusterAPI sync.ClusterAPI) error {
+	var pendingBuilds []string
+	for _, component := range workspace.Spec.Template.Components {
+		if component.Image == nil {
+			continue
+		}
+		specJob, err := getSpecBuildJob(workspace, component.Name, component.Image)
+		if err != nil {
+			return &ProvisioningError{
+				Err:     err,
+				Message: fmt.Sprintf("Invalid image component %s", component.Name),
+			}
+		}
+		if err := controllerutil.SetControllerReference(workspace, specJob, clusterAPI.Scheme); err != nil {
+			return err
+		}
+
+		clusterObj, err := sync.SyncObjectWithCluster(specJob, clusterAPI)
+		switch t := err.(type) {
+		case nil:
+			break
+		case *sync.NotInSyncError:
+			pendingBuilds = append(pendingBuilds, component.Name)
+			continue
+		case *sync.UnrecoverableSyncError:
+			return &ProvisioningError{
+				Err:     t.Cause,
+				Message: fmt.Sprintf("Failed to sync build job for image component %s", component.Name),
+			}
+		default:
+			return err
+		}
+
+		clusterJob := clusterObj.(*batchv1.Job)
+		complete, failed := getJobStatus(clusterJob)
+		switch {
+		case complete:
+			continue
+		case failed:
+			// Delete the failed job so that the image is rebuilt the next time the DevWorkspace is started.
+			err := clusterAPI.Client.Delete(clusterAPI.Ctx, clusterJob, client.PropagationPolicy(metav1.DeletePropagationBackground))
+			if err != nil && !k8sErrors.IsNotFound(err) {
+				return err
+			}
+			return &ProvisioningError{
+				Message: fmt.Sprintf("Failed to build image for component %s: see logs for job %q for details", component.Name, clusterJob.Name),
+			}
+		default:
+			pendingBuilds = append(pendingBuilds, component.Name)
+		}
+	}
+	if len(pendingBuilds) > 0 {
+		return &NotReadyError{
+			Message:      fmt.Sprintf("Waiting for images to be built for components %s", strings.Join(pendingBuilds, ", ")),
+			RequeueAfter: buildJobRequeueInterval,
+		}
+	}
+	return nil
+}
+
+func getJobStatus(job *batchv1.Job) (complete, failed bool) {
+	for _, condition := range job.Status.Conditions {
+		if condition.Status != corev1.ConditionTrue {
+			continue
+		}
+		switch condition.Type {
+		case batchv1.JobComplete:
+			return true, false
+		case batchv1.JobFailed:
+			return false, true
+		}
+	}
+	return false, false
+}
+
+func getSpecBuildJob(workspace *dw.DevWorkspace, componentName string, image *dw.ImageComponent) (*batchv1.Job, error) {
+	if image.Dockerfile == nil {
+		return nil, errors.New("only Dockerfile image components are supported")
+	}
+	builtImage, err := getBuiltImageName(workspace.Status.DevWorkspaceId, componentName, image)
+	if err != nil {
+		return nil, err
+	}
+	buildArgs, err := getBuildArgs(image.Dockerfile, workspace.Spec.Template.Projects)
+	if err != nil {
+		return nil, err
+	}
+	specHash, err := getBuildSpecHash(image)
+	if err != nil {
+		return nil, err
+	}
+
+	script := fmt.Sprintf("buildah bud %s --tag %s && buildah push %s", strings.Join(buildArgs, " "), shellQuote(builtImage), shellQuote(builtImage))
+
+	env := []corev1.EnvVar{
+		{Name: "STORAGE_DRIVER", Value: "vfs"},
+		{Name: "BUILDAH_ISOLATION", Value: "chroot"},
+	}
+	volumes := []corev1.Volume{
+		{
+			Name: buildContextVolumeName,
+			VolumeSource: corev1.VolumeSource{
+				EmptyDir: &corev1.EmptyDirVolumeSource{},
+			},
+		},
+	}
+	volumeMounts := []corev1.VolumeMount{
+		{
+			Name:      buildContextVolumeName,
+			MountPath: buildContextPath,
+		},
+	}
+	if pushSecret := config.Workspace.ImageBuild.PushSecret; pushSecret != "" {
+		volumes = append(volumes, corev1.Volume{
+			Name: pushSecretVolumeName,
+			VolumeSource: corev1.VolumeSource{
+				Secret: &corev1.SecretVolumeSource{
+					SecretName: pushSecret,
+				},
+			},
+		})
+		volumeMounts = append(volumeMounts, corev1.VolumeMount{
+			Name:      pushSecretVolumeName,
+			MountPath: pushSecretMountPath,
+			ReadOnly:  true,
+		})
+		env = append(env, corev1.EnvVar{
+			Name:  "REGISTRY_AUTH_FILE",
+			Value: path.Join(pushSecretMountPath, corev1.DockerConfigJsonKey),
+		})
+	}
+
+	securityContext := wsprovision.GetDevWorkspaceSecurityContext()
+	var containerSecurityContext *corev1.SecurityContext
+	if image.Dockerfile.RootRequired != nil && *image.Dockerfile.RootRequired {
+		if allowRoot := config.Workspace.ImageBuild.AllowRootBuilds; allowRoot == nil || !*allowRoot {
+			return nil, errors.New("image builds that require root are not allowed by the DevWorkspace Operator configuration")
+		}
+		privileged := true
+		rootUID := int64(0)
+		securityContext = &corev1.PodSecurityContext{}
+		containerSecurityContext = &corev1.SecurityContext{
+			Privileged: &privileged,
+			RunAsUser:  &rootUID,
+		}
+	}
+
+	jobLabels := map[string]string{
+		constants.DevWorkspaceIDLabel: workspace.Status.DevWorkspaceId,
+	}
+	if restrictedAccess, needsRestrictedAccess := workspace.Annotations[constants.DevWorkspaceRestrictedAccessAnnotation]; needsRestrictedAccess {
+		jobLabels[constants.DevWorkspaceRestrictedAccessAnnotation] = restrictedAccess
+	}
+	jobName := common.ImageBuildJobName(workspace.Status.DevWorkspaceId, componentName)
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      jobName,
+			Namespace: workspace.Namespace,
+			Labels:    jobLabels,
+		},
+		Spec: batchv1.JobSpec{
+			Completions:  &buildJobCompletions,
+			BackoffLimit: &buildJobBackoffLimit,
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Annotations: map[string]string{
+						buildSpecHashAnnotation: specHash,
+					},
+				},
+				Spec: corev1.PodSpec{
+					RestartPolicy:   "Never",
+					SecurityContext: securityContext,
+					Volumes:         volumes,
+					Containers: []corev1.Container{
+						{
+							Name:            "build",
+							Image:           images.GetImageBuilderImage(),
+							Command:         []string{"/bin/sh", "-c"},
+							Args:            []string{script},
+							Env:             env,
+							WorkingDir:      buildContextPath,
+							VolumeMounts:    volumeMounts,
+							SecurityContext: containerSecurityContext,
+						},
+					},
+				},
+			},
+		},
+	}
+	return job, nil
+}
+
+// getBuildArgs returns the arguments to 'buildah bud' (excluding the image tag) required to build a Dockerfile image
+// component. Dockerfiles loaded from a URI are built using the first project in the DevWorkspace as build context,
+// matching the $PROJECT_SOURCE directory that the URI and buildContext are relative to. All returned arguments are
+// quoted for use in a shell script.
+func getBuildArgs(dockerfile *dw.DockerfileImage, projects []dw.Project) ([]string, error) {
+	var args []string
+	for _, arg := range dockerfile.Args {
+		args = append(args, "--build-arg", shellQuote(arg))
+	}
+
+	switch {
+	case dockerfile.Git != nil:
+		context, err := getGitBuildContext(&dockerfile.Git.GitLikeProjectSource, dockerfile.BuildContext)
+		if err != nil {
+			return nil, err
+		}
+		if dockerfile.Git.FileLocation != "" {
+			args = append(args, "--file", shellQuote(dockerfile.Git.FileLocation))
+		}
+		args = append(args, shellQuote(context))
+	case dockerfile.Uri != "":
+		args = append(args, "--file", shellQuote(dockerfile.Uri))
+		if len(projects) == 0 {
+			if dockerfile.BuildContext != "" {
+				return nil, errors.New("buildContext for a Dockerfile URI requires a project to be defined in the DevWorkspace")
+			}
+			// No project sources to use as the context; build with an empty context directory
+			args = append(args, buildContextPath)
+			break
+		}
+		project := projects[0]
+		if project.Git == nil {
+			return nil, fmt.Errorf("project %s must use a git source to be used as build context for a Dockerfile URI", project.Name)
+		}
+		context, err := getGitBuildContext(&project.Git.GitLikeProjectSource, dockerfile.BuildContext)
+		if err != nil {
+			return nil, fmt.Errorf("failed to use project %s as build context: %w", project.Name, err)
+		}
+		args = append(args, shellQuote(context))
+	case dockerfile.DevfileRegistry != nil:
+		return nil, errors.New("Dockerfiles from a devfile registry are not supported")
+	default:
+		return nil, errors.New("Dockerfile source is not specified")
+	}
+	return args, nil
+}
+
+// getGitBuildContext returns a git repository build context for buildah, using the syntax <url>#<ref>:<subdirectory>
+func getGitBuildContext(source *dw.GitLikeProjectSource, buildContext string) (string, error) {
+	remote, err := getGitRemote(source)
+	if err != nil {
+		return "", err
+	}
+	context := remote
+	var revision string
+	if source.CheckoutFrom != nil {
+		revision = source.CheckoutFrom.Revision
+	}
+	if revision != "" || buildContext != "" {
+		context = fmt.Sprintf("%s#%s", context, revision)
+		if buildContext != "" {
+			context = fmt.Sprintf("%s:%s", context, buildContext)
+		}
+	}
+	return context, nil
+}
+
+func getGitRemote(source *dw.GitLikeProjectSource) (string, error) {
+	if source.CheckoutFrom != nil && source.CheckoutFrom.Remote != "" {
+		remote, ok := source.Remotes[source.CheckoutFrom.Remote]
+		if !ok {
+			return "", fmt.Errorf("remote %s is not defined", source.CheckoutFrom.Remote)
+		}
+		return remote, nil
+	}
+	if len(source.Remotes) != 1 {
+		return "", errors.New("checkoutFrom.remote must be specified when multiple git remotes are defined")
+	}
+	for _, remote := range source.Remotes {
+		return remote, nil
+	}
+	return "", errors.New("no git remotes are defined")
+}
+
+// getBuildSpecHash returns a hash of an image component and the configuration used to build it
+func getBuildSpecHash(image *dw.ImageComponent) (string, error) {
+	spec, err := json.Marshal(struct {
+		Image        *dw.ImageComponent `json:"image"`
+		BuilderImage string             `json:"builderImage"`
+	}{image, images.GetImageBuilderImage()})
+	if err != nil {
+		return "", err
+	}
+	hash := sha256.Sum256(spec)
+	return hex.EncodeToString(hash[:]), nil
+}
+
+// shellQuote quotes a string for use as a single argument in a POSIX shell script.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'"'"'`) + "'"
+}
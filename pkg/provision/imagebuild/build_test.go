@@ -0,0 +1,249 @@
+//
+// Copyright (c) 2019-2021 Red Hat, Inc.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package imagebuild
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	dw "github.com/devfile/api/v2/pkg/apis/workspaces/v1alpha2"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/yaml"
+
+	"github.com/devfile/devworkspace-operator/apis/controller/v1alpha1"
+	"github.com/devfile/devworkspace-operator/pkg/config"
+	"github.com/devfile/devworkspace-operator/pkg/infrastructure"
+)
+
+type testCase struct {
+	Name   string     `json:"name,omitempty"`
+	Input  testInput  `json:"input,omitempty"`
+	Output testOutput `json:"output,omitempty"`
+}
+
+type testInput struct {
+	DevWorkspaceID string                       `json:"devworkspaceId,omitempty"`
+	ImageBuild     *v1alpha1.ImageBuildConfig   `json:"imageBuild,omitempty"`
+	Workspace      *dw.DevWorkspaceTemplateSpec `json:"workspace,omitempty"`
+}
+
+type testOutput struct {
+	Workspace *dw.DevWorkspaceTemplateSpec `json:"workspace,omitempty"`
+	// BuildScripts maps image component names to the script run by their build job
+	BuildScripts map[string]string `json:"buildScripts,omitempty"`
+	ErrRegexp    *string           `json:"errRegexp,omitempty"`
+}
+
+func loadTestCaseOrPanic(t *testing.T, testFilepath string) testCase {
+	bytes, err := ioutil.ReadFile(testFilepath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var test testCase
+	if err := yaml.Unmarshal(bytes, &test); err != nil {
+		t.Fatal(err)
+	}
+	t.Log(fmt.Sprintf("Read file:\n%+v\n\n", test))
+	return test
+}
+
+func loadAllTestCasesOrPanic(t *testing.T, fromDir string) []testCase {
+	files, err := ioutil.ReadDir(fromDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var tests []testCase
+	for _, file := range files {
+		if file.IsDir() {
+			tests = append(tests, loadAllTestCasesOrPanic(t, filepath.Join(fromDir, file.Name()))...)
+		} else {
+			tests = append(tests, loadTestCaseOrPanic(t, filepath.Join(fromDir, file.Name())))
+		}
+	}
+	return tests
+}
+
+func TestImageBuild(t *testing.T) {
+	infrastructure.InitializeForTesting(infrastructure.Kubernetes)
+	tests := loadAllTestCasesOrPanic(t, "testdata")
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			config.SetConfigForTesting(&v1alpha1.OperatorConfiguration{
+				Workspace: &v1alpha1.WorkspaceConfig{
+					ImageBuild: tt.Input.ImageBuild,
+				},
+			})
+			workspace := &dw.DevWorkspace{}
+			workspace.Spec.Template = *tt.Input.Workspace
+			workspace.Status.DevWorkspaceId = tt.Input.DevWorkspaceID
+			workspace.Namespace = "test-namespace"
+
+			err := SubstituteBuiltImages(workspace.Status.DevWorkspaceId, &workspace.Spec.Template)
+			buildScripts := map[string]string{}
+			if err == nil {
+				for _, component := range workspace.Spec.Template.Components {
+					if component.Image == nil {
+						continue
+					}
+					job, jobErr := getSpecBuildJob(workspace, component.Name, component.Image)
+					if jobErr != nil {
+						err = jobErr
+						break
+					}
+					buildScripts[component.Name] = job.Spec.Template.Spec.Containers[0].Args[0]
+				}
+			}
+			if tt.Output.ErrRegexp != nil && assert.Error(t, err) {
+				assert.Regexp(t, *tt.Output.ErrRegexp, err.Error(), "Error message should match")
+			} else {
+				if !assert.NoError(t, err, "Should not return error") {
+					return
+				}
+				assert.Equal(t, tt.Output.Workspace, &workspace.Spec.Template, "Workspace should have built images substituted")
+				assert.Equal(t, tt.Output.BuildScripts, buildScripts, "Build scripts should match expected output")
+			}
+		})
+	}
+}
+
+func getTestImageBuildWorkspace() *dw.DevWorkspace {
+	workspace := &dw.DevWorkspace{}
+	workspace.Namespace = "test-namespace"
+	workspace.Status.DevWorkspaceId = "test-workspaceid"
+	return workspace
+}
+
+func TestRootRequiredBuildIsPrivileged(t *testing.T) {
+	infrastructure.InitializeForTesting(infrastructure.Kubernetes)
+	allowRoot := true
+	config.SetConfigForTesting(&v1alpha1.OperatorConfiguration{
+		Workspace: &v1alpha1.WorkspaceConfig{
+			ImageBuild: &v1alpha1.ImageBuildConfig{Registry: "registry.example.com", AllowRootBuilds: &allowRoot},
+		},
+	})
+	rootRequired := true
+	image := &dw.ImageComponent{
+		Image: dw.Image{
+			ImageName: "app",
+			ImageUnion: dw.ImageUnion{Dockerfile: &dw.DockerfileImage{
+				DockerfileSrc: dw.DockerfileSrc{Uri: "https://example.com/Dockerfile"},
+				Dockerfile:    dw.Dockerfile{RootRequired: &rootRequired},
+			}},
+		},
+	}
+	job, err := getSpecBuildJob(getTestImageBuildWorkspace(), "app-image", image)
+	if !assert.NoError(t, err) {
+		return
+	}
+	securityContext := job.Spec.Template.Spec.Containers[0].SecurityContext
+	if assert.NotNil(t, securityContext) {
+		assert.True(t, *securityContext.Privileged, "Root builds should run in privileged container")
+	}
+
+	rootRequired = false
+	job, err = getSpecBuildJob(getTestImageBuildWorkspace(), "app-image", image)
+	if assert.NoError(t, err) {
+		assert.Nil(t, job.Spec.Template.Spec.Containers[0].SecurityContext, "Non-root builds should not be privileged")
+	}
+}
+
+func TestBuildJobChangesWithImageComponent(t *testing.T) {
+	infrastructure.InitializeForTesting(infrastructure.Kubernetes)
+	config.SetConfigForTesting(&v1alpha1.OperatorConfiguration{
+		Workspace: &v1alpha1.WorkspaceConfig{
+			ImageBuild: &v1alpha1.ImageBuildConfig{Registry: "registry.example.com"},
+		},
+	})
+	image := &dw.ImageComponent{
+		Image: dw.Image{
+			ImageName: "app",
+			ImageUnion: dw.ImageUnion{Dockerfile: &dw.DockerfileImage{
+				DockerfileSrc: dw.DockerfileSrc{Uri: "https://example.com/Dockerfile"},
+			}},
+		},
+	}
+	getHash := func() string {
+		job, err := getSpecBuildJob(getTestImageBuildWorkspace(), "app-image", image)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return job.Spec.Template.Annotations[buildSpecHashAnnotation]
+	}
+	initialHash := getHash()
+	assert.NotEmpty(t, initialHash, "Build job should have spec hash annotation")
+	assert.Equal(t, initialHash, getHash(), "Spec hash should be stable")
+
+	image.Dockerfile.Args = []string{"VERSION=2.0"}
+	assert.NotEqual(t, initialHash, getHash(), "Spec hash should change when image component changes")
+}
+
+func TestBuiltImageName(t *testing.T) {
+	config.SetConfigForTesting(&v1alpha1.OperatorConfiguration{
+		Workspace: &v1alpha1.WorkspaceConfig{
+			ImageBuild: &v1alpha1.ImageBuildConfig{Registry: "registry.example.com"},
+		},
+	})
+	getImage := func(imageName string) *dw.ImageComponent {
+		return &dw.ImageComponent{
+			Image: dw.Image{
+				ImageName: imageName,
+				ImageUnion: dw.ImageUnion{Dockerfile: &dw.DockerfileImage{
+					DockerfileSrc: dw.DockerfileSrc{Uri: "https://example.com/Dockerfile"},
+				}},
+			},
+		}
+	}
+	frontendImage, backendImage := getImage("quay.io/frontend/app:latest"), getImage("quay.io/backend/app:latest")
+	frontend, err := getBuiltImageName("test-workspaceid", "frontend", frontendImage)
+	assert.NoError(t, err)
+	assert.Regexp(t, `^registry\.example\.com/app:test-workspaceid-frontend-[0-9a-f]{12}$`, frontend)
+	backend, err := getBuiltImageName("test-workspaceid", "backend", backendImage)
+	assert.NoError(t, err)
+	assert.NotEqual(t, frontend, backend, "Image components with the same image basename should be pushed to different tags")
+
+	frontendImage.Dockerfile.Args = []string{"VERSION=2.0"}
+	rebuilt, err := getBuiltImageName("test-workspaceid", "frontend", frontendImage)
+	assert.NoError(t, err)
+	assert.NotEqual(t, frontend, rebuilt, "Built image should change when image component changes")
+
+	workspace := &dw.DevWorkspaceTemplateSpec{}
+	workspace.Components = []dw.Component{
+		{Name: "frontend", ComponentUnion: dw.ComponentUnion{Image: getImage("app")}},
+		{Name: "backend", ComponentUnion: dw.ComponentUnion{Image: getImage("app")}},
+	}
+	err = SubstituteBuiltImages("test-workspaceid", workspace)
+	assert.Error(t, err, "Should not allow multiple image components with the same imageName")
+}
+
+func TestGetPullSecretPodAdditions(t *testing.T) {
+	config.SetConfigForTesting(&v1alpha1.OperatorConfiguration{
+		Workspace: &v1alpha1.WorkspaceConfig{
+			ImageBuild: &v1alpha1.ImageBuildConfig{Registry: "registry.example.com"},
+		},
+	})
+	assert.Empty(t, GetPullSecretPodAdditions().PullSecrets, "No pull secrets should be added if push secret is not configured")
+
+	config.SetConfigForTesting(&v1alpha1.OperatorConfiguration{
+		Workspace: &v1alpha1.WorkspaceConfig{
+			ImageBuild: &v1alpha1.ImageBuildConfig{Registry: "registry.example.com", PushSecret: "push-secret"},
+		},
+	})
+	assert.Equal(t, []corev1.LocalObjectReference{{Name: "push-secret"}}, GetPullSecretPodAdditions().PullSecrets,
+		"Push secret should be added as pull secret")
+}
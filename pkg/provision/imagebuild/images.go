@@ -0,0 +1,112 @@
+//
+// Copyright (c) 2019-2021 Red Hat, Inc.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package imagebuild
+
+import (
+	"fmt"
+	"strings"
+
+	dw "github.com/devfile/api/v2/pkg/apis/workspaces/v1alpha2"
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/devfile/devworkspace-operator/apis/controller/v1alpha1"
+	"github.com/devfile/devworkspace-operator/pkg/config"
+)
+
+// builtImageHashLength is the number of characters of the build spec hash included in the tag of built images
+const builtImageHashLength = 12
+
+// HasImageComponents returns whether the flattened DevWorkspace contains any image components.
+func HasImageComponents(workspace *dw.DevWorkspaceTemplateSpec) bool {
+	for _, component := range workspace.Components {
+		if component.Image != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// SubstituteBuiltImages replaces the image in any container component that references an image component (i.e.
+// where the container's image matches the image component's imageName) with the image that is built for that image
+// component. Returns an error if image builds are not configured or if multiple image components use the same
+// imageName.
+func SubstituteBuiltImages(workspaceId string, workspace *dw.DevWorkspaceTemplateSpec) error {
+	builtImages := map[string]string{}
+	for _, component := range workspace.Components {
+		if component.Image == nil {
+			continue
+		}
+		if _, exists := builtImages[component.Image.ImageName]; exists {
+			return fmt.Errorf("multiple image components use imageName %q", component.Image.ImageName)
+		}
+		builtImage, err := getBuiltImageName(workspaceId, component.Name, component.Image)
+		if err != nil {
+			return err
+		}
+		builtImages[component.Image.ImageName] = builtImage
+	}
+	for idx, component := range workspace.Components {
+		if component.Container == nil {
+			continue
+		}
+		if builtImage, ok := builtImages[component.Container.Image]; ok {
+			workspace.Components[idx].Container.Image = builtImage
+		}
+	}
+	return nil
+}
+
+// GetPullSecretPodAdditions returns pod additions that add the secret used to push built images, if one is configured,
+// to the image pull secrets of a DevWorkspace, allowing built images to be pulled from registries that require
+// authentication.
+func GetPullSecretPodAdditions() v1alpha1.PodAdditions {
+	if config.Workspace.ImageBuild == nil || config.Workspace.ImageBuild.PushSecret == "" {
+		return v1alpha1.PodAdditions{}
+	}
+	return v1alpha1.PodAdditions{
+		PullSecrets: []corev1.LocalObjectReference{{Name: config.Workspace.ImageBuild.PushSecret}},
+	}
+}
+
+// getBuiltImageName returns the image that an image component is pushed to, in the form
+// <registry>/<name>:<workspaceId>-<componentName>-<hash>, where <name> is the last path element of the component's
+// imageName without a tag or digest and <hash> is a prefix of the component's build spec hash. Including the hash
+// ensures that the workspace deployment is updated, and the new image is pulled, when an image is rebuilt.
+func getBuiltImageName(workspaceId, componentName string, image *dw.ImageComponent) (string, error) {
+	if config.Workspace.ImageBuild == nil || config.Workspace.ImageBuild.Registry == "" {
+		return "", fmt.Errorf("image components are not supported: a registry for built images is not configured")
+	}
+	imageName := image.ImageName
+	name := imageName
+	if idx := strings.LastIndex(name, "/"); idx >= 0 {
+		name = name[idx+1:]
+	}
+	if idx := strings.Index(name, "@"); idx >= 0 {
+		name = name[:idx]
+	}
+	if idx := strings.Index(name, ":"); idx >= 0 {
+		name = name[:idx]
+	}
+	if name == "" {
+		return "", fmt.Errorf("invalid imageName %q", imageName)
+	}
+	specHash, err := getBuildSpecHash(image)
+	if err != nil {
+		return "", err
+	}
+	registry := strings.TrimSuffix(config.Workspace.ImageBuild.Registry, "/")
+	return fmt.Sprintf("%s/%s:%s-%s-%s", registry, name, workspaceId, componentName, specHash[:builtImageHashLength]), nil
+}
@@ -0,0 +1,96 @@
+//
+// Copyright (c) 2019-2021 Red Hat, Inc.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+// Package kubecomponents handles provisioning the objects defined in Kubernetes and OpenShift components
+// in a DevWorkspace.
+//
+// TODO: Components referenced by apply commands are applied on start like any other component.
+package kubecomponents
+
+import (
+	"fmt"
+	"time"
+
+	dw "github.com/devfile/api/v2/pkg/apis/workspaces/v1alpha2"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	"github.com/devfile/devworkspace-operator/pkg/library/flatten/network"
+	"github.com/devfile/devworkspace-operator/pkg/provision/sync"
+)
+
+const objectsNotReadyRequeueInterval = 1 * time.Second
+
+// SyncKubernetesComponents reads the objects defined in Kubernetes and OpenShift components of a flattened
+// DevWorkspace and syncs them to the cluster in the DevWorkspace's namespace. Objects are labelled with the
+// DevWorkspace's ID and owned by the DevWorkspace, so that they are removed when the DevWorkspace is deleted. Objects
+// that were applied previously but are no longer defined in the DevWorkspace's components are deleted; applied objects
+// are recorded in an annotation on clusterWorkspace.
+//
+// Returns NotReadyError if objects were created or updated and the caller should check again later,
+// ProvisioningError if components cannot be processed or objects cannot be applied to the cluster, or a
+// generic error if an unexpected error is encountered.
+func SyncKubernetesComponents(workspace, clusterWorkspace *dw.DevWorkspace, httpClient network.HTTPGetter, api sync.ClusterAPI) error {
+	specObjects, err := getSpecObjects(workspace, httpClient)
+	if err != nil {
+		return &ProvisioningError{
+			Err:     err,
+			Message: "Failed to process Kubernetes components",
+		}
+	}
+	if err := pruneObjects(clusterWorkspace, specObjects, api); err != nil {
+		return err
+	}
+
+	var notReadyMessages []string
+	for _, specObj := range specObjects {
+		if err := controllerutil.SetControllerReference(workspace, specObj, api.Scheme); err != nil {
+			return &ProvisioningError{
+				Err:     err,
+				Message: fmt.Sprintf("Failed to set owner reference on %s %s", specObj.GetKind(), specObj.GetName()),
+			}
+		}
+		_, err := sync.SyncObjectWithCluster(specObj, api)
+		switch t := err.(type) {
+		case nil:
+			continue
+		case *sync.NotInSyncError:
+			notReadyMessages = append(notReadyMessages, fmt.Sprintf("%s %s", specObj.GetKind(), specObj.GetName()))
+		case *sync.UnrecoverableSyncError:
+			return &ProvisioningError{
+				Err:     t.Cause,
+				Message: fmt.Sprintf("Failed to sync %s %s with cluster", specObj.GetKind(), specObj.GetName()),
+			}
+		default:
+			if k8sErrors.IsForbidden(err) || meta.IsNoMatchError(err) {
+				// Either the object's kind is not available on the cluster or the controller's role does not
+				// allow managing it; retrying will not help.
+				return &ProvisioningError{
+					Err:     err,
+					Message: fmt.Sprintf("Failed to sync %s %s with cluster", specObj.GetKind(), specObj.GetName()),
+				}
+			}
+			return err
+		}
+	}
+	if len(notReadyMessages) > 0 {
+		return &NotReadyError{
+			Message:      fmt.Sprintf("Waiting for objects from Kubernetes components to be ready: %v", notReadyMessages),
+			RequeueAfter: objectsNotReadyRequeueInterval,
+		}
+	}
+	return nil
+}
@@ -0,0 +1,130 @@
+//
+// Copyright (c) 2019-2021 Red Hat, Inc.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package kubecomponents
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"testing"
+
+	dw "github.com/devfile/api/v2/pkg/apis/workspaces/v1alpha2"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+)
+
+type testCase struct {
+	Name   string     `json:"name,omitempty"`
+	Input  testInput  `json:"input,omitempty"`
+	Output testOutput `json:"output,omitempty"`
+}
+
+type testInput struct {
+	DevWorkspaceID string                       `json:"devworkspaceId,omitempty"`
+	Workspace      *dw.DevWorkspaceTemplateSpec `json:"workspace,omitempty"`
+	// URIContent maps URIs to the content returned when they are fetched
+	URIContent map[string]string `json:"uriContent,omitempty"`
+}
+
+type testOutput struct {
+	Objects   []map[string]interface{} `json:"objects,omitempty"`
+	ErrRegexp *string                  `json:"errRegexp,omitempty"`
+}
+
+type fakeHTTPGetter struct {
+	content map[string]string
+}
+
+func (f *fakeHTTPGetter) Get(location string) (*http.Response, error) {
+	content, ok := f.content[location]
+	if !ok {
+		return &http.Response{
+			StatusCode: http.StatusNotFound,
+			Body:       ioutil.NopCloser(bytes.NewBuffer([]byte{})),
+		}, nil
+	}
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Body:       ioutil.NopCloser(bytes.NewBufferString(content)),
+	}, nil
+}
+
+func loadTestCaseOrPanic(t *testing.T, testFilepath string) testCase {
+	bytes, err := ioutil.ReadFile(testFilepath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var test testCase
+	if err := yaml.Unmarshal(bytes, &test); err != nil {
+		t.Fatal(err)
+	}
+	t.Log(fmt.Sprintf("Read file:\n%+v\n\n", test))
+	return test
+}
+
+func loadAllTestCasesOrPanic(t *testing.T, fromDir string) []testCase {
+	files, err := ioutil.ReadDir(fromDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var tests []testCase
+	for _, file := range files {
+		if file.IsDir() {
+			tests = append(tests, loadAllTestCasesOrPanic(t, filepath.Join(fromDir, file.Name()))...)
+		} else {
+			tests = append(tests, loadTestCaseOrPanic(t, filepath.Join(fromDir, file.Name())))
+		}
+	}
+	return tests
+}
+
+func TestGetSpecObjects(t *testing.T) {
+	tests := loadAllTestCasesOrPanic(t, "testdata")
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			workspace := &dw.DevWorkspace{}
+			workspace.Spec.Template = *tt.Input.Workspace
+			workspace.Status.DevWorkspaceId = tt.Input.DevWorkspaceID
+			workspace.Namespace = "test-namespace"
+			objects, err := getSpecObjects(workspace, &fakeHTTPGetter{content: tt.Input.URIContent})
+			if tt.Output.ErrRegexp != nil && assert.Error(t, err) {
+				assert.Regexp(t, *tt.Output.ErrRegexp, err.Error(), "Error message should match")
+			} else {
+				if !assert.NoError(t, err, "Should not return error") {
+					return
+				}
+				var expected []*unstructured.Unstructured
+				for _, obj := range tt.Output.Objects {
+					// Round-trip through json to get the same number types as returned by getSpecObjects
+					jsonBytes, err := json.Marshal(obj)
+					if !assert.NoError(t, err) {
+						return
+					}
+					expectedObj := &unstructured.Unstructured{}
+					if !assert.NoError(t, expectedObj.UnmarshalJSON(jsonBytes)) {
+						return
+					}
+					expected = append(expected, expectedObj)
+				}
+				assert.Equal(t, expected, objects, "Objects should match expected output")
+			}
+		})
+	}
+}
@@ -0,0 +1,193 @@
+//
+// Copyright (c) 2019-2021 Red Hat, Inc.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package kubecomponents
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	dw "github.com/devfile/api/v2/pkg/apis/workspaces/v1alpha2"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8syaml "k8s.io/apimachinery/pkg/util/yaml"
+
+	"github.com/devfile/devworkspace-operator/pkg/constants"
+	"github.com/devfile/devworkspace-operator/pkg/library/flatten/network"
+)
+
+// contentFetchTimeout is the maximum duration of a request for the content of a Kubernetes or OpenShift component
+// specified via URI. Content is fetched within a reconcile, so requests must not be allowed to block indefinitely.
+const contentFetchTimeout = 10 * time.Second
+
+// contextHTTPGetter fetches component content with a timeout, cancelling requests when its context is done.
+type contextHTTPGetter struct {
+	ctx    context.Context
+	client *http.Client
+}
+
+var _ network.HTTPGetter = (*contextHTTPGetter)(nil)
+
+// NewHTTPGetter returns a network.HTTPGetter for fetching the content of Kubernetes and OpenShift components. Requests
+// time out after contentFetchTimeout and are cancelled if ctx is done.
+func NewHTTPGetter(ctx context.Context) network.HTTPGetter {
+	return &contextHTTPGetter{
+		ctx:    ctx,
+		client: &http.Client{Timeout: contentFetchTimeout},
+	}
+}
+
+func (g *contextHTTPGetter) Get(location string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(g.ctx, http.MethodGet, location, nil)
+	if err != nil {
+		return nil, err
+	}
+	return g.client.Do(req)
+}
+
+// HasKubernetesComponents returns whether the flattened DevWorkspace contains any Kubernetes or OpenShift
+// components.
+func HasKubernetesComponents(workspace *dw.DevWorkspaceTemplateSpec) bool {
+	for _, component := range workspace.Components {
+		if component.Kubernetes != nil || component.Openshift != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// getSpecObjects reads the objects defined in all Kubernetes and OpenShift components in a DevWorkspace, either from
+// inlined content or by fetching the component's URI. All returned objects are set to the DevWorkspace's namespace
+// and are labelled with the DevWorkspace's ID. An error is returned if any object cannot be parsed or if it specifies
+// a namespace other than the DevWorkspace's namespace.
+func getSpecObjects(workspace *dw.DevWorkspace, httpClient network.HTTPGetter) ([]*unstructured.Unstructured, error) {
+	var objects []*unstructured.Unstructured
+	for _, component := range workspace.Spec.Template.Components {
+		var location *dw.K8sLikeComponentLocation
+		switch {
+		case component.Kubernetes != nil:
+			location = &component.Kubernetes.K8sLikeComponentLocation
+		case component.Openshift != nil:
+			location = &component.Openshift.K8sLikeComponentLocation
+		default:
+			continue
+		}
+		content, err := getComponentContent(location, httpClient)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read content for component %s: %w", component.Name, err)
+		}
+		componentObjects, err := parseObjects(content)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse content for component %s: %w", component.Name, err)
+		}
+		for _, obj := range componentObjects {
+			if err := prepareObject(obj, workspace); err != nil {
+				return nil, fmt.Errorf("invalid object in component %s: %w", component.Name, err)
+			}
+		}
+		objects = append(objects, componentObjects...)
+	}
+	return objects, nil
+}
+
+func getComponentContent(location *dw.K8sLikeComponentLocation, httpClient network.HTTPGetter) ([]byte, error) {
+	switch {
+	case location.Inlined != "":
+		return []byte(location.Inlined), nil
+	case location.Uri != "":
+		if httpClient == nil {
+			return nil, fmt.Errorf("cannot fetch content from %s: HTTP client not provided", location.Uri)
+		}
+		resp, err := httpClient.Get(location.Uri)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch content from %s: %w", location.Uri, err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("could not fetch content from %s: got status %d", location.Uri, resp.StatusCode)
+		}
+		content, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			return nil, fmt.Errorf("could not read data from %s: %w", location.Uri, err)
+		}
+		return content, nil
+	default:
+		return nil, errors.New("component does not define inlined content or a URI")
+	}
+}
+
+// parseObjects reads a (potentially multi-document) yaml or json manifest into a list of objects. Objects of
+// kind 'List' are expanded into their items.
+func parseObjects(content []byte) ([]*unstructured.Unstructured, error) {
+	var objects []*unstructured.Unstructured
+	decoder := k8syaml.NewYAMLOrJSONDecoder(bytes.NewReader(content), 4096)
+	for {
+		var raw json.RawMessage
+		if err := decoder.Decode(&raw); err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, err
+		}
+		if len(raw) == 0 || string(raw) == "null" {
+			// Empty yaml document, e.g. due to a leading '---'
+			continue
+		}
+		obj := &unstructured.Unstructured{}
+		if err := obj.UnmarshalJSON(raw); err != nil {
+			return nil, err
+		}
+		if obj.GetAPIVersion() == "" {
+			return nil, fmt.Errorf("%s object does not specify an apiVersion", obj.GetKind())
+		}
+		if obj.IsList() {
+			err := obj.EachListItem(func(item runtime.Object) error {
+				objects = append(objects, item.(*unstructured.Unstructured))
+				return nil
+			})
+			if err != nil {
+				return nil, err
+			}
+			continue
+		}
+		objects = append(objects, obj)
+	}
+	return objects, nil
+}
+
+func prepareObject(obj *unstructured.Unstructured, workspace *dw.DevWorkspace) error {
+	if obj.GetName() == "" {
+		return fmt.Errorf("%s object does not specify a name", obj.GetKind())
+	}
+	if obj.GetNamespace() != "" && obj.GetNamespace() != workspace.Namespace {
+		return fmt.Errorf("%s %s specifies namespace %s; objects can only be created in the DevWorkspace's namespace",
+			obj.GetKind(), obj.GetName(), obj.GetNamespace())
+	}
+	obj.SetNamespace(workspace.Namespace)
+	labels := obj.GetLabels()
+	if labels == nil {
+		labels = map[string]string{}
+	}
+	labels[constants.DevWorkspaceIDLabel] = workspace.Status.DevWorkspaceId
+	obj.SetLabels(labels)
+	return nil
+}
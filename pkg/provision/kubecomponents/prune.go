@@ -0,0 +1,144 @@
+//
+// Copyright (c) 2019-2021 Red Hat, Inc.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package kubecomponents
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	dw "github.com/devfile/api/v2/pkg/apis/workspaces/v1alpha2"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/devfile/devworkspace-operator/pkg/constants"
+	"github.com/devfile/devworkspace-operator/pkg/provision/sync"
+)
+
+// appliedObject identifies an object created on the cluster from a Kubernetes or OpenShift component
+type appliedObject struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Name       string `json:"name"`
+}
+
+// HasAppliedObjects returns whether objects from Kubernetes or OpenShift components have been applied to the cluster
+// for a DevWorkspace, as recorded in the DevWorkspaceKubeComponentObjectsAnnotation annotation.
+func HasAppliedObjects(workspace *dw.DevWorkspace) bool {
+	_, ok := workspace.Annotations[constants.DevWorkspaceKubeComponentObjectsAnnotation]
+	return ok
+}
+
+// pruneObjects deletes objects that were previously applied for clusterWorkspace, as recorded in the
+// DevWorkspaceKubeComponentObjectsAnnotation annotation, but are no longer in specObjects. Only objects controlled by
+// the DevWorkspace are deleted. Once stale objects are deleted, the annotation is updated to record specObjects.
+func pruneObjects(clusterWorkspace *dw.DevWorkspace, specObjects []*unstructured.Unstructured, api sync.ClusterAPI) error {
+	var previous []appliedObject
+	if annotation, ok := clusterWorkspace.Annotations[constants.DevWorkspaceKubeComponentObjectsAnnotation]; ok {
+		if err := json.Unmarshal([]byte(annotation), &previous); err != nil {
+			// Annotation is managed by the controller; if it's invalid, overwrite it
+			api.Logger.Info("Ignoring invalid annotation on DevWorkspace", "annotation", constants.DevWorkspaceKubeComponentObjectsAnnotation)
+			previous = nil
+		}
+	}
+	current := getAppliedObjects(specObjects)
+
+	for _, stale := range getStaleObjects(previous, current) {
+		clusterObj := &unstructured.Unstructured{}
+		clusterObj.SetAPIVersion(stale.APIVersion)
+		clusterObj.SetKind(stale.Kind)
+		err := api.Client.Get(api.Ctx, types.NamespacedName{Name: stale.Name, Namespace: clusterWorkspace.Namespace}, clusterObj)
+		if err != nil {
+			if k8sErrors.IsNotFound(err) || meta.IsNoMatchError(err) {
+				continue
+			}
+			return err
+		}
+		if !metav1.IsControlledBy(clusterObj, clusterWorkspace) {
+			continue
+		}
+		if err := api.Client.Delete(api.Ctx, clusterObj); err != nil && !k8sErrors.IsNotFound(err) {
+			return fmt.Errorf("failed to delete %s %s: %w", stale.Kind, stale.Name, err)
+		}
+		api.Logger.Info("Deleted object removed from Kubernetes components", "kind", stale.Kind, "name", stale.Name)
+	}
+
+	return updateAppliedObjectsAnnotation(clusterWorkspace, current, api)
+}
+
+func updateAppliedObjectsAnnotation(clusterWorkspace *dw.DevWorkspace, current []appliedObject, api sync.ClusterAPI) error {
+	patch := client.MergeFrom(clusterWorkspace.DeepCopy())
+	if len(current) == 0 {
+		if !HasAppliedObjects(clusterWorkspace) {
+			return nil
+		}
+		delete(clusterWorkspace.Annotations, constants.DevWorkspaceKubeComponentObjectsAnnotation)
+		return api.Client.Patch(api.Ctx, clusterWorkspace, patch)
+	}
+	value, err := json.Marshal(current)
+	if err != nil {
+		return err
+	}
+	if clusterWorkspace.Annotations[constants.DevWorkspaceKubeComponentObjectsAnnotation] == string(value) {
+		return nil
+	}
+	if clusterWorkspace.Annotations == nil {
+		clusterWorkspace.Annotations = map[string]string{}
+	}
+	clusterWorkspace.Annotations[constants.DevWorkspaceKubeComponentObjectsAnnotation] = string(value)
+	return api.Client.Patch(api.Ctx, clusterWorkspace, patch)
+}
+
+// getAppliedObjects returns references to specObjects, sorted so that the recorded annotation is stable
+func getAppliedObjects(specObjects []*unstructured.Unstructured) []appliedObject {
+	var objects []appliedObject
+	for _, obj := range specObjects {
+		objects = append(objects, appliedObject{
+			APIVersion: obj.GetAPIVersion(),
+			Kind:       obj.GetKind(),
+			Name:       obj.GetName(),
+		})
+	}
+	sort.Slice(objects, func(i, j int) bool {
+		if objects[i].APIVersion != objects[j].APIVersion {
+			return objects[i].APIVersion < objects[j].APIVersion
+		}
+		if objects[i].Kind != objects[j].Kind {
+			return objects[i].Kind < objects[j].Kind
+		}
+		return objects[i].Name < objects[j].Name
+	})
+	return objects
+}
+
+// getStaleObjects returns the objects in previous that are not in current
+func getStaleObjects(previous, current []appliedObject) []appliedObject {
+	currentSet := map[appliedObject]bool{}
+	for _, obj := range current {
+		currentSet[obj] = true
+	}
+	var stale []appliedObject
+	for _, obj := range previous {
+		if !currentSet[obj] {
+			stale = append(stale, obj)
+		}
+	}
+	return stale
+}
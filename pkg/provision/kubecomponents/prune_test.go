@@ -0,0 +1,116 @@
+//
+// Copyright (c) 2019-2021 Red Hat, Inc.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package kubecomponents
+
+import (
+	"context"
+	"testing"
+
+	dw "github.com/devfile/api/v2/pkg/apis/workspaces/v1alpha2"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+
+	"github.com/devfile/devworkspace-operator/pkg/constants"
+	"github.com/devfile/devworkspace-operator/pkg/provision/sync"
+)
+
+func TestGetStaleObjects(t *testing.T) {
+	kept := appliedObject{APIVersion: "v1", Kind: "ConfigMap", Name: "kept"}
+	removed := appliedObject{APIVersion: "v1", Kind: "ConfigMap", Name: "removed"}
+	changedKind := appliedObject{APIVersion: "v1", Kind: "Secret", Name: "kept"}
+
+	stale := getStaleObjects([]appliedObject{kept, removed, changedKind}, []appliedObject{kept})
+	assert.Equal(t, []appliedObject{removed, changedKind}, stale, "Objects no longer applied should be stale")
+	assert.Empty(t, getStaleObjects(nil, []appliedObject{kept}), "No objects should be stale if none were applied")
+}
+
+func TestPruneObjects(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	if err := dw.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	workspace := &dw.DevWorkspace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-workspace",
+			Namespace: "test-namespace",
+			UID:       "test-uid",
+			Annotations: map[string]string{
+				constants.DevWorkspaceKubeComponentObjectsAnnotation: `[{"apiVersion":"v1","kind":"ConfigMap","name":"kept"},` +
+					`{"apiVersion":"v1","kind":"ConfigMap","name":"removed"},` +
+					`{"apiVersion":"v1","kind":"ConfigMap","name":"not-owned"}]`,
+			},
+		},
+	}
+	getConfigMap := func(name string, owned bool) *corev1.ConfigMap {
+		cm := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "test-namespace"}}
+		if owned {
+			if err := controllerutil.SetControllerReference(workspace, cm, scheme); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return cm
+	}
+	api := sync.ClusterAPI{
+		Ctx: context.Background(),
+		Client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+			workspace, getConfigMap("kept", true), getConfigMap("removed", true), getConfigMap("not-owned", false)).Build(),
+		Scheme: scheme,
+		Logger: zap.New(),
+	}
+	specObj := &unstructured.Unstructured{}
+	specObj.SetAPIVersion("v1")
+	specObj.SetKind("ConfigMap")
+	specObj.SetName("kept")
+
+	if !assert.NoError(t, pruneObjects(workspace, []*unstructured.Unstructured{specObj}, api)) {
+		return
+	}
+
+	getErr := func(name string) error {
+		return api.Client.Get(api.Ctx, types.NamespacedName{Name: name, Namespace: "test-namespace"}, &corev1.ConfigMap{})
+	}
+	assert.NoError(t, getErr("kept"), "Object still defined in components should not be deleted")
+	assert.True(t, k8sErrors.IsNotFound(getErr("removed")), "Object removed from components should be deleted")
+	assert.NoError(t, getErr("not-owned"), "Object not owned by the DevWorkspace should not be deleted")
+
+	clusterWorkspace := &dw.DevWorkspace{}
+	if !assert.NoError(t, api.Client.Get(api.Ctx, client.ObjectKeyFromObject(workspace), clusterWorkspace)) {
+		return
+	}
+	assert.Equal(t, `[{"apiVersion":"v1","kind":"ConfigMap","name":"kept"}]`,
+		clusterWorkspace.Annotations[constants.DevWorkspaceKubeComponentObjectsAnnotation],
+		"Annotation should record only objects currently defined in components")
+
+	// Removing all components removes the annotation
+	if !assert.NoError(t, pruneObjects(clusterWorkspace, nil, api)) {
+		return
+	}
+	assert.True(t, k8sErrors.IsNotFound(getErr("kept")), "Object removed from components should be deleted")
+	assert.False(t, HasAppliedObjects(clusterWorkspace), "Annotation should be removed when no objects are applied")
+}
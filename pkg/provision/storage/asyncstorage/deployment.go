@@ -152,3 +152,21 @@ func GetWorkspaceSyncDeploymentCluster(namespace string, clusterAPI sync.Cluster
 	err := clusterAPI.Client.Get(clusterAPI.Ctx, namespacedName, deploy)
 	return deploy, err
 }
+
+// GetAsyncServerAffinity returns a pod affinity that schedules a pod on the same node as the async storage server. This
+// allows pods to mount the common PVC while it is in use by the async storage server when the PVC's access mode is
+// ReadWriteOnce.
+func GetAsyncServerAffinity() *corev1.Affinity {
+	return &corev1.Affinity{
+		PodAffinity: &corev1.PodAffinity{
+			RequiredDuringSchedulingIgnoredDuringExecution: []corev1.PodAffinityTerm{
+				{
+					LabelSelector: &metav1.LabelSelector{
+						MatchLabels: asyncServerLabels,
+					},
+					TopologyKey: "kubernetes.io/hostname",
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,366 @@
+//
+// Copyright (c) 2019-2021 Red Hat, Inc.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"sort"
+	"time"
+
+	dw "github.com/devfile/api/v2/pkg/apis/workspaces/v1alpha2"
+	corev1 "k8s.io/api/core/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	"github.com/devfile/devworkspace-operator/pkg/common"
+	"github.com/devfile/devworkspace-operator/pkg/config"
+	"github.com/devfile/devworkspace-operator/pkg/constants"
+	"github.com/devfile/devworkspace-operator/pkg/provision/sync"
+)
+
+// deletionBackupSuffix is the suffix of the name of the VolumeSnapshot taken before a workspace's storage is cleaned up
+const deletionBackupSuffix = "deleted"
+
+var volumeSnapshotGVK = schema.GroupVersionKind{
+	Group:   "snapshot.storage.k8s.io",
+	Version: "v1",
+	Kind:    "VolumeSnapshot",
+}
+
+// BackupWorkspaceStorage creates a VolumeSnapshot named snapshotName of the PVC that stores a DevWorkspace's data.
+// The VolumeSnapshot is not owned by the DevWorkspace, so that it can be used to restore the DevWorkspace's data
+// after the DevWorkspace is deleted. For storage types that use the common PVC, the snapshot contains the data of all
+// workspaces in the namespace, and only the DevWorkspace's subpath is used when restoring.
+//
+// If a maximum number of backups is configured, the DevWorkspace's oldest VolumeSnapshots beyond that number are
+// deleted once a new VolumeSnapshot is created. Failing to delete old VolumeSnapshots is logged but not returned.
+//
+// Returns the VolumeSnapshot on the cluster if it was created or already exists, or nil if the DevWorkspace has no
+// persistent data to back up. Returns ProvisioningError if the VolumeSnapshot API is not available on the cluster.
+func BackupWorkspaceStorage(workspace *dw.DevWorkspace, snapshotName string, clusterAPI sync.ClusterAPI) (*unstructured.Unstructured, error) {
+	storageType := getProvisionedStorageType(workspace)
+	pvcName, err := getWorkspacePVCName(workspace.Status.DevWorkspaceId, storageType)
+	if err != nil {
+		return nil, &ProvisioningError{Message: "Cannot back up DevWorkspace storage", Err: err}
+	}
+	if pvcName == "" {
+		return nil, nil
+	}
+	exists, err := pvcExists(pvcName, workspace.Namespace, clusterAPI)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, nil
+	}
+
+	snapshot := getSpecVolumeSnapshot(workspace, snapshotName, pvcName, storageType)
+	err = clusterAPI.Client.Create(clusterAPI.Ctx, snapshot)
+	switch {
+	case err == nil:
+		clusterAPI.Logger.Info("Created object", "kind", "VolumeSnapshot", "name", snapshotName)
+		if config.Workspace.Backup != nil && config.Workspace.Backup.MaxBackups != nil {
+			if err := pruneWorkspaceBackups(workspace, *config.Workspace.Backup.MaxBackups, clusterAPI); err != nil {
+				clusterAPI.Logger.Error(err, "Failed to delete old DevWorkspace backups")
+			}
+		}
+		return snapshot, nil
+	case k8sErrors.IsAlreadyExists(err):
+		return getVolumeSnapshot(snapshotName, workspace.Namespace, clusterAPI)
+	case meta.IsNoMatchError(err):
+		return nil, &ProvisioningError{Message: "Cannot back up DevWorkspace storage: VolumeSnapshot API is not available on the cluster"}
+	default:
+		return nil, err
+	}
+}
+
+// BackupWorkspaceStorageForDeletion creates a VolumeSnapshot of a DevWorkspace's storage before it is cleaned up on
+// deletion, and waits for the snapshot to be ready to use.
+//
+// Returns nil once the snapshot is ready (or if there is no persistent data to back up), NotReadyError if the snapshot
+// is not yet ready, ProvisioningError if the snapshot cannot be created, and any other error if an unexpected problem
+// arises.
+func BackupWorkspaceStorageForDeletion(workspace *dw.DevWorkspace, clusterAPI sync.ClusterAPI) error {
+	snapshotName := common.WorkspaceBackupName(workspace.Status.DevWorkspaceId, deletionBackupSuffix)
+	snapshot, err := BackupWorkspaceStorage(workspace, snapshotName, clusterAPI)
+	if err != nil || snapshot == nil {
+		return err
+	}
+	ready, err := checkVolumeSnapshotReady(snapshot)
+	if err != nil {
+		return &ProvisioningError{Message: fmt.Sprintf("Failed to back up DevWorkspace storage to VolumeSnapshot %s", snapshotName), Err: err}
+	}
+	if !ready {
+		return &NotReadyError{
+			Message:      fmt.Sprintf("Waiting for VolumeSnapshot %s to be ready", snapshotName),
+			RequeueAfter: 5 * time.Second,
+		}
+	}
+	return nil
+}
+
+// RestoreWorkspaceStorage restores a DevWorkspace's data from a VolumeSnapshot created by BackupWorkspaceStorage. The
+// snapshot is restored to a temporary PVC, from which a Job copies the backed-up workspace's data to the location used
+// by the DevWorkspace's current storage type. The snapshot may have been taken of a different DevWorkspace and with a
+// different storage type.
+//
+// Returns nil once data is restored, NotReadyError if restoring is in progress, ProvisioningError if the snapshot
+// cannot be restored, and any other error if an unexpected problem arises.
+func RestoreWorkspaceStorage(workspace *dw.DevWorkspace, snapshotName string, clusterAPI sync.ClusterAPI) error {
+	workspaceId := workspace.Status.DevWorkspaceId
+	snapshot, err := getVolumeSnapshot(snapshotName, workspace.Namespace, clusterAPI)
+	if err != nil {
+		if k8sErrors.IsNotFound(err) || meta.IsNoMatchError(err) {
+			return &ProvisioningError{Message: fmt.Sprintf("VolumeSnapshot %s not found", snapshotName)}
+		}
+		return err
+	}
+	ready, err := checkVolumeSnapshotReady(snapshot)
+	if err != nil {
+		return &ProvisioningError{Message: fmt.Sprintf("VolumeSnapshot %s cannot be used", snapshotName), Err: err}
+	}
+	if !ready {
+		return &NotReadyError{
+			Message:      fmt.Sprintf("Waiting for VolumeSnapshot %s to be ready", snapshotName),
+			RequeueAfter: 5 * time.Second,
+		}
+	}
+
+	sourceWorkspaceId := snapshot.GetLabels()[constants.DevWorkspaceIDLabel]
+	sourceLayout, err := getStorageLayout(snapshot.GetAnnotations()[constants.DevWorkspaceStorageTypeAnnotation])
+	if err != nil || sourceWorkspaceId == "" || sourceLayout == noLayout {
+		return &ProvisioningError{Message: fmt.Sprintf("VolumeSnapshot %s is not a backup of DevWorkspace storage", snapshotName)}
+	}
+	sourcePath := copyJobSourceMountPath
+	if sourceLayout == commonPVCLayout {
+		sourcePath = path.Join(copyJobSourceMountPath, sourceWorkspaceId)
+	}
+
+	var targetPVCName, targetPath string
+	targetLayout, err := getStorageLayout(GetStorageType(workspace))
+	if err != nil {
+		return &ProvisioningError{Message: "Cannot restore DevWorkspace storage", Err: err}
+	}
+	switch targetLayout {
+	case commonPVCLayout:
+		if _, err := syncCommonPVC(workspace.Namespace, clusterAPI); err != nil {
+			return err
+		}
+		targetPVCName, targetPath = config.Workspace.PVCName, path.Join(copyJobTargetMountPath, workspaceId)
+	case perWorkspacePVCLayout:
+		targetPVCName = common.PerWorkspacePVCName(workspaceId)
+		if _, err := syncPerWorkspacePVC(targetPVCName, workspace, clusterAPI); err != nil {
+			return err
+		}
+		targetPath = copyJobTargetMountPath
+	default:
+		return &ProvisioningError{Message: "Cannot restore DevWorkspace storage: DevWorkspace does not use persistent storage"}
+	}
+
+	restorePVC, err := getSpecRestorePVC(workspace, snapshot, clusterAPI)
+	if err != nil {
+		return err
+	}
+	if _, err := sync.SyncObjectWithCluster(restorePVC, clusterAPI); err != nil {
+		switch t := err.(type) {
+		case *sync.NotInSyncError:
+			return &NotReadyError{Message: fmt.Sprintf("Restoring VolumeSnapshot %s to PVC", snapshotName)}
+		case *sync.UnrecoverableSyncError:
+			return &ProvisioningError{Message: "Failed to sync PVC to cluster", Err: t.Cause}
+		default:
+			return err
+		}
+	}
+
+	specJob, err := getSpecCopyJob(workspace, common.StorageRestoreName(workspaceId), restorePVC.Name, sourcePath, targetPVCName, targetPath, false, clusterAPI)
+	if err != nil {
+		return err
+	}
+	clusterJob, err := runCopyJob(specJob, "storage restore", clusterAPI)
+	if err != nil {
+		return err
+	}
+	if err := deleteJob(clusterJob, clusterAPI); err != nil {
+		return err
+	}
+	return deletePVC(restorePVC.Name, restorePVC.Namespace, clusterAPI)
+}
+
+// pruneWorkspaceBackups deletes the oldest VolumeSnapshots of a DevWorkspace, as determined by the DevWorkspace ID
+// label, so that at most maxBackups remain. The VolumeSnapshot taken when the DevWorkspace is deleted and the
+// VolumeSnapshot the DevWorkspace is being restored from are never deleted and do not count towards maxBackups.
+func pruneWorkspaceBackups(workspace *dw.DevWorkspace, maxBackups int, clusterAPI sync.ClusterAPI) error {
+	snapshots := &unstructured.UnstructuredList{}
+	snapshots.SetGroupVersionKind(volumeSnapshotGVK.GroupVersion().WithKind(volumeSnapshotGVK.Kind + "List"))
+	err := clusterAPI.Client.List(clusterAPI.Ctx, snapshots,
+		client.InNamespace(workspace.Namespace),
+		client.MatchingLabels{constants.DevWorkspaceIDLabel: workspace.Status.DevWorkspaceId})
+	if err != nil {
+		return err
+	}
+
+	deletionBackupName := common.WorkspaceBackupName(workspace.Status.DevWorkspaceId, deletionBackupSuffix)
+	var backups []unstructured.Unstructured
+	for _, snapshot := range snapshots.Items {
+		name := snapshot.GetName()
+		if name == deletionBackupName || name == workspace.Annotations[constants.DevWorkspaceRestoreFromAnnotation] {
+			continue
+		}
+		backups = append(backups, snapshot)
+	}
+	if len(backups) <= maxBackups {
+		return nil
+	}
+
+	// Backup names include the time they were taken, so they are used to order backups created in the same second.
+	sort.Slice(backups, func(i, j int) bool {
+		iCreated, jCreated := backups[i].GetCreationTimestamp(), backups[j].GetCreationTimestamp()
+		if !iCreated.Equal(&jCreated) {
+			return iCreated.Before(&jCreated)
+		}
+		return backups[i].GetName() < backups[j].GetName()
+	})
+	for idx := range backups[:len(backups)-maxBackups] {
+		snapshot := &backups[idx]
+		if err := clusterAPI.Client.Delete(clusterAPI.Ctx, snapshot); err != nil && !k8sErrors.IsNotFound(err) {
+			return err
+		}
+		clusterAPI.Logger.Info("Deleted object", "kind", "VolumeSnapshot", "name", snapshot.GetName())
+	}
+	return nil
+}
+
+// getProvisionedStorageType returns the storage type storage was last provisioned for, falling back to the storage type
+// defined in the DevWorkspace if storage has not been provisioned.
+func getProvisionedStorageType(workspace *dw.DevWorkspace) string {
+	if storageType, ok := workspace.Annotations[constants.DevWorkspaceStorageTypeAnnotation]; ok {
+		return storageType
+	}
+	return GetStorageType(workspace)
+}
+
+// getWorkspacePVCName returns the name of the PVC that stores a workspace's data for a given storage type. Returns an
+// empty string if the storage type does not persist data.
+func getWorkspacePVCName(workspaceId, storageType string) (string, error) {
+	layout, err := getStorageLayout(storageType)
+	if err != nil {
+		return "", err
+	}
+	switch layout {
+	case commonPVCLayout:
+		return config.Workspace.PVCName, nil
+	case perWorkspacePVCLayout:
+		return common.PerWorkspacePVCName(workspaceId), nil
+	default:
+		return "", nil
+	}
+}
+
+func getSpecVolumeSnapshot(workspace *dw.DevWorkspace, snapshotName, pvcName, storageType string) *unstructured.Unstructured {
+	snapshot := &unstructured.Unstructured{}
+	snapshot.SetGroupVersionKind(volumeSnapshotGVK)
+	snapshot.SetName(snapshotName)
+	snapshot.SetNamespace(workspace.Namespace)
+	snapshot.SetLabels(map[string]string{
+		constants.DevWorkspaceIDLabel:   workspace.Status.DevWorkspaceId,
+		constants.DevWorkspaceNameLabel: workspace.Name,
+	})
+	snapshot.SetAnnotations(map[string]string{
+		constants.DevWorkspaceStorageTypeAnnotation: storageType,
+	})
+	spec := map[string]interface{}{
+		"source": map[string]interface{}{
+			"persistentVolumeClaimName": pvcName,
+		},
+	}
+	if config.Workspace.Backup != nil && config.Workspace.Backup.VolumeSnapshotClassName != nil {
+		spec["volumeSnapshotClassName"] = *config.Workspace.Backup.VolumeSnapshotClassName
+	}
+	snapshot.Object["spec"] = spec
+	return snapshot
+}
+
+func getVolumeSnapshot(name, namespace string, clusterAPI sync.ClusterAPI) (*unstructured.Unstructured, error) {
+	snapshot := &unstructured.Unstructured{}
+	snapshot.SetGroupVersionKind(volumeSnapshotGVK)
+	err := clusterAPI.Client.Get(clusterAPI.Ctx, types.NamespacedName{Name: name, Namespace: namespace}, snapshot)
+	if err != nil {
+		return nil, err
+	}
+	return snapshot, nil
+}
+
+// checkVolumeSnapshotReady returns whether a VolumeSnapshot is ready to use. Returns an error if the snapshot controller
+// reported an error creating the snapshot.
+func checkVolumeSnapshotReady(snapshot *unstructured.Unstructured) (bool, error) {
+	if errMsg, found, _ := unstructured.NestedString(snapshot.Object, "status", "error", "message"); found && errMsg != "" {
+		return false, errors.New(errMsg)
+	}
+	ready, _, _ := unstructured.NestedBool(snapshot.Object, "status", "readyToUse")
+	return ready, nil
+}
+
+// getSpecRestorePVC returns a temporary PVC with the contents of a VolumeSnapshot, used when restoring a DevWorkspace's
+// storage.
+func getSpecRestorePVC(workspace *dw.DevWorkspace, snapshot *unstructured.Unstructured, clusterAPI sync.ClusterAPI) (*corev1.PersistentVolumeClaim, error) {
+	restoreSize, _, _ := unstructured.NestedString(snapshot.Object, "status", "restoreSize")
+	if restoreSize == "" {
+		return nil, &ProvisioningError{Message: fmt.Sprintf("VolumeSnapshot %s does not specify a restore size", snapshot.GetName())}
+	}
+	size, err := resource.ParseQuantity(restoreSize)
+	if err != nil {
+		return nil, &ProvisioningError{Message: fmt.Sprintf("Failed to parse restore size of VolumeSnapshot %s", snapshot.GetName()), Err: err}
+	}
+	apiGroup := volumeSnapshotGVK.Group
+	pvc := &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      common.StorageRestoreName(workspace.Status.DevWorkspaceId),
+			Namespace: workspace.Namespace,
+			Labels: map[string]string{
+				constants.DevWorkspaceIDLabel: workspace.Status.DevWorkspaceId,
+			},
+		},
+		Spec: corev1.PersistentVolumeClaimSpec{
+			AccessModes: []corev1.PersistentVolumeAccessMode{
+				corev1.ReadWriteOnce,
+			},
+			Resources: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{
+					"storage": size,
+				},
+			},
+			StorageClassName: config.Workspace.StorageClassName,
+			DataSource: &corev1.TypedLocalObjectReference{
+				APIGroup: &apiGroup,
+				Kind:     volumeSnapshotGVK.Kind,
+				Name:     snapshot.GetName(),
+			},
+		},
+	}
+	if err := controllerutil.SetControllerReference(workspace, pvc, clusterAPI.Scheme); err != nil {
+		return nil, err
+	}
+	return pvc, nil
+}
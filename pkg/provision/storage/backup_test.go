@@ -0,0 +1,226 @@
+//
+// Copyright (c) 2019-2021 Red Hat, Inc.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package storage
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/devfile/devworkspace-operator/apis/controller/v1alpha1"
+	"github.com/devfile/devworkspace-operator/pkg/common"
+	"github.com/devfile/devworkspace-operator/pkg/config"
+	"github.com/devfile/devworkspace-operator/pkg/constants"
+	"github.com/devfile/devworkspace-operator/pkg/provision/sync"
+)
+
+func init() {
+	scheme.AddKnownTypeWithName(volumeSnapshotGVK, &unstructured.Unstructured{})
+	scheme.AddKnownTypeWithName(volumeSnapshotGVK.GroupVersion().WithKind("VolumeSnapshotList"), &unstructured.UnstructuredList{})
+}
+
+func TestBackupWorkspaceStorage(t *testing.T) {
+	setupControllerCfg()
+	tests := []struct {
+		name        string
+		storageType string
+		pvcName     string
+	}{
+		{name: "Common storage", storageType: constants.CommonStorageClassType, pvcName: "claim-devworkspace"},
+		{name: "Async storage", storageType: constants.AsyncStorageClassType, pvcName: "claim-devworkspace"},
+		{name: "Per-workspace storage", storageType: constants.PerWorkspaceStorageClassType, pvcName: common.PerWorkspacePVCName("test-workspaceid")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			workspace := getTestMigrationDevWorkspace(tt.storageType)
+			pvc := &corev1.PersistentVolumeClaim{}
+			pvc.Name = tt.pvcName
+			pvc.Namespace = workspace.Namespace
+			clusterAPI := getTestMigrationClusterAPI(pvc)
+
+			snapshot, err := BackupWorkspaceStorage(workspace, "test-snapshot", clusterAPI)
+			if !assert.NoError(t, err, "Should not return error") || !assert.NotNil(t, snapshot, "Should create VolumeSnapshot") {
+				return
+			}
+			clusterSnapshot := getTestVolumeSnapshot(t, "test-snapshot", clusterAPI)
+			sourcePVC, _, _ := unstructured.NestedString(clusterSnapshot.Object, "spec", "source", "persistentVolumeClaimName")
+			assert.Equal(t, tt.pvcName, sourcePVC, "VolumeSnapshot should be of workspace PVC")
+			assert.Equal(t, tt.storageType, clusterSnapshot.GetAnnotations()[constants.DevWorkspaceStorageTypeAnnotation], "VolumeSnapshot should record storage type")
+			assert.Equal(t, workspace.Status.DevWorkspaceId, clusterSnapshot.GetLabels()[constants.DevWorkspaceIDLabel], "VolumeSnapshot should record workspace ID")
+			assert.Empty(t, clusterSnapshot.GetOwnerReferences(), "VolumeSnapshot should not be owned by DevWorkspace")
+
+			_, err = BackupWorkspaceStorage(workspace, "test-snapshot", clusterAPI)
+			assert.NoError(t, err, "Should not return error if VolumeSnapshot already exists")
+		})
+	}
+}
+
+func TestBackupWorkspaceStorageDoesNothingWithoutData(t *testing.T) {
+	setupControllerCfg()
+	tests := []struct {
+		name        string
+		storageType string
+	}{
+		{name: "Ephemeral storage", storageType: constants.EphemeralStorageClassType},
+		{name: "No PVC", storageType: constants.PerWorkspaceStorageClassType},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			workspace := getTestMigrationDevWorkspace(tt.storageType)
+			clusterAPI := getTestMigrationClusterAPI()
+			snapshot, err := BackupWorkspaceStorage(workspace, "test-snapshot", clusterAPI)
+			assert.NoError(t, err, "Should not return error")
+			assert.Nil(t, snapshot, "Should not create VolumeSnapshot")
+		})
+	}
+}
+
+func TestBackupWorkspaceStoragePrunesOldBackups(t *testing.T) {
+	cfg := testControllerCfg.DeepCopy()
+	maxBackups := 2
+	cfg.Workspace.Backup = &v1alpha1.BackupConfig{MaxBackups: &maxBackups}
+	config.SetConfigForTesting(cfg)
+	defer setupControllerCfg()
+
+	workspace := getTestMigrationDevWorkspace(constants.PerWorkspaceStorageClassType)
+	pvcName := common.PerWorkspacePVCName(workspace.Status.DevWorkspaceId)
+	pvc := &corev1.PersistentVolumeClaim{}
+	pvc.Name = pvcName
+	pvc.Namespace = workspace.Namespace
+	otherWorkspace := getTestMigrationDevWorkspace(constants.PerWorkspaceStorageClassType)
+	otherWorkspace.Status.DevWorkspaceId = "other-workspaceid"
+	clusterAPI := getTestMigrationClusterAPI(pvc,
+		getSpecVolumeSnapshot(workspace, common.WorkspaceBackupName("test-workspaceid", "deleted"), pvcName, constants.PerWorkspaceStorageClassType),
+		getSpecVolumeSnapshot(otherWorkspace, common.WorkspaceBackupName("other-workspaceid", "20210101-000000"), pvcName, constants.PerWorkspaceStorageClassType),
+	)
+
+	for _, timestamp := range []string{"20210101-000000", "20210102-000000", "20210103-000000"} {
+		_, err := BackupWorkspaceStorage(workspace, common.WorkspaceBackupName("test-workspaceid", timestamp), clusterAPI)
+		assert.NoError(t, err, "Should not return error")
+	}
+
+	_, err := getVolumeSnapshot(common.WorkspaceBackupName("test-workspaceid", "20210101-000000"), workspace.Namespace, clusterAPI)
+	assert.True(t, k8sErrors.IsNotFound(err), "Oldest backup should be deleted")
+	for _, name := range []string{
+		common.WorkspaceBackupName("test-workspaceid", "20210102-000000"),
+		common.WorkspaceBackupName("test-workspaceid", "20210103-000000"),
+		common.WorkspaceBackupName("test-workspaceid", "deleted"),
+		common.WorkspaceBackupName("other-workspaceid", "20210101-000000"),
+	} {
+		_, err := getVolumeSnapshot(name, workspace.Namespace, clusterAPI)
+		assert.NoError(t, err, "VolumeSnapshot %s should not be deleted", name)
+	}
+}
+
+func TestBackupWorkspaceStorageForDeletionWaitsForSnapshot(t *testing.T) {
+	setupControllerCfg()
+	workspace := getTestMigrationDevWorkspace(constants.PerWorkspaceStorageClassType)
+	pvc := &corev1.PersistentVolumeClaim{}
+	pvc.Name = common.PerWorkspacePVCName(workspace.Status.DevWorkspaceId)
+	pvc.Namespace = workspace.Namespace
+	clusterAPI := getTestMigrationClusterAPI(pvc)
+
+	err := BackupWorkspaceStorageForDeletion(workspace, clusterAPI)
+	assert.IsType(t, &NotReadyError{}, err, "Should wait for VolumeSnapshot to be ready")
+
+	snapshot := getTestVolumeSnapshot(t, common.WorkspaceBackupName(workspace.Status.DevWorkspaceId, "deleted"), clusterAPI)
+	setTestVolumeSnapshotStatus(t, snapshot, "1Gi", clusterAPI)
+	assert.NoError(t, BackupWorkspaceStorageForDeletion(workspace, clusterAPI), "Should not return error once VolumeSnapshot is ready")
+}
+
+func TestRestoreWorkspaceStorageFromCommonToPerWorkspace(t *testing.T) {
+	setupControllerCfg()
+	sourceWorkspace := getTestMigrationDevWorkspace(constants.CommonStorageClassType)
+	sourceWorkspace.Status.DevWorkspaceId = "source-workspaceid"
+	snapshot := getSpecVolumeSnapshot(sourceWorkspace, "test-snapshot", "claim-devworkspace", constants.CommonStorageClassType)
+	clusterAPI := getTestMigrationClusterAPI(snapshot)
+	setTestVolumeSnapshotStatus(t, snapshot, "5Gi", clusterAPI)
+	workspace := getTestMigrationDevWorkspace(constants.PerWorkspaceStorageClassType)
+
+	// First reconcile creates per-workspace PVC
+	err := RestoreWorkspaceStorage(workspace, "test-snapshot", clusterAPI)
+	assert.IsType(t, &NotReadyError{}, err, "Should wait for per-workspace PVC to be created")
+
+	// Second reconcile creates PVC from snapshot
+	err = RestoreWorkspaceStorage(workspace, "test-snapshot", clusterAPI)
+	assert.IsType(t, &NotReadyError{}, err, "Should wait for restore PVC to be created")
+	restorePVC := &corev1.PersistentVolumeClaim{}
+	restorePVCName := types.NamespacedName{Name: common.StorageRestoreName(workspace.Status.DevWorkspaceId), Namespace: workspace.Namespace}
+	if assert.NoError(t, clusterAPI.Client.Get(clusterAPI.Ctx, restorePVCName, restorePVC), "Restore PVC should be created") {
+		if assert.NotNil(t, restorePVC.Spec.DataSource, "Restore PVC should have data source") {
+			assert.Equal(t, "test-snapshot", restorePVC.Spec.DataSource.Name, "Restore PVC should be created from VolumeSnapshot")
+		}
+		restoreSize := restorePVC.Spec.Resources.Requests[corev1.ResourceStorage]
+		assert.Equal(t, "5Gi", restoreSize.String(), "Restore PVC should use VolumeSnapshot restore size")
+	}
+
+	// Third reconcile creates restore job
+	err = RestoreWorkspaceStorage(workspace, "test-snapshot", clusterAPI)
+	assert.IsType(t, &NotReadyError{}, err, "Should wait for restore job to be created")
+	job := &batchv1.Job{}
+	if err := clusterAPI.Client.Get(clusterAPI.Ctx, restorePVCName, job); err != nil {
+		t.Fatalf("Failed to get restore job: %s", err)
+	}
+	script := job.Spec.Template.Spec.Containers[0].Args[1]
+	assert.Contains(t, script, "/tmp/copy/source/source-workspaceid/.", "Restore job should copy source workspace subpath")
+	assert.NotContains(t, script, "rm -rf", "Restore job should not remove data from restore PVC")
+
+	setTestJobCondition(t, job, batchv1.JobComplete, clusterAPI)
+	assert.NoError(t, RestoreWorkspaceStorage(workspace, "test-snapshot", clusterAPI), "Restore should be complete")
+	err = clusterAPI.Client.Get(clusterAPI.Ctx, restorePVCName, &corev1.PersistentVolumeClaim{})
+	assert.True(t, k8sErrors.IsNotFound(err), "Restore PVC should be deleted")
+}
+
+func TestRestoreWorkspaceStorageErrors(t *testing.T) {
+	setupControllerCfg()
+	sourceWorkspace := getTestMigrationDevWorkspace(constants.PerWorkspaceStorageClassType)
+	readySnapshot := getSpecVolumeSnapshot(sourceWorkspace, "ready-snapshot", "storage-test-workspaceid", constants.PerWorkspaceStorageClassType)
+	pendingSnapshot := getSpecVolumeSnapshot(sourceWorkspace, "pending-snapshot", "storage-test-workspaceid", constants.PerWorkspaceStorageClassType)
+	clusterAPI := getTestMigrationClusterAPI(readySnapshot, pendingSnapshot)
+	setTestVolumeSnapshotStatus(t, readySnapshot, "1Gi", clusterAPI)
+
+	err := RestoreWorkspaceStorage(getTestMigrationDevWorkspace(constants.CommonStorageClassType), "missing-snapshot", clusterAPI)
+	assert.IsType(t, &ProvisioningError{}, err, "Should return ProvisioningError if VolumeSnapshot does not exist")
+
+	err = RestoreWorkspaceStorage(getTestMigrationDevWorkspace(constants.CommonStorageClassType), "pending-snapshot", clusterAPI)
+	assert.IsType(t, &NotReadyError{}, err, "Should wait for VolumeSnapshot to be ready")
+
+	err = RestoreWorkspaceStorage(getTestMigrationDevWorkspace(constants.EphemeralStorageClassType), "ready-snapshot", clusterAPI)
+	assert.IsType(t, &ProvisioningError{}, err, "Should return ProvisioningError when restoring to ephemeral storage")
+}
+
+func getTestVolumeSnapshot(t *testing.T, name string, clusterAPI sync.ClusterAPI) *unstructured.Unstructured {
+	snapshot, err := getVolumeSnapshot(name, "test-namespace", clusterAPI)
+	if err != nil {
+		t.Fatalf("Failed to get VolumeSnapshot: %s", err)
+	}
+	return snapshot
+}
+
+func setTestVolumeSnapshotStatus(t *testing.T, snapshot *unstructured.Unstructured, restoreSize string, clusterAPI sync.ClusterAPI) {
+	snapshot.Object["status"] = map[string]interface{}{
+		"readyToUse":  true,
+		"restoreSize": restoreSize,
+	}
+	if err := clusterAPI.Client.Update(clusterAPI.Ctx, snapshot); err != nil {
+		t.Fatalf("Failed to update VolumeSnapshot status: %s", err)
+	}
+}
@@ -0,0 +1,175 @@
+//
+// Copyright (c) 2019-2021 Red Hat, Inc.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package storage
+
+import (
+	"fmt"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/devfile/devworkspace-operator/internal/images"
+	"github.com/devfile/devworkspace-operator/pkg/config"
+	"github.com/devfile/devworkspace-operator/pkg/provision/storage/asyncstorage"
+	"github.com/devfile/devworkspace-operator/pkg/provision/sync"
+	wsprovision "github.com/devfile/devworkspace-operator/pkg/provision/workspace"
+)
+
+// commonPVCJobDeadline is the maximum time a namespace-wide job on the common PVC can run before it is considered
+// failed, e.g. if the common PVC cannot be mounted because it is in use on another node.
+const commonPVCJobDeadline = int64(3600)
+
+// runCommonPVCJob runs a job that mounts the common PVC in a namespace at pvcClaimMountPath and runs a bash script,
+// used for namespace-wide tasks such as garbage collection. The script reports its result by writing to
+// /dev/termination-log, which is limited to 4096 bytes. Once the job completes, it is deleted and its result is
+// returned along with the job itself. If the job does not exist, getSpecJob is called to create it.
+//
+// Returns NotReadyError while the job is running, and ProvisioningError if the job fails.
+func runCommonPVCJob(namespace, jobName string, getSpecJob func() (*batchv1.Job, error), clusterAPI sync.ClusterAPI) (result string, job *batchv1.Job, err error) {
+	clusterJob := &batchv1.Job{}
+	err = clusterAPI.Client.Get(clusterAPI.Ctx, types.NamespacedName{Name: jobName, Namespace: namespace}, clusterJob)
+	switch {
+	case k8sErrors.IsNotFound(err):
+		specJob, err := getSpecJob()
+		if err != nil {
+			return "", nil, err
+		}
+		if err := clusterAPI.Client.Create(clusterAPI.Ctx, specJob); err != nil && !k8sErrors.IsAlreadyExists(err) {
+			return "", nil, err
+		}
+		clusterAPI.Logger.Info("Created object", "kind", "Job", "name", jobName)
+		return "", nil, &NotReadyError{Message: fmt.Sprintf("Waiting for job %s to start", jobName), RequeueAfter: 10 * time.Second}
+	case err != nil:
+		return "", nil, err
+	}
+
+	for _, condition := range clusterJob.Status.Conditions {
+		if condition.Status != corev1.ConditionTrue {
+			continue
+		}
+		switch condition.Type {
+		case batchv1.JobComplete:
+			result, err := getJobTerminationMessage(clusterJob, clusterAPI)
+			if err != nil {
+				return "", nil, err
+			}
+			return result, clusterJob, deleteJob(clusterJob, clusterAPI)
+		case batchv1.JobFailed:
+			if err := deleteJob(clusterJob, clusterAPI); err != nil {
+				return "", nil, err
+			}
+			return "", nil, &ProvisioningError{
+				Message: fmt.Sprintf("Job %s failed: %s", jobName, condition.Message),
+			}
+		}
+	}
+	return "", nil, &NotReadyError{Message: fmt.Sprintf("Job %s is not complete", jobName), RequeueAfter: 10 * time.Second}
+}
+
+// getSpecCommonPVCJob returns the spec for a job that runs script with the common PVC in a namespace mounted at
+// pvcClaimMountPath (available to the script as $PVC_PATH).
+func getSpecCommonPVCJob(namespace, jobName, script string, env []corev1.EnvVar, clusterAPI sync.ClusterAPI) (*batchv1.Job, error) {
+	// If the async storage server is running, it has the common PVC mounted; run the job on the same node so that it
+	// can mount the common PVC even if it is ReadWriteOnce.
+	var affinity *corev1.Affinity
+	if asyncDeploy, err := asyncstorage.GetWorkspaceSyncDeploymentCluster(namespace, clusterAPI); err == nil {
+		if asyncDeploy.Status.ReadyReplicas > 0 {
+			affinity = asyncstorage.GetAsyncServerAffinity()
+		}
+	} else if !k8sErrors.IsNotFound(err) {
+		return nil, err
+	}
+
+	pvcName := config.Workspace.PVCName
+	backoffLimit := int32(0)
+	deadline := commonPVCJobDeadline
+	env = append([]corev1.EnvVar{{Name: "PVC_PATH", Value: pvcClaimMountPath}}, env...)
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      jobName,
+			Namespace: namespace,
+		},
+		Spec: batchv1.JobSpec{
+			Completions:           &cleanupJobCompletions,
+			BackoffLimit:          &backoffLimit,
+			ActiveDeadlineSeconds: &deadline,
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					RestartPolicy:   "Never",
+					SecurityContext: wsprovision.GetDevWorkspaceSecurityContext(),
+					Affinity:        affinity,
+					Volumes: []corev1.Volume{
+						{
+							Name: pvcName,
+							VolumeSource: corev1.VolumeSource{
+								PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+									ClaimName: pvcName,
+								},
+							},
+						},
+					},
+					Containers: []corev1.Container{
+						{
+							Name:    jobName,
+							Image:   images.GetPVCCleanupJobImage(),
+							Command: []string{"/bin/bash"},
+							Args:    []string{"-c", script},
+							Env:     env,
+							Resources: corev1.ResourceRequirements{
+								Requests: corev1.ResourceList{
+									corev1.ResourceMemory: pvcCleanupPodMemoryRequest,
+									corev1.ResourceCPU:    pvcCleanupPodCPURequest,
+								},
+								Limits: corev1.ResourceList{
+									corev1.ResourceMemory: pvcCleanupPodMemoryLimit,
+									corev1.ResourceCPU:    pvcCleanupPodCPULimit,
+								},
+							},
+							VolumeMounts: []corev1.VolumeMount{
+								{
+									Name:      pvcName,
+									MountPath: pvcClaimMountPath,
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	return job, nil
+}
+
+// getJobTerminationMessage reads the termination message of the container of a successfully completed job.
+func getJobTerminationMessage(job *batchv1.Job, clusterAPI sync.ClusterAPI) (string, error) {
+	pods := &corev1.PodList{}
+	if err := clusterAPI.Client.List(clusterAPI.Ctx, pods, client.InNamespace(job.Namespace), client.MatchingLabels{"job-name": job.Name}); err != nil {
+		return "", err
+	}
+	for _, pod := range pods.Items {
+		for _, status := range pod.Status.ContainerStatuses {
+			if status.State.Terminated != nil && status.State.Terminated.ExitCode == 0 {
+				return status.State.Terminated.Message, nil
+			}
+		}
+	}
+	return "", &ProvisioningError{Message: fmt.Sprintf("Could not read result of job %s", job.Name)}
+}
@@ -25,8 +25,6 @@ import (
 
 	"github.com/devfile/devworkspace-operator/apis/controller/v1alpha1"
 	"github.com/devfile/devworkspace-operator/pkg/config"
-	"github.com/devfile/devworkspace-operator/pkg/constants"
-	devfileConstants "github.com/devfile/devworkspace-operator/pkg/library/constants"
 )
 
 // The CommonStorageProvisioner provisions one PVC per namespace and configures all volumes in a workspace
@@ -72,23 +70,9 @@ func (*CommonStorageProvisioner) CleanupWorkspaceStorage(workspace *dw.DevWorksp
 //
 // Also adds appropriate k8s Volumes to PodAdditions to accomodate the rewritten VolumeMounts.
 func (p *CommonStorageProvisioner) rewriteContainerVolumeMounts(workspaceId string, podAdditions *v1alpha1.PodAdditions, workspace *dw.DevWorkspaceTemplateSpec) error {
-	devfileVolumes := map[string]dw.VolumeComponent{}
-
-	// Construct map of volume name -> volume Component
-	for _, component := range workspace.Components {
-		if component.Volume != nil {
-			if _, exists := devfileVolumes[component.Name]; exists {
-				return fmt.Errorf("volume component '%s' is defined multiple times", component.Name)
-			}
-			devfileVolumes[component.Name] = *component.Volume
-		}
-	}
-
-	// Add implicit projects volume to support mountSources, if needed
-	if _, exists := devfileVolumes[devfileConstants.ProjectsVolumeName]; !exists {
-		projectsVolume := dw.VolumeComponent{}
-		projectsVolume.Size = constants.PVCStorageSize
-		devfileVolumes[devfileConstants.ProjectsVolumeName] = projectsVolume
+	devfileVolumes, err := getDevfileVolumes(workspace)
+	if err != nil {
+		return err
 	}
 
 	// TODO: What should we do when a volume isn't explicitly defined?
@@ -37,6 +37,7 @@ import (
 
 	"github.com/devfile/devworkspace-operator/apis/controller/v1alpha1"
 	"github.com/devfile/devworkspace-operator/pkg/config"
+	"github.com/devfile/devworkspace-operator/pkg/infrastructure"
 )
 
 var scheme = runtime.NewScheme()
@@ -46,6 +47,7 @@ func init() {
 	utilruntime.Must(v1alpha1.AddToScheme(scheme))
 	utilruntime.Must(dw.AddToScheme(scheme))
 	config.SetConfigForTesting(nil)
+	infrastructure.InitializeForTesting(infrastructure.Kubernetes)
 }
 
 type testCase struct {
@@ -63,6 +65,7 @@ type testInput struct {
 type testOutput struct {
 	PodAdditions v1alpha1.PodAdditions `json:"podAdditions,omitempty"`
 	ErrRegexp    *string               `json:"errRegexp,omitempty"`
+	PVCSize      *string               `json:"pvcSize,omitempty"`
 }
 
 var testControllerCfg = &v1alpha1.OperatorConfiguration{
@@ -38,14 +38,14 @@ func (e EphemeralStorageProvisioner) NeedsStorage(_ *dw.DevWorkspaceTemplateSpec
 func (e EphemeralStorageProvisioner) ProvisionStorage(podAdditions *v1alpha1.PodAdditions, workspace *dw.DevWorkspace, _ sync.ClusterAPI) error {
 	persistent, ephemeral, projects := getWorkspaceVolumes(workspace)
 	if _, err := addEphemeralVolumesToPodAdditions(podAdditions, persistent); err != nil {
-		return err
+		return &ProvisioningError{Message: "Failed to add ephemeral volumes to workspace", Err: err}
 	}
 	if _, err := addEphemeralVolumesToPodAdditions(podAdditions, ephemeral); err != nil {
-		return err
+		return &ProvisioningError{Message: "Failed to add ephemeral volumes to workspace", Err: err}
 	}
 	if projects != nil {
 		if _, err := addEphemeralVolumesToPodAdditions(podAdditions, []dw.Component{*projects}); err != nil {
-			return err
+			return &ProvisioningError{Message: "Failed to add projects volume to workspace", Err: err}
 		}
 	} else {
 		if container.AnyMountSources(workspace.Spec.Template.Components) {
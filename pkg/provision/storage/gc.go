@@ -0,0 +1,140 @@
+//
+// Copyright (c) 2019-2021 Red Hat, Inc.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package storage
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	dw "github.com/devfile/api/v2/pkg/apis/workspaces/v1alpha2"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/devfile/devworkspace-operator/pkg/common"
+	"github.com/devfile/devworkspace-operator/pkg/config"
+	"github.com/devfile/devworkspace-operator/pkg/provision/sync"
+)
+
+const (
+	// gcMinimumAge is the minimum time since a directory on the common PVC was last modified before it can be
+	// considered orphaned. This avoids removing data for DevWorkspaces created after the garbage collection job was
+	// started.
+	gcMinimumAge = 10 * time.Minute
+
+	// gcScript removes (or only reports, if DRY_RUN is "true") directories named after workspace IDs on the common
+	// PVC that do not belong to a DevWorkspace in KNOWN_IDS and were not modified after MAX_MTIME. The total size of
+	// orphaned directories and their names are written to the termination log for the controller to read.
+	gcScript = `cd "$PVC_PATH" || exit 1
+total=0
+orphans=""
+for dir in workspace*/; do
+  [ -d "$dir" ] || continue
+  id="${dir%/}"
+  case " $KNOWN_IDS " in *" $id "*) continue ;; esac
+  [ "$(stat -c %Y "$id")" -le "$MAX_MTIME" ] || continue
+  size="$(du -sb "$id" | cut -f1)"
+  total=$((total + size))
+  orphans="$orphans $id"
+  if [ "$DRY_RUN" != "true" ]; then
+    rm -rf "$id" || exit 1
+  fi
+done
+echo "$total$orphans" > /dev/termination-log
+`
+)
+
+// GarbageCollectionResult describes the orphaned data found on the common PVC in a namespace.
+type GarbageCollectionResult struct {
+	// OrphanedIDs are the workspace IDs with data on the common PVC that do not belong to any DevWorkspace.
+	OrphanedIDs []string
+	// OrphanedBytes is the total size of orphaned data on the common PVC.
+	OrphanedBytes int64
+	// DryRun is true if orphaned data was only reported and not removed.
+	DryRun bool
+}
+
+// CollectOrphanedStorage runs a job that removes data for DevWorkspaces that no longer exist from the common PVC in a
+// namespace. If garbage collection is configured as a dry run, orphaned data is only reported. Since the common PVC is
+// shared by all DevWorkspaces in the namespace, clusterAPI should not use a cached client, to ensure all DevWorkspaces
+// in the namespace are considered.
+//
+// Returns the result of garbage collection once the job is complete, or nil if there is no common PVC in the namespace.
+// Returns NotReadyError while the job is running, and ProvisioningError if the job fails.
+func CollectOrphanedStorage(namespace string, clusterAPI sync.ClusterAPI) (*GarbageCollectionResult, error) {
+	exists, err := pvcExists(config.Workspace.PVCName, namespace, clusterAPI)
+	if err != nil || !exists {
+		return nil, err
+	}
+
+	getSpecJob := func() (*batchv1.Job, error) {
+		return getSpecGarbageCollectionJob(namespace, clusterAPI)
+	}
+	message, job, err := runCommonPVCJob(namespace, common.StorageGarbageCollectionJobName(), getSpecJob, clusterAPI)
+	if err != nil {
+		return nil, err
+	}
+	return parseGarbageCollectionResult(message, job)
+}
+
+func getSpecGarbageCollectionJob(namespace string, clusterAPI sync.ClusterAPI) (*batchv1.Job, error) {
+	workspaces := &dw.DevWorkspaceList{}
+	if err := clusterAPI.Client.List(clusterAPI.Ctx, workspaces, client.InNamespace(namespace)); err != nil {
+		return nil, err
+	}
+	var knownIds []string
+	for _, workspace := range workspaces.Items {
+		if workspace.Status.DevWorkspaceId != "" {
+			knownIds = append(knownIds, workspace.Status.DevWorkspaceId)
+		}
+	}
+	sort.Strings(knownIds)
+	maxMTime := time.Now().Add(-gcMinimumAge).Unix()
+	dryRun := config.Workspace.StorageGarbageCollection != nil &&
+		config.Workspace.StorageGarbageCollection.DryRun != nil &&
+		*config.Workspace.StorageGarbageCollection.DryRun
+
+	env := []corev1.EnvVar{
+		{Name: "KNOWN_IDS", Value: strings.Join(knownIds, " ")},
+		{Name: "MAX_MTIME", Value: strconv.FormatInt(maxMTime, 10)},
+		{Name: "DRY_RUN", Value: strconv.FormatBool(dryRun)},
+	}
+	return getSpecCommonPVCJob(namespace, common.StorageGarbageCollectionJobName(), gcScript, env, clusterAPI)
+}
+
+func parseGarbageCollectionResult(message string, job *batchv1.Job) (*GarbageCollectionResult, error) {
+	fields := strings.Fields(message)
+	if len(fields) == 0 {
+		return nil, &ProvisioningError{Message: fmt.Sprintf("Storage garbage collection job %s did not report a result", job.Name)}
+	}
+	orphanedBytes, err := strconv.ParseInt(fields[0], 10, 64)
+	if err != nil {
+		return nil, &ProvisioningError{Message: fmt.Sprintf("Failed to parse result of storage garbage collection job %s", job.Name), Err: err}
+	}
+	result := &GarbageCollectionResult{
+		OrphanedIDs:   fields[1:],
+		OrphanedBytes: orphanedBytes,
+	}
+	for _, env := range job.Spec.Template.Spec.Containers[0].Env {
+		if env.Name == "DRY_RUN" {
+			result.DryRun = env.Value == "true"
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,96 @@
+//
+// Copyright (c) 2019-2021 Red Hat, Inc.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package storage
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/devfile/devworkspace-operator/pkg/common"
+	"github.com/devfile/devworkspace-operator/pkg/constants"
+)
+
+func TestCollectOrphanedStorage(t *testing.T) {
+	setupControllerCfg()
+	workspace := getTestMigrationDevWorkspace(constants.CommonStorageClassType)
+	commonPVC, err := getCommonPVCSpec(workspace.Namespace, "1Gi")
+	if err != nil {
+		t.Fatalf("Failure during setup: %s", err)
+	}
+	clusterAPI := getTestMigrationClusterAPI(workspace, commonPVC)
+
+	result, err := CollectOrphanedStorage(workspace.Namespace, clusterAPI)
+	assert.IsType(t, &NotReadyError{}, err, "Should wait for garbage collection job to be created")
+	assert.Nil(t, result)
+	job := &batchv1.Job{}
+	jobName := types.NamespacedName{Name: common.StorageGarbageCollectionJobName(), Namespace: workspace.Namespace}
+	if err := clusterAPI.Client.Get(clusterAPI.Ctx, jobName, job); err != nil {
+		t.Fatalf("Failed to get garbage collection job: %s", err)
+	}
+	env := map[string]string{}
+	for _, envVar := range job.Spec.Template.Spec.Containers[0].Env {
+		env[envVar.Name] = envVar.Value
+	}
+	assert.Equal(t, workspace.Status.DevWorkspaceId, env["KNOWN_IDS"], "Garbage collection job should not remove data for existing DevWorkspaces")
+	assert.Equal(t, "false", env["DRY_RUN"], "Garbage collection should not be a dry run by default")
+
+	_, err = CollectOrphanedStorage(workspace.Namespace, clusterAPI)
+	assert.IsType(t, &NotReadyError{}, err, "Should wait for garbage collection job to complete")
+
+	pod := &corev1.Pod{}
+	pod.Name = "devworkspace-storage-gc-abcde"
+	pod.Namespace = workspace.Namespace
+	pod.Labels = map[string]string{"job-name": job.Name}
+	pod.Status.ContainerStatuses = []corev1.ContainerStatus{
+		{
+			State: corev1.ContainerState{
+				Terminated: &corev1.ContainerStateTerminated{
+					ExitCode: 0,
+					Message:  "3072 workspace1234 workspace5678\n",
+				},
+			},
+		},
+	}
+	if err := clusterAPI.Client.Create(clusterAPI.Ctx, pod); err != nil {
+		t.Fatalf("Failed to create pod: %s", err)
+	}
+	setTestJobCondition(t, job, batchv1.JobComplete, clusterAPI)
+
+	result, err = CollectOrphanedStorage(workspace.Namespace, clusterAPI)
+	if assert.NoError(t, err, "Garbage collection should be complete") {
+		assert.Equal(t, []string{"workspace1234", "workspace5678"}, result.OrphanedIDs, "Should report orphaned workspace IDs")
+		assert.Equal(t, int64(3072), result.OrphanedBytes, "Should report size of orphaned data")
+		assert.False(t, result.DryRun)
+	}
+	err = clusterAPI.Client.Get(clusterAPI.Ctx, jobName, &batchv1.Job{})
+	assert.True(t, k8sErrors.IsNotFound(err), "Garbage collection job should be deleted")
+}
+
+func TestCollectOrphanedStorageWithoutCommonPVC(t *testing.T) {
+	setupControllerCfg()
+	clusterAPI := getTestMigrationClusterAPI()
+	result, err := CollectOrphanedStorage("test-namespace", clusterAPI)
+	assert.NoError(t, err)
+	assert.Nil(t, result, "Should not run garbage collection if there is no common PVC")
+	jobs := &batchv1.JobList{}
+	assert.NoError(t, clusterAPI.Client.List(clusterAPI.Ctx, jobs))
+	assert.Empty(t, jobs.Items, "Should not create garbage collection job")
+}
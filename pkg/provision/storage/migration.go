@@ -0,0 +1,335 @@
+//
+// Copyright (c) 2019-2021 Red Hat, Inc.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package storage
+
+import (
+	"fmt"
+	"path"
+	"time"
+
+	dw "github.com/devfile/api/v2/pkg/apis/workspaces/v1alpha2"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	"github.com/devfile/devworkspace-operator/internal/images"
+	"github.com/devfile/devworkspace-operator/pkg/common"
+	"github.com/devfile/devworkspace-operator/pkg/config"
+	"github.com/devfile/devworkspace-operator/pkg/constants"
+	"github.com/devfile/devworkspace-operator/pkg/provision/sync"
+	wsprovision "github.com/devfile/devworkspace-operator/pkg/provision/workspace"
+)
+
+const (
+	copyJobSourceMountPath = "/tmp/copy/source"
+	copyJobTargetMountPath = "/tmp/copy/target"
+	copyJobSourceVolume    = "source"
+	copyJobTargetVolume    = "target"
+)
+
+// storageLayout describes where a storage type keeps a workspace's persistent data
+type storageLayout string
+
+const (
+	// commonPVCLayout stores workspace data in the <workspace-id> subpath of the common PVC. This is used for both
+	// the common and async storage types, as the async storage server backs up workspace data to the common PVC.
+	commonPVCLayout storageLayout = "common-pvc"
+	// perWorkspacePVCLayout stores workspace data in the root of a PVC dedicated to the workspace.
+	perWorkspacePVCLayout storageLayout = "per-workspace-pvc"
+	// noLayout is used for storage types that do not persist data.
+	noLayout storageLayout = ""
+)
+
+// GetStorageType returns the storage type used by a DevWorkspace, as defined by the storage-type attribute. If the
+// attribute is not set, the common storage type is returned.
+func GetStorageType(workspace *dw.DevWorkspace) string {
+	storageType := workspace.Spec.Template.Attributes.GetString(constants.DevWorkspaceStorageTypeAttribute, nil)
+	if storageType == "" {
+		return constants.CommonStorageClassType
+	}
+	return storageType
+}
+
+// CheckMigration returns an error if the storage of a DevWorkspace cannot be migrated from storage type fromType to
+// storage type toType, e.g. because either type is unsupported or data would be lost by migrating.
+func CheckMigration(fromType, toType string) error {
+	fromLayout, err := getStorageLayout(fromType)
+	if err != nil {
+		return err
+	}
+	toLayout, err := getStorageLayout(toType)
+	if err != nil {
+		return err
+	}
+	if fromLayout != noLayout && toLayout == noLayout {
+		return fmt.Errorf("cannot migrate from %s storage to %s storage as workspace data would be lost", fromType, toType)
+	}
+	return nil
+}
+
+// MigrateStorage moves the persistent data of a stopped DevWorkspace from where it is stored for storage type fromType
+// to where it is stored for the DevWorkspace's current storage type. Data is copied by a Job, after which the data in
+// the old location is removed.
+//
+// Returns nil if migration is complete (or no migration is necessary), NotReadyError if migration is in progress,
+// ProvisioningError if the storage cannot be migrated or the migration failed, and any other error if an unexpected
+// problem arises.
+func MigrateStorage(workspace *dw.DevWorkspace, fromType string, clusterAPI sync.ClusterAPI) error {
+	toType := GetStorageType(workspace)
+	if err := CheckMigration(fromType, toType); err != nil {
+		return &ProvisioningError{Message: "Cannot migrate DevWorkspace storage", Err: err}
+	}
+	fromLayout, _ := getStorageLayout(fromType)
+	toLayout, _ := getStorageLayout(toType)
+	if fromLayout == toLayout || fromLayout == noLayout {
+		// Data is already where the new storage type expects it, or there is no data to migrate
+		return nil
+	}
+
+	workspaceId := workspace.Status.DevWorkspaceId
+	var sourcePVCName, sourcePath, targetPVCName, targetPath string
+	switch fromLayout {
+	case commonPVCLayout:
+		sourcePVCName, sourcePath = config.Workspace.PVCName, path.Join(copyJobSourceMountPath, workspaceId)
+	case perWorkspacePVCLayout:
+		sourcePVCName, sourcePath = common.PerWorkspacePVCName(workspaceId), copyJobSourceMountPath
+	}
+
+	sourceExists, err := pvcExists(sourcePVCName, workspace.Namespace, clusterAPI)
+	if err != nil {
+		return err
+	}
+	if !sourceExists {
+		// Nothing to migrate
+		return nil
+	}
+
+	switch toLayout {
+	case commonPVCLayout:
+		if _, err := syncCommonPVC(workspace.Namespace, clusterAPI); err != nil {
+			return err
+		}
+		targetPVCName, targetPath = config.Workspace.PVCName, path.Join(copyJobTargetMountPath, workspaceId)
+	case perWorkspacePVCLayout:
+		targetPVCName = common.PerWorkspacePVCName(workspaceId)
+		if _, err := syncPerWorkspacePVC(targetPVCName, workspace, clusterAPI); err != nil {
+			return err
+		}
+		targetPath = copyJobTargetMountPath
+	}
+
+	// When data is stored in a PVC dedicated to the workspace, the PVC is deleted after migration instead
+	cleanupSource := fromLayout == commonPVCLayout
+	specJob, err := getSpecCopyJob(workspace, common.StorageMigrationJobName(workspaceId), sourcePVCName, sourcePath, targetPVCName, targetPath, cleanupSource, clusterAPI)
+	if err != nil {
+		return err
+	}
+	clusterJob, err := runCopyJob(specJob, "storage migration", clusterAPI)
+	if err != nil {
+		return err
+	}
+	if fromLayout == perWorkspacePVCLayout {
+		if err := deletePVC(sourcePVCName, workspace.Namespace, clusterAPI); err != nil {
+			return err
+		}
+	}
+	return deleteJob(clusterJob, clusterAPI)
+}
+
+func getStorageLayout(storageType string) (storageLayout, error) {
+	switch storageType {
+	case "", constants.CommonStorageClassType, constants.AsyncStorageClassType:
+		return commonPVCLayout, nil
+	case constants.PerWorkspaceStorageClassType:
+		return perWorkspacePVCLayout, nil
+	case constants.EphemeralStorageClassType:
+		return noLayout, nil
+	default:
+		return noLayout, fmt.Errorf("%w: %s", UnsupportedStorageStrategy, storageType)
+	}
+}
+
+// getSpecCopyJob returns a job that copies the contents of sourcePath in PVC sourcePVCName to targetPath in PVC
+// targetPVCName. Paths must be within the mount paths used for the source and target PVC. If cleanupSource is true, the
+// source path is removed once it has been copied.
+func getSpecCopyJob(workspace *dw.DevWorkspace, jobName, sourcePVCName, sourcePath, targetPVCName, targetPath string, cleanupSource bool, clusterAPI sync.ClusterAPI) (*batchv1.Job, error) {
+	workspaceId := workspace.Status.DevWorkspaceId
+	jobLabels := map[string]string{
+		constants.DevWorkspaceIDLabel: workspaceId,
+	}
+	if restrictedAccess, needsRestrictedAccess := workspace.Annotations[constants.DevWorkspaceRestrictedAccessAnnotation]; needsRestrictedAccess {
+		jobLabels[constants.DevWorkspaceRestrictedAccessAnnotation] = restrictedAccess
+	}
+
+	script := fmt.Sprintf("if [ -d %[1]s ]; then mkdir -p %[2]s && cp -a %[1]s/. %[2]s/", sourcePath, targetPath)
+	if cleanupSource {
+		script = fmt.Sprintf("%s && rm -rf %s", script, sourcePath)
+	}
+	script = script + "; fi"
+
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      jobName,
+			Namespace: workspace.Namespace,
+			Labels:    jobLabels,
+		},
+		Spec: batchv1.JobSpec{
+			Completions:  &cleanupJobCompletions,
+			BackoffLimit: &cleanupJobBackoffLimit,
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					RestartPolicy:   "Never",
+					SecurityContext: wsprovision.GetDevWorkspaceSecurityContext(),
+					Volumes: []corev1.Volume{
+						{
+							Name: copyJobSourceVolume,
+							VolumeSource: corev1.VolumeSource{
+								PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+									ClaimName: sourcePVCName,
+								},
+							},
+						},
+						{
+							Name: copyJobTargetVolume,
+							VolumeSource: corev1.VolumeSource{
+								PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+									ClaimName: targetPVCName,
+								},
+							},
+						},
+					},
+					Containers: []corev1.Container{
+						{
+							Name:    jobName,
+							Image:   images.GetPVCCleanupJobImage(),
+							Command: []string{"/bin/sh"},
+							Args:    []string{"-c", script},
+							Resources: corev1.ResourceRequirements{
+								Requests: corev1.ResourceList{
+									corev1.ResourceMemory: pvcCleanupPodMemoryRequest,
+									corev1.ResourceCPU:    pvcCleanupPodCPURequest,
+								},
+								Limits: corev1.ResourceList{
+									corev1.ResourceMemory: pvcCleanupPodMemoryLimit,
+									corev1.ResourceCPU:    pvcCleanupPodCPULimit,
+								},
+							},
+							VolumeMounts: []corev1.VolumeMount{
+								{
+									Name:      copyJobSourceVolume,
+									MountPath: copyJobSourceMountPath,
+								},
+								{
+									Name:      copyJobTargetVolume,
+									MountPath: copyJobTargetMountPath,
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	err := controllerutil.SetControllerReference(workspace, job, clusterAPI.Scheme)
+	if err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+// runCopyJob syncs a job returned by getSpecCopyJob to the cluster and returns it once it has completed. Returns
+// NotReadyError if the job is still running and ProvisioningError if it has failed. Failed jobs are deleted from the
+// cluster so that the job is retried on the next attempt. The description is used in messages returned to the user.
+func runCopyJob(specJob *batchv1.Job, description string, clusterAPI sync.ClusterAPI) (*batchv1.Job, error) {
+	clusterObj, err := sync.SyncObjectWithCluster(specJob, clusterAPI)
+	switch t := err.(type) {
+	case nil:
+		break
+	case *sync.NotInSyncError:
+		return nil, &NotReadyError{
+			Message:      fmt.Sprintf("Starting %s job", description),
+			RequeueAfter: 1 * time.Second,
+		}
+	case *sync.UnrecoverableSyncError:
+		return nil, &ProvisioningError{Message: fmt.Sprintf("Failed to sync %s job with cluster", description), Err: t.Cause}
+	default:
+		return nil, err
+	}
+
+	clusterJob := clusterObj.(*batchv1.Job)
+	for _, condition := range clusterJob.Status.Conditions {
+		if condition.Status != corev1.ConditionTrue {
+			continue
+		}
+		switch condition.Type {
+		case batchv1.JobComplete:
+			return clusterJob, nil
+		case batchv1.JobFailed:
+			// Remove the failed job so that it is recreated the next time the DevWorkspace is reconciled; otherwise,
+			// the failed job would be found again on every subsequent attempt.
+			if err := deleteJob(clusterJob, clusterAPI); err != nil {
+				return nil, err
+			}
+			return nil, &ProvisioningError{
+				Message: fmt.Sprintf("DevWorkspace %s job %q failed: %s", description, clusterJob.Name, condition.Message),
+			}
+		}
+	}
+	return nil, &NotReadyError{
+		Message:      fmt.Sprintf("Waiting for %s job to complete", description),
+		RequeueAfter: 10 * time.Second,
+	}
+}
+
+func deleteJob(job *batchv1.Job, clusterAPI sync.ClusterAPI) error {
+	err := clusterAPI.Client.Delete(clusterAPI.Ctx, job, client.PropagationPolicy(metav1.DeletePropagationBackground))
+	if err != nil && !k8sErrors.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
+
+// pvcExists returns whether a PVC exists and is not being deleted
+func pvcExists(name, namespace string, clusterAPI sync.ClusterAPI) (bool, error) {
+	pvc := &corev1.PersistentVolumeClaim{}
+	err := clusterAPI.Client.Get(clusterAPI.Ctx, types.NamespacedName{Name: name, Namespace: namespace}, pvc)
+	if err != nil {
+		if k8sErrors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return pvc.DeletionTimestamp == nil, nil
+}
+
+func deletePVC(name, namespace string, clusterAPI sync.ClusterAPI) error {
+	pvc := &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+	}
+	err := clusterAPI.Client.Delete(clusterAPI.Ctx, pvc)
+	if err != nil && !k8sErrors.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
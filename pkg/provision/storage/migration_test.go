@@ -0,0 +1,204 @@
+//
+// Copyright (c) 2019-2021 Red Hat, Inc.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package storage
+
+import (
+	"context"
+	"testing"
+
+	dw "github.com/devfile/api/v2/pkg/apis/workspaces/v1alpha2"
+	"github.com/devfile/api/v2/pkg/attributes"
+	"github.com/stretchr/testify/assert"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+
+	"github.com/devfile/devworkspace-operator/pkg/common"
+	"github.com/devfile/devworkspace-operator/pkg/constants"
+	"github.com/devfile/devworkspace-operator/pkg/provision/sync"
+)
+
+func TestCheckMigration(t *testing.T) {
+	tests := []struct {
+		from, to string
+		allowed  bool
+	}{
+		{from: "common", to: "per-workspace", allowed: true},
+		{from: "per-workspace", to: "common", allowed: true},
+		{from: "per-workspace", to: "async", allowed: true},
+		{from: "async", to: "common", allowed: true},
+		{from: "", to: "per-workspace", allowed: true},
+		{from: "ephemeral", to: "common", allowed: true},
+		{from: "common", to: "ephemeral", allowed: false},
+		{from: "per-workspace", to: "ephemeral", allowed: false},
+		{from: "common", to: "unsupported", allowed: false},
+		{from: "unsupported", to: "common", allowed: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.from+" to "+tt.to, func(t *testing.T) {
+			err := CheckMigration(tt.from, tt.to)
+			if tt.allowed {
+				assert.NoError(t, err, "Migration should be allowed")
+			} else {
+				assert.Error(t, err, "Migration should not be allowed")
+			}
+		})
+	}
+}
+
+func TestMigrateStorageFromCommonToPerWorkspace(t *testing.T) {
+	setupControllerCfg()
+	workspace := getTestMigrationDevWorkspace(constants.PerWorkspaceStorageClassType)
+	commonPVC, err := getCommonPVCSpec(workspace.Namespace, "1Gi")
+	if err != nil {
+		t.Fatalf("Failure during setup: %s", err)
+	}
+	clusterAPI := getTestMigrationClusterAPI(commonPVC)
+
+	// First reconcile creates per-workspace PVC
+	err = MigrateStorage(workspace, constants.CommonStorageClassType, clusterAPI)
+	assert.IsType(t, &NotReadyError{}, err, "Should wait for per-workspace PVC to be created")
+	assert.NoError(t, clusterAPI.Client.Get(clusterAPI.Ctx, types.NamespacedName{Name: common.PerWorkspacePVCName(workspace.Status.DevWorkspaceId), Namespace: workspace.Namespace}, &corev1.PersistentVolumeClaim{}),
+		"Per-workspace PVC should be created")
+
+	// Second reconcile creates migration job
+	err = MigrateStorage(workspace, constants.CommonStorageClassType, clusterAPI)
+	if assert.IsType(t, &NotReadyError{}, err, "Should wait for migration job to be created") {
+		assert.NotZero(t, err.(*NotReadyError).RequeueAfter, "Should requeue after creating migration job")
+	}
+	job := getTestMigrationJob(t, workspace.Status.DevWorkspaceId, clusterAPI)
+	assert.Contains(t, job.Spec.Template.Spec.Containers[0].Args[1], "rm -rf /tmp/copy/source/test-workspaceid", "Migration job should clean up workspace subpath in common PVC")
+
+	err = MigrateStorage(workspace, constants.CommonStorageClassType, clusterAPI)
+	assert.IsType(t, &NotReadyError{}, err, "Should wait for migration job to complete")
+
+	setTestJobCondition(t, job, batchv1.JobComplete, clusterAPI)
+	assert.NoError(t, MigrateStorage(workspace, constants.CommonStorageClassType, clusterAPI), "Migration should be complete")
+	err = clusterAPI.Client.Get(clusterAPI.Ctx, types.NamespacedName{Name: job.Name, Namespace: job.Namespace}, &batchv1.Job{})
+	assert.True(t, k8sErrors.IsNotFound(err), "Migration job should be deleted")
+}
+
+func TestMigrateStorageFromPerWorkspaceToCommon(t *testing.T) {
+	setupControllerCfg()
+	workspace := getTestMigrationDevWorkspace(constants.CommonStorageClassType)
+	commonPVC, err := getCommonPVCSpec(workspace.Namespace, "1Gi")
+	if err != nil {
+		t.Fatalf("Failure during setup: %s", err)
+	}
+	perWorkspacePVC, err := getPerWorkspacePVCSpec(common.PerWorkspacePVCName(workspace.Status.DevWorkspaceId), workspace)
+	if err != nil {
+		t.Fatalf("Failure during setup: %s", err)
+	}
+	clusterAPI := getTestMigrationClusterAPI(commonPVC, perWorkspacePVC)
+
+	err = MigrateStorage(workspace, constants.PerWorkspaceStorageClassType, clusterAPI)
+	assert.IsType(t, &NotReadyError{}, err, "Should wait for migration job to be created")
+	job := getTestMigrationJob(t, workspace.Status.DevWorkspaceId, clusterAPI)
+	assert.NotContains(t, job.Spec.Template.Spec.Containers[0].Args[1], "rm -rf", "Migration job should not remove data from per-workspace PVC")
+
+	setTestJobCondition(t, job, batchv1.JobComplete, clusterAPI)
+	assert.NoError(t, MigrateStorage(workspace, constants.PerWorkspaceStorageClassType, clusterAPI), "Migration should be complete")
+	err = clusterAPI.Client.Get(clusterAPI.Ctx, types.NamespacedName{Name: perWorkspacePVC.Name, Namespace: perWorkspacePVC.Namespace}, &corev1.PersistentVolumeClaim{})
+	assert.True(t, k8sErrors.IsNotFound(err), "Per-workspace PVC should be deleted")
+}
+
+func TestMigrateStorageReportsFailedJob(t *testing.T) {
+	setupControllerCfg()
+	workspace := getTestMigrationDevWorkspace(constants.CommonStorageClassType)
+	perWorkspacePVC, err := getPerWorkspacePVCSpec(common.PerWorkspacePVCName(workspace.Status.DevWorkspaceId), workspace)
+	if err != nil {
+		t.Fatalf("Failure during setup: %s", err)
+	}
+	commonPVC, err := getCommonPVCSpec(workspace.Namespace, "1Gi")
+	if err != nil {
+		t.Fatalf("Failure during setup: %s", err)
+	}
+	clusterAPI := getTestMigrationClusterAPI(commonPVC, perWorkspacePVC)
+
+	err = MigrateStorage(workspace, constants.PerWorkspaceStorageClassType, clusterAPI)
+	assert.IsType(t, &NotReadyError{}, err, "Should wait for migration job to be created")
+	job := getTestMigrationJob(t, workspace.Status.DevWorkspaceId, clusterAPI)
+	setTestJobCondition(t, job, batchv1.JobFailed, clusterAPI)
+	err = MigrateStorage(workspace, constants.PerWorkspaceStorageClassType, clusterAPI)
+	assert.IsType(t, &ProvisioningError{}, err, "Should return ProvisioningError when migration job fails")
+	err = clusterAPI.Client.Get(clusterAPI.Ctx, types.NamespacedName{Name: job.Name, Namespace: job.Namespace}, &batchv1.Job{})
+	assert.True(t, k8sErrors.IsNotFound(err), "Failed migration job should be deleted")
+	err = MigrateStorage(workspace, constants.PerWorkspaceStorageClassType, clusterAPI)
+	assert.IsType(t, &NotReadyError{}, err, "Should retry migration after job fails")
+	getTestMigrationJob(t, workspace.Status.DevWorkspaceId, clusterAPI)
+}
+
+func TestMigrateStorageDoesNothingWhenNotNeeded(t *testing.T) {
+	setupControllerCfg()
+	tests := []struct {
+		name     string
+		from, to string
+	}{
+		{name: "Same storage layout", from: constants.CommonStorageClassType, to: constants.AsyncStorageClassType},
+		{name: "No persistent data", from: constants.EphemeralStorageClassType, to: constants.PerWorkspaceStorageClassType},
+		{name: "No source PVC", from: constants.PerWorkspaceStorageClassType, to: constants.CommonStorageClassType},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			workspace := getTestMigrationDevWorkspace(tt.to)
+			clusterAPI := getTestMigrationClusterAPI()
+			assert.NoError(t, MigrateStorage(workspace, tt.from, clusterAPI), "Should not need to migrate storage")
+			jobs := &batchv1.JobList{}
+			assert.NoError(t, clusterAPI.Client.List(clusterAPI.Ctx, jobs))
+			assert.Empty(t, jobs.Items, "Should not create migration job")
+		})
+	}
+}
+
+func getTestMigrationDevWorkspace(storageType string) *dw.DevWorkspace {
+	workspace := getTestPerWorkspaceDevWorkspace("test-workspaceid")
+	workspace.Spec.Template.Attributes = attributes.Attributes{}.PutString(constants.DevWorkspaceStorageTypeAttribute, storageType)
+	return workspace
+}
+
+func getTestMigrationClusterAPI(objs ...client.Object) sync.ClusterAPI {
+	return sync.ClusterAPI{
+		Ctx:    context.Background(),
+		Client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(objs...).Build(),
+		Scheme: scheme,
+		Logger: zap.New(),
+	}
+}
+
+func getTestMigrationJob(t *testing.T, workspaceId string, clusterAPI sync.ClusterAPI) *batchv1.Job {
+	job := &batchv1.Job{}
+	err := clusterAPI.Client.Get(clusterAPI.Ctx, types.NamespacedName{Name: common.StorageMigrationJobName(workspaceId), Namespace: "test-namespace"}, job)
+	if err != nil {
+		t.Fatalf("Failed to get migration job: %s", err)
+	}
+	return job
+}
+
+func setTestJobCondition(t *testing.T, job *batchv1.Job, conditionType batchv1.JobConditionType, clusterAPI sync.ClusterAPI) {
+	job.Status.Conditions = []batchv1.JobCondition{
+		{
+			Type:   conditionType,
+			Status: corev1.ConditionTrue,
+		},
+	}
+	if err := clusterAPI.Client.Status().Update(clusterAPI.Ctx, job); err != nil {
+		t.Fatalf("Failed to update job status: %s", err)
+	}
+}
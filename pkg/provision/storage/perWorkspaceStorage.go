@@ -0,0 +1,217 @@
+//
+// Copyright (c) 2019-2021 Red Hat, Inc.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	dw "github.com/devfile/api/v2/pkg/apis/workspaces/v1alpha2"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	"github.com/devfile/devworkspace-operator/apis/controller/v1alpha1"
+	"github.com/devfile/devworkspace-operator/pkg/common"
+	"github.com/devfile/devworkspace-operator/pkg/config"
+	"github.com/devfile/devworkspace-operator/pkg/constants"
+	"github.com/devfile/devworkspace-operator/pkg/provision/sync"
+)
+
+// The PerWorkspaceStorageProvisioner provisions one PVC per workspace and configures all volumes in the workspace
+// to mount on subpaths within that PVC. The PVC is sized according to the workspace's volumes and is owned by the
+// workspace, so no cleanup job is required when the workspace is deleted.
+type PerWorkspaceStorageProvisioner struct{}
+
+var _ Provisioner = (*PerWorkspaceStorageProvisioner)(nil)
+
+func (*PerWorkspaceStorageProvisioner) NeedsStorage(workspace *dw.DevWorkspaceTemplateSpec) bool {
+	return needsStorage(workspace)
+}
+
+func (p *PerWorkspaceStorageProvisioner) ProvisionStorage(podAdditions *v1alpha1.PodAdditions, workspace *dw.DevWorkspace, clusterAPI sync.ClusterAPI) error {
+	// Add ephemeral volumes
+	if err := addEphemeralVolumesFromWorkspace(workspace, podAdditions); err != nil {
+		return err
+	}
+
+	// If persistent storage is not needed, we're done
+	if !p.NeedsStorage(&workspace.Spec.Template) {
+		return nil
+	}
+
+	pvcName := common.PerWorkspacePVCName(workspace.Status.DevWorkspaceId)
+	if err := p.rewriteContainerVolumeMounts(pvcName, podAdditions, &workspace.Spec.Template); err != nil {
+		return &ProvisioningError{
+			Err:     err,
+			Message: "Could not rewrite container volume mounts",
+		}
+	}
+
+	if _, err := syncPerWorkspacePVC(pvcName, workspace, clusterAPI); err != nil {
+		return err
+	}
+	return nil
+}
+
+// CleanupWorkspaceStorage deletes the workspace's PVC. As the PVC is only used by a single workspace, there is no need to
+// run a job to remove the workspace's files from it.
+func (*PerWorkspaceStorageProvisioner) CleanupWorkspaceStorage(workspace *dw.DevWorkspace, clusterAPI sync.ClusterAPI) error {
+	return deletePVC(common.PerWorkspacePVCName(workspace.Status.DevWorkspaceId), workspace.Namespace, clusterAPI)
+}
+
+// rewriteContainerVolumeMounts rewrites the VolumeMounts in a set of PodAdditions according to the 'per-workspace' PVC
+// strategy (i.e. all volume mounts are subpaths into a PVC used only by the current workspace).
+//
+// Also adds appropriate k8s Volumes to PodAdditions to accomodate the rewritten VolumeMounts.
+func (p *PerWorkspaceStorageProvisioner) rewriteContainerVolumeMounts(pvcName string, podAdditions *v1alpha1.PodAdditions, workspace *dw.DevWorkspaceTemplateSpec) error {
+	devfileVolumes, err := getDevfileVolumes(workspace)
+	if err != nil {
+		return err
+	}
+
+	rewriteVolumeMounts := func(containers []corev1.Container) error {
+		for cIdx, container := range containers {
+			for vmIdx, vm := range container.VolumeMounts {
+				volume, ok := devfileVolumes[vm.Name]
+				if !ok {
+					return fmt.Errorf("container '%s' references undefined volume '%s'", container.Name, vm.Name)
+				}
+				if !isEphemeral(&volume) {
+					containers[cIdx].VolumeMounts[vmIdx].SubPath = vm.Name
+					containers[cIdx].VolumeMounts[vmIdx].Name = pvcName
+				}
+			}
+		}
+		return nil
+	}
+	if err := rewriteVolumeMounts(podAdditions.Containers); err != nil {
+		return err
+	}
+	if err := rewriteVolumeMounts(podAdditions.InitContainers); err != nil {
+		return err
+	}
+
+	podAdditions.Volumes = append(podAdditions.Volumes, corev1.Volume{
+		Name: pvcName,
+		VolumeSource: corev1.VolumeSource{
+			PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+				ClaimName: pvcName,
+			},
+		},
+	})
+
+	return nil
+}
+
+// getPerWorkspacePVCSize computes the size of the PVC required for a workspace as the sum of the sizes of all
+// non-ephemeral volumes in the workspace. Volumes that do not define a size count as constants.PVCStorageSize.
+func getPerWorkspacePVCSize(workspace *dw.DevWorkspaceTemplateSpec) (*resource.Quantity, error) {
+	defaultSize := resource.MustParse(constants.PVCStorageSize)
+	totalSize := resource.Quantity{Format: defaultSize.Format}
+
+	for _, component := range workspace.Components {
+		if component.Volume == nil || isEphemeral(component.Volume) {
+			continue
+		}
+		if component.Volume.Size == "" {
+			totalSize.Add(defaultSize)
+			continue
+		}
+		size, err := resource.ParseQuantity(component.Volume.Size)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse size for Volume %s: %w", component.Name, err)
+		}
+		totalSize.Add(size)
+	}
+
+	// Implicit projects volume is not defined in the components list
+	if projectsComponent, needed := processProjectsVolume(workspace); needed && projectsComponent == nil {
+		totalSize.Add(defaultSize)
+	}
+
+	return &totalSize, nil
+}
+
+func getPerWorkspacePVCSpec(pvcName string, workspace *dw.DevWorkspace) (*corev1.PersistentVolumeClaim, error) {
+	pvcSize, err := getPerWorkspacePVCSize(&workspace.Spec.Template)
+	if err != nil {
+		return nil, err
+	}
+
+	return &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      pvcName,
+			Namespace: workspace.Namespace,
+			Labels: map[string]string{
+				constants.DevWorkspaceIDLabel: workspace.Status.DevWorkspaceId,
+			},
+		},
+		Spec: corev1.PersistentVolumeClaimSpec{
+			AccessModes: []corev1.PersistentVolumeAccessMode{
+				corev1.ReadWriteOnce,
+			},
+			Resources: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{
+					"storage": *pvcSize,
+				},
+			},
+			StorageClassName: config.Workspace.StorageClassName,
+		},
+	}, nil
+}
+
+func syncPerWorkspacePVC(pvcName string, workspace *dw.DevWorkspace, clusterAPI sync.ClusterAPI) (*corev1.PersistentVolumeClaim, error) {
+	pvc, err := getPerWorkspacePVCSpec(pvcName, workspace)
+	if err != nil {
+		return nil, &ProvisioningError{
+			Message: "Failed to compute size of workspace PVC",
+			Err:     err,
+		}
+	}
+	if err := controllerutil.SetControllerReference(workspace, pvc, clusterAPI.Scheme); err != nil {
+		return nil, err
+	}
+	if err := limitPVCExpansion(pvc, clusterAPI); err != nil {
+		return nil, err
+	}
+
+	currObject, err := sync.SyncObjectWithCluster(pvc, clusterAPI)
+	switch t := err.(type) {
+	case nil:
+		break
+	case *sync.NotInSyncError:
+		return nil, &NotReadyError{
+			Message:      "Updated workspace PVC on cluster",
+			RequeueAfter: 1 * time.Second,
+		}
+	case *sync.UnrecoverableSyncError:
+		return nil, &ProvisioningError{
+			Message: "failed to sync PVC to cluster",
+			Err:     t.Cause,
+		}
+	default:
+		return nil, err
+	}
+
+	currPVC, ok := currObject.(*corev1.PersistentVolumeClaim)
+	if !ok {
+		return nil, errors.New("tried to sync PVC to cluster but did not get a PVC back")
+	}
+	return currPVC, nil
+}
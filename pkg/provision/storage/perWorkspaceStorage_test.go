@@ -0,0 +1,114 @@
+//
+// Copyright (c) 2019-2021 Red Hat, Inc.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package storage
+
+import (
+	"context"
+	"testing"
+
+	dw "github.com/devfile/api/v2/pkg/apis/workspaces/v1alpha2"
+	"github.com/google/go-cmp/cmp"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+
+	"github.com/devfile/devworkspace-operator/pkg/common"
+	"github.com/devfile/devworkspace-operator/pkg/provision/sync"
+)
+
+func TestRewriteContainerVolumeMountsForPerWorkspaceStorageClass(t *testing.T) {
+	tests := loadAllTestCasesOrPanic(t, "testdata/per-workspace-storage")
+	setupControllerCfg()
+	perWorkspaceStorage := PerWorkspaceStorageProvisioner{}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			// sanity check that file is read correctly.
+			assert.NotNil(t, tt.Input.Workspace, "Input does not define workspace")
+			workspace := getTestPerWorkspaceDevWorkspace(tt.Input.DevWorkspaceID)
+			workspace.Spec.Template = *tt.Input.Workspace
+			clusterAPI := sync.ClusterAPI{
+				Ctx:    context.Background(),
+				Client: fake.NewClientBuilder().WithScheme(scheme).Build(),
+				Scheme: scheme,
+				Logger: zap.New(),
+			}
+
+			err := perWorkspaceStorage.ProvisionStorage(&tt.Input.PodAdditions, workspace, clusterAPI)
+			if tt.Output.ErrRegexp != nil && assert.Error(t, err) {
+				assert.Regexp(t, *tt.Output.ErrRegexp, err.Error(), "Error message should match")
+				return
+			}
+			if tt.Output.PVCSize != nil {
+				// PVC is created on the first call, so storage is not yet ready
+				if assert.IsType(t, &NotReadyError{}, err, "Should return NotReadyError when PVC is created") {
+					assert.NotZero(t, err.(*NotReadyError).RequeueAfter, "Should requeue after PVC is created")
+				}
+				pvc := &corev1.PersistentVolumeClaim{}
+				pvcNamespacedName := types.NamespacedName{Name: common.PerWorkspacePVCName(tt.Input.DevWorkspaceID), Namespace: workspace.Namespace}
+				if assert.NoError(t, clusterAPI.Client.Get(clusterAPI.Ctx, pvcNamespacedName, pvc), "PVC should be created on cluster") {
+					expectedSize := resource.MustParse(*tt.Output.PVCSize)
+					actualSize := pvc.Spec.Resources.Requests[corev1.ResourceStorage]
+					assert.Zero(t, expectedSize.Cmp(actualSize), "PVC size should be %s, found %s", expectedSize.String(), actualSize.String())
+					if assert.Len(t, pvc.OwnerReferences, 1, "PVC should be owned by DevWorkspace") {
+						assert.Equal(t, workspace.UID, pvc.OwnerReferences[0].UID, "PVC should be owned by DevWorkspace")
+					}
+				}
+			} else if !assert.NoError(t, err, "Should not return error") {
+				return
+			}
+			sortVolumesAndVolumeMounts(&tt.Output.PodAdditions)
+			sortVolumesAndVolumeMounts(&tt.Input.PodAdditions)
+			assert.Equal(t, tt.Output.PodAdditions, tt.Input.PodAdditions,
+				"PodAdditions should match expected output: Diff: %s", cmp.Diff(tt.Output.PodAdditions, tt.Input.PodAdditions))
+		})
+	}
+}
+
+func TestCleanupPerWorkspaceStorageDeletesPVC(t *testing.T) {
+	setupControllerCfg()
+	workspace := getTestPerWorkspaceDevWorkspace("test-workspaceid")
+	pvc, err := getPerWorkspacePVCSpec(common.PerWorkspacePVCName(workspace.Status.DevWorkspaceId), workspace)
+	if err != nil {
+		t.Fatalf("Failure during setup: %s", err)
+	}
+	clusterAPI := sync.ClusterAPI{
+		Ctx:    context.Background(),
+		Client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(pvc).Build(),
+		Scheme: scheme,
+		Logger: zap.New(),
+	}
+	provisioner := PerWorkspaceStorageProvisioner{}
+
+	assert.NoError(t, provisioner.CleanupWorkspaceStorage(workspace, clusterAPI), "Should not return error")
+	err = clusterAPI.Client.Get(clusterAPI.Ctx, types.NamespacedName{Name: pvc.Name, Namespace: pvc.Namespace}, &corev1.PersistentVolumeClaim{})
+	assert.True(t, k8sErrors.IsNotFound(err), "PVC should be deleted")
+
+	assert.NoError(t, provisioner.CleanupWorkspaceStorage(workspace, clusterAPI), "Should not return error if PVC does not exist")
+}
+
+func getTestPerWorkspaceDevWorkspace(workspaceId string) *dw.DevWorkspace {
+	workspace := &dw.DevWorkspace{}
+	workspace.Name = "test-workspace"
+	workspace.Namespace = "test-namespace"
+	workspace.UID = "test-uid"
+	workspace.Status.DevWorkspaceId = workspaceId
+	return workspace
+}
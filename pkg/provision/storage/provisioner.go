@@ -41,13 +41,8 @@ type Provisioner interface {
 
 // GetProvisioner returns the storage provisioner that should be used for the current workspace
 func GetProvisioner(workspace *dw.DevWorkspace) (Provisioner, error) {
-	// TODO: Figure out what to do if a workspace changes the storage type after its been created
-	// e.g. common -> async so as to not leave files on PVCs after removal. Maybe block changes to
-	// this label via webhook?
-	storageClass := workspace.Spec.Template.Attributes.GetString(constants.DevWorkspaceStorageTypeAttribute, nil)
-	if storageClass == "" {
-		return &CommonStorageProvisioner{}, nil
-	}
+	// Changes to the storage type of existing workspaces are handled by MigrateStorage
+	storageClass := GetStorageType(workspace)
 	switch storageClass {
 	case constants.CommonStorageClassType:
 		return &CommonStorageProvisioner{}, nil
@@ -55,6 +50,8 @@ func GetProvisioner(workspace *dw.DevWorkspace) (Provisioner, error) {
 		return &AsyncStorageProvisioner{}, nil
 	case constants.EphemeralStorageClassType:
 		return &EphemeralStorageProvisioner{}, nil
+	case constants.PerWorkspaceStorageClassType:
+		return &PerWorkspaceStorageProvisioner{}, nil
 	default:
 		return nil, UnsupportedStorageStrategy
 	}
@@ -23,8 +23,12 @@ import (
 	"github.com/devfile/devworkspace-operator/pkg/provision/sync"
 
 	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	"github.com/devfile/devworkspace-operator/apis/controller/v1alpha1"
 	"github.com/devfile/devworkspace-operator/pkg/config"
@@ -64,6 +68,28 @@ func isEphemeral(volume *dw.VolumeComponent) bool {
 	return volume.Ephemeral != nil && *volume.Ephemeral
 }
 
+// getDevfileVolumes returns a map of volume name to volume component for all volumes in a workspace, including the
+// implicit projects volume if it is not defined explicitly.
+func getDevfileVolumes(workspace *dw.DevWorkspaceTemplateSpec) (map[string]dw.VolumeComponent, error) {
+	devfileVolumes := map[string]dw.VolumeComponent{}
+	for _, component := range workspace.Components {
+		if component.Volume != nil {
+			if _, exists := devfileVolumes[component.Name]; exists {
+				return nil, fmt.Errorf("volume component '%s' is defined multiple times", component.Name)
+			}
+			devfileVolumes[component.Name] = *component.Volume
+		}
+	}
+
+	// Add implicit projects volume to support mountSources, if needed
+	if _, exists := devfileVolumes[devfileConstants.ProjectsVolumeName]; !exists {
+		projectsVolume := dw.VolumeComponent{}
+		projectsVolume.Size = constants.PVCStorageSize
+		devfileVolumes[devfileConstants.ProjectsVolumeName] = projectsVolume
+	}
+	return devfileVolumes, nil
+}
+
 // needsStorage returns true if storage will need to be provisioned for the current workspace. Note that ephemeral volumes
 // do not need to provision storage
 func needsStorage(workspace *dw.DevWorkspaceTemplateSpec) bool {
@@ -88,19 +114,18 @@ func needsStorage(workspace *dw.DevWorkspaceTemplateSpec) bool {
 }
 
 func syncCommonPVC(namespace string, clusterAPI sync.ClusterAPI) (*corev1.PersistentVolumeClaim, error) {
-	namespacedConfig, err := nsconfig.ReadNamespacedConfig(namespace, clusterAPI)
+	pvcSize, err := getCommonPVCSize(namespace, clusterAPI)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read namespace-specific configuration: %w", err)
-	}
-	pvcSize := constants.PVCStorageSize
-	if namespacedConfig != nil && namespacedConfig.CommonPVCSize != "" {
-		pvcSize = namespacedConfig.CommonPVCSize
+		return nil, err
 	}
 
 	pvc, err := getCommonPVCSpec(namespace, pvcSize)
 	if err != nil {
 		return nil, err
 	}
+	if err := limitPVCExpansion(pvc, clusterAPI); err != nil {
+		return nil, err
+	}
 	currObject, err := sync.SyncObjectWithCluster(pvc, clusterAPI)
 	switch t := err.(type) {
 	case nil:
@@ -131,6 +156,104 @@ func syncCommonPVC(namespace string, clusterAPI sync.ClusterAPI) (*corev1.Persis
 	return currPVC, nil
 }
 
+// getCommonPVCSize returns the size of the common PVC in a namespace. A size set in the per-namespace configmap takes
+// precedence over the size set in the DevWorkspace Operator configuration. If the DevWorkspace Operator is configured to
+// compute the size of the common PVC, the size is increased to fit the volumes of all DevWorkspaces in the namespace
+// that store their data on the common PVC.
+func getCommonPVCSize(namespace string, clusterAPI sync.ClusterAPI) (string, error) {
+	namespacedConfig, err := nsconfig.ReadNamespacedConfig(namespace, clusterAPI)
+	if err != nil {
+		return "", fmt.Errorf("failed to read namespace-specific configuration: %w", err)
+	}
+	if namespacedConfig != nil && namespacedConfig.CommonPVCSize != "" {
+		return namespacedConfig.CommonPVCSize, nil
+	}
+
+	pvcSize := resource.MustParse(constants.PVCStorageSize)
+	if config.Workspace.CommonPVCSize != nil {
+		pvcSize = config.Workspace.CommonPVCSize.DeepCopy()
+	}
+	if config.Workspace.ComputeCommonPVCSize != nil && *config.Workspace.ComputeCommonPVCSize {
+		requiredSize, err := getRequiredCommonPVCSize(namespace, clusterAPI)
+		if err != nil {
+			return "", err
+		}
+		if requiredSize.Cmp(pvcSize) > 0 {
+			pvcSize = *requiredSize
+		}
+	}
+	return pvcSize.String(), nil
+}
+
+// getRequiredCommonPVCSize computes the size of the common PVC required to fit the volumes of all DevWorkspaces in a
+// namespace that store their data on the common PVC, as the sum of the sizes computed by getPerWorkspacePVCSize.
+func getRequiredCommonPVCSize(namespace string, clusterAPI sync.ClusterAPI) (*resource.Quantity, error) {
+	workspaces := &dw.DevWorkspaceList{}
+	if err := clusterAPI.Client.List(clusterAPI.Ctx, workspaces, client.InNamespace(namespace)); err != nil {
+		return nil, err
+	}
+	totalSize := resource.Quantity{Format: resource.BinarySI}
+	for _, workspace := range workspaces.Items {
+		layout, err := getStorageLayout(getProvisionedStorageType(&workspace))
+		if err != nil || layout != commonPVCLayout || !needsStorage(&workspace.Spec.Template) {
+			continue
+		}
+		workspaceSize, err := getPerWorkspacePVCSize(&workspace.Spec.Template)
+		if err != nil {
+			return nil, &ProvisioningError{
+				Message: fmt.Sprintf("Failed to compute storage required by DevWorkspace %s", workspace.Name),
+				Err:     err,
+			}
+		}
+		totalSize.Add(*workspaceSize)
+	}
+	return &totalSize, nil
+}
+
+// limitPVCExpansion prevents expanding a PVC that already exists on the cluster if its storage class does not allow
+// volume expansion, in which case the API server would reject the update. If the PVC cannot be expanded, the storage
+// requested by pvc is reset to the size of the existing PVC.
+func limitPVCExpansion(pvc *corev1.PersistentVolumeClaim, clusterAPI sync.ClusterAPI) error {
+	clusterPVC := &corev1.PersistentVolumeClaim{}
+	err := clusterAPI.Client.Get(clusterAPI.Ctx, types.NamespacedName{Name: pvc.Name, Namespace: pvc.Namespace}, clusterPVC)
+	if err != nil {
+		if k8sErrors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+	specSize := pvc.Spec.Resources.Requests[corev1.ResourceStorage]
+	clusterSize := clusterPVC.Spec.Resources.Requests[corev1.ResourceStorage]
+	if specSize.Cmp(clusterSize) <= 0 {
+		return nil
+	}
+	expandable, err := storageClassAllowsExpansion(clusterPVC.Spec.StorageClassName, clusterAPI)
+	if err != nil {
+		return err
+	}
+	if !expandable {
+		clusterAPI.Logger.V(1).Info("Not expanding PVC as its storage class does not allow volume expansion",
+			"name", pvc.Name, "size", clusterSize.String(), "requestedSize", specSize.String())
+		pvc.Spec.Resources.Requests[corev1.ResourceStorage] = clusterSize
+	}
+	return nil
+}
+
+func storageClassAllowsExpansion(storageClassName *string, clusterAPI sync.ClusterAPI) (bool, error) {
+	if storageClassName == nil || *storageClassName == "" {
+		return false, nil
+	}
+	storageClass := &storagev1.StorageClass{}
+	err := clusterAPI.Client.Get(clusterAPI.Ctx, types.NamespacedName{Name: *storageClassName}, storageClass)
+	if err != nil {
+		if k8sErrors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return storageClass.AllowVolumeExpansion != nil && *storageClass.AllowVolumeExpansion, nil
+}
+
 // addEphemeralVolumesFromWorkspace adds emptyDir volumes for all ephemeral volume components required for a devworkspace.
 // This includes any volume components marked with the ephemeral field, including projects.
 // Returns a ProvisioningError if any ephemeral volume cannot be parsed (e.g. cannot parse size for kubernetes)
@@ -148,31 +271,83 @@ func addEphemeralVolumesFromWorkspace(workspace *dw.DevWorkspace, podAdditions *
 	return nil
 }
 
-// addEphemeralVolumesToPodAdditions adds emptyDir volumes to podAdditions for each volume in workspaceVolumes.
-// Returns a non-nil error if the size field of a volume is unparseable; otherwise, the list of k8s volumes that
-// were added are returned.
+// addEphemeralVolumesToPodAdditions adds ephemeral volumes to podAdditions for each volume in workspaceVolumes.
+// Returns a non-nil error if the size field or attributes of a volume are invalid; otherwise, the list of k8s volumes
+// that were added are returned.
 func addEphemeralVolumesToPodAdditions(podAdditions *v1alpha1.PodAdditions, workspaceVolumes []dw.Component) (addedVolumes []corev1.Volume, err error) {
 	for _, component := range workspaceVolumes {
 		if component.Volume == nil {
 			continue
 		}
-		vol := corev1.Volume{
+		vol, err := getEphemeralVolume(component)
+		if err != nil {
+			return nil, err
+		}
+		podAdditions.Volumes = append(podAdditions.Volumes, *vol)
+		addedVolumes = append(addedVolumes, *vol)
+	}
+	return addedVolumes, nil
+}
+
+// getEphemeralVolume returns the volume used to provide an ephemeral volume component. By default, an emptyDir volume
+// limited to the component's size is used. If the volume component specifies a storage class through the
+// EphemeralVolumeStorageClassAttribute attribute, a generic ephemeral volume using that storage class is used instead,
+// and if it specifies the "Memory" medium through the EphemeralVolumeMediumAttribute attribute, the emptyDir volume is
+// backed by memory.
+func getEphemeralVolume(component dw.Component) (*corev1.Volume, error) {
+	var size *resource.Quantity
+	if component.Volume.Size != "" {
+		sizeResource, err := resource.ParseQuantity(component.Volume.Size)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse size for Volume %s: %w", component.Name, err)
+		}
+		size = &sizeResource
+	}
+	medium := component.Attributes.GetString(constants.EphemeralVolumeMediumAttribute, nil)
+	storageClass := component.Attributes.GetString(constants.EphemeralVolumeStorageClassAttribute, nil)
+
+	switch {
+	case storageClass != "" && medium != "":
+		return nil, fmt.Errorf("volume %s cannot specify both attributes %s and %s", component.Name,
+			constants.EphemeralVolumeMediumAttribute, constants.EphemeralVolumeStorageClassAttribute)
+	case storageClass != "":
+		if size == nil {
+			defaultSize := resource.MustParse(constants.PVCStorageSize)
+			size = &defaultSize
+		}
+		return &corev1.Volume{
 			Name: component.Name,
 			VolumeSource: corev1.VolumeSource{
-				EmptyDir: &corev1.EmptyDirVolumeSource{},
+				Ephemeral: &corev1.EphemeralVolumeSource{
+					VolumeClaimTemplate: &corev1.PersistentVolumeClaimTemplate{
+						Spec: corev1.PersistentVolumeClaimSpec{
+							AccessModes: []corev1.PersistentVolumeAccessMode{
+								corev1.ReadWriteOnce,
+							},
+							Resources: corev1.ResourceRequirements{
+								Requests: corev1.ResourceList{
+									corev1.ResourceStorage: *size,
+								},
+							},
+							StorageClassName: &storageClass,
+						},
+					},
+				},
 			},
-		}
-		if component.Volume.Size != "" {
-			sizeResource, err := resource.ParseQuantity(component.Volume.Size)
-			if err != nil {
-				return nil, fmt.Errorf("failed to parse size for Volume %s: %w", component.Name, err)
-			}
-			vol.EmptyDir.SizeLimit = &sizeResource
-		}
-		podAdditions.Volumes = append(podAdditions.Volumes, vol)
-		addedVolumes = append(addedVolumes, vol)
+		}, nil
+	case medium != "" && medium != string(corev1.StorageMediumMemory):
+		return nil, fmt.Errorf("unsupported value %q for attribute %s on volume %s; only %q is supported", medium,
+			constants.EphemeralVolumeMediumAttribute, component.Name, corev1.StorageMediumMemory)
 	}
-	return addedVolumes, nil
+	return &corev1.Volume{
+		Name: component.Name,
+		VolumeSource: corev1.VolumeSource{
+			EmptyDir: &corev1.EmptyDirVolumeSource{
+				Medium:    corev1.StorageMedium(medium),
+				SizeLimit: size,
+			},
+		},
+	}, nil
 }
 
 // getWorkspaceVolumes returns all volumes defined in the DevWorkspace, separated out into persistent volumes, ephemeral
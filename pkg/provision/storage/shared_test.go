@@ -0,0 +1,149 @@
+//
+// Copyright (c) 2019-2021 Red Hat, Inc.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package storage
+
+import (
+	"testing"
+
+	dw "github.com/devfile/api/v2/pkg/apis/workspaces/v1alpha2"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/devfile/devworkspace-operator/apis/controller/v1alpha1"
+	"github.com/devfile/devworkspace-operator/pkg/config"
+	"github.com/devfile/devworkspace-operator/pkg/constants"
+)
+
+func TestSyncCommonPVCExpandsPVC(t *testing.T) {
+	tests := []struct {
+		name                 string
+		allowVolumeExpansion bool
+		expectedSize         string
+	}{
+		{
+			name:                 "Expands PVC when storage class allows expansion",
+			allowVolumeExpansion: true,
+			expectedSize:         "5Gi",
+		},
+		{
+			name:                 "Does not expand PVC when storage class does not allow expansion",
+			allowVolumeExpansion: false,
+			expectedSize:         "1Gi",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pvcSize := resource.MustParse("5Gi")
+			setupTestCommonPVCConfig(&pvcSize, false)
+			storageClass := &storagev1.StorageClass{}
+			storageClass.Name = "test-storage-class"
+			storageClass.AllowVolumeExpansion = &tt.allowVolumeExpansion
+			commonPVC, err := getCommonPVCSpec("test-namespace", "1Gi")
+			if err != nil {
+				t.Fatalf("Failure during setup: %s", err)
+			}
+			commonPVC.Spec.StorageClassName = &storageClass.Name
+			clusterAPI := getTestMigrationClusterAPI(storageClass, commonPVC)
+
+			_, err = syncCommonPVC("test-namespace", clusterAPI)
+			if tt.allowVolumeExpansion {
+				assert.IsType(t, &NotReadyError{}, err, "Should update PVC on cluster")
+			} else {
+				assert.NoError(t, err, "Should not update PVC on cluster")
+			}
+			clusterPVC := &corev1.PersistentVolumeClaim{}
+			if err := clusterAPI.Client.Get(clusterAPI.Ctx, types.NamespacedName{Name: commonPVC.Name, Namespace: commonPVC.Namespace}, clusterPVC); err != nil {
+				t.Fatalf("Failed to get PVC: %s", err)
+			}
+			clusterSize := clusterPVC.Spec.Resources.Requests[corev1.ResourceStorage]
+			assert.Equal(t, tt.expectedSize, clusterSize.String())
+		})
+	}
+}
+
+func TestSyncCommonPVCDoesNotShrinkPVC(t *testing.T) {
+	pvcSize := resource.MustParse("1Gi")
+	setupTestCommonPVCConfig(&pvcSize, false)
+	commonPVC, err := getCommonPVCSpec("test-namespace", "5Gi")
+	if err != nil {
+		t.Fatalf("Failure during setup: %s", err)
+	}
+	clusterAPI := getTestMigrationClusterAPI(commonPVC)
+
+	clusterPVC, err := syncCommonPVC("test-namespace", clusterAPI)
+	if assert.NoError(t, err, "Should not update PVC on cluster") {
+		clusterSize := clusterPVC.Spec.Resources.Requests[corev1.ResourceStorage]
+		assert.Equal(t, "5Gi", clusterSize.String(), "PVC should not be shrunk")
+	}
+}
+
+func TestGetCommonPVCSizeComputedFromVolumes(t *testing.T) {
+	pvcSize := resource.MustParse("2Gi")
+	setupTestCommonPVCConfig(&pvcSize, true)
+	workspace := getTestMigrationDevWorkspace(constants.CommonStorageClassType)
+	workspace.Spec.Template.Components = []dw.Component{
+		getTestVolumeComponent("projects", "3Gi"),
+		getTestVolumeComponent("m2", ""),
+	}
+	otherWorkspace := getTestMigrationDevWorkspace(constants.AsyncStorageClassType)
+	otherWorkspace.Name = "other-workspace"
+	otherWorkspace.Spec.Template.Components = []dw.Component{
+		getTestVolumeComponent("projects", "2Gi"),
+	}
+	perWorkspace := getTestMigrationDevWorkspace(constants.PerWorkspaceStorageClassType)
+	perWorkspace.Name = "per-workspace"
+	perWorkspace.Spec.Template.Components = []dw.Component{
+		getTestVolumeComponent("projects", "100Gi"),
+	}
+
+	clusterAPI := getTestMigrationClusterAPI(workspace, otherWorkspace, perWorkspace)
+	size, err := getCommonPVCSize("test-namespace", clusterAPI)
+	if assert.NoError(t, err) {
+		assert.Equal(t, "6Gi", size, "Common PVC should fit volumes of all DevWorkspaces using it")
+	}
+
+	clusterAPI = getTestMigrationClusterAPI(perWorkspace)
+	size, err = getCommonPVCSize("test-namespace", clusterAPI)
+	if assert.NoError(t, err) {
+		assert.Equal(t, "2Gi", size, "Common PVC should not be smaller than configured size")
+	}
+}
+
+func setupTestCommonPVCConfig(commonPVCSize *resource.Quantity, computeSize bool) {
+	config.SetConfigForTesting(&v1alpha1.OperatorConfiguration{
+		Workspace: &v1alpha1.WorkspaceConfig{
+			ImagePullPolicy:      "Always",
+			CommonPVCSize:        commonPVCSize,
+			ComputeCommonPVCSize: &computeSize,
+		},
+	})
+}
+
+func getTestVolumeComponent(name, size string) dw.Component {
+	return dw.Component{
+		Name: name,
+		ComponentUnion: dw.ComponentUnion{
+			Volume: &dw.VolumeComponent{
+				Volume: dw.Volume{
+					Size: size,
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,94 @@
+//
+// Copyright (c) 2019-2021 Red Hat, Inc.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package storage
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	batchv1 "k8s.io/api/batch/v1"
+
+	"github.com/devfile/devworkspace-operator/pkg/common"
+	"github.com/devfile/devworkspace-operator/pkg/config"
+	"github.com/devfile/devworkspace-operator/pkg/provision/sync"
+)
+
+// maxTerminationMessageLength is the maximum length of a container's termination message. Longer termination logs
+// are truncated by the kubelet, which keeps only the end of the file.
+const maxTerminationMessageLength = 4096
+
+// storageUsageScript writes the disk usage in bytes of each workspace directory on the common PVC to the termination
+// log, as space-separated "<workspace-id>=<bytes>" pairs. Workspaces that do not fit within the termination message
+// length limit (including the trailing newline) are omitted, so that the termination log is never truncated.
+var storageUsageScript = fmt.Sprintf(`cd "$PVC_PATH" || exit 1
+usage=""
+for dir in workspace*/; do
+  [ -d "$dir" ] || continue
+  id="${dir%%/}"
+  entry=" $id=$(du -sb "$id" | cut -f1)"
+  [ $(( ${#usage} + ${#entry} )) -lt %d ] || break
+  usage="$usage$entry"
+done
+echo "$usage" > /dev/termination-log
+`, maxTerminationMessageLength-1)
+
+// MeasureStorageUsage runs a job that measures the disk usage of each DevWorkspace's data on the common PVC in a
+// namespace. Since the job's result is read from its termination message, which is limited to 4096 bytes, usage is
+// not reported for all DevWorkspaces in namespaces that contain a very large number (roughly 100 or more) of
+// DevWorkspaces; DevWorkspaces that are not measured are absent from the returned map.
+//
+// Returns a map of DevWorkspace IDs to disk usage in bytes once the job is complete, or nil if there is no common PVC in
+// the namespace. Returns NotReadyError while the job is running, and ProvisioningError if the job fails.
+func MeasureStorageUsage(namespace string, clusterAPI sync.ClusterAPI) (map[string]int64, error) {
+	exists, err := pvcExists(config.Workspace.PVCName, namespace, clusterAPI)
+	if err != nil || !exists {
+		return nil, err
+	}
+
+	jobName := common.StorageUsageJobName()
+	getSpecJob := func() (*batchv1.Job, error) {
+		return getSpecCommonPVCJob(namespace, jobName, storageUsageScript, nil, clusterAPI)
+	}
+	message, _, err := runCommonPVCJob(namespace, jobName, getSpecJob, clusterAPI)
+	if err != nil {
+		return nil, err
+	}
+	return parseStorageUsage(message)
+}
+
+// parseStorageUsage parses the termination message written by storageUsageScript. If the message is as long as the
+// termination message length limit, it may have been truncated by the kubelet, so the first field, which may be
+// incomplete, is ignored.
+func parseStorageUsage(message string) (map[string]int64, error) {
+	usage := map[string]int64{}
+	fields := strings.Fields(message)
+	if len(message) >= maxTerminationMessageLength && len(fields) > 0 {
+		fields = fields[1:]
+	}
+	for _, field := range fields {
+		parts := strings.SplitN(field, "=", 2)
+		if len(parts) != 2 {
+			return nil, &ProvisioningError{Message: fmt.Sprintf("Failed to parse storage usage %q", field)}
+		}
+		bytes, err := strconv.ParseInt(parts[1], 10, 64)
+		if err != nil {
+			return nil, &ProvisioningError{Message: fmt.Sprintf("Failed to parse storage usage %q", field), Err: err}
+		}
+		usage[parts[0]] = bytes
+	}
+	return usage, nil
+}
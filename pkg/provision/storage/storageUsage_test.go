@@ -0,0 +1,122 @@
+//
+// Copyright (c) 2019-2021 Red Hat, Inc.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package storage
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/devfile/devworkspace-operator/pkg/common"
+	"github.com/devfile/devworkspace-operator/pkg/constants"
+)
+
+func TestMeasureStorageUsage(t *testing.T) {
+	setupControllerCfg()
+	workspace := getTestMigrationDevWorkspace(constants.CommonStorageClassType)
+	commonPVC, err := getCommonPVCSpec(workspace.Namespace, "1Gi")
+	if err != nil {
+		t.Fatalf("Failure during setup: %s", err)
+	}
+	clusterAPI := getTestMigrationClusterAPI(workspace, commonPVC)
+
+	usage, err := MeasureStorageUsage(workspace.Namespace, clusterAPI)
+	assert.IsType(t, &NotReadyError{}, err, "Should wait for storage usage job to be created")
+	assert.Nil(t, usage)
+	job := &batchv1.Job{}
+	jobName := types.NamespacedName{Name: common.StorageUsageJobName(), Namespace: workspace.Namespace}
+	if err := clusterAPI.Client.Get(clusterAPI.Ctx, jobName, job); err != nil {
+		t.Fatalf("Failed to get storage usage job: %s", err)
+	}
+
+	pod := &corev1.Pod{}
+	pod.Name = "devworkspace-storage-usage-abcde"
+	pod.Namespace = workspace.Namespace
+	pod.Labels = map[string]string{"job-name": job.Name}
+	pod.Status.ContainerStatuses = []corev1.ContainerStatus{
+		{
+			State: corev1.ContainerState{
+				Terminated: &corev1.ContainerStateTerminated{
+					ExitCode: 0,
+					Message:  " workspace1234=4096 workspace5678=1048576\n",
+				},
+			},
+		},
+	}
+	if err := clusterAPI.Client.Create(clusterAPI.Ctx, pod); err != nil {
+		t.Fatalf("Failed to create pod: %s", err)
+	}
+	setTestJobCondition(t, job, batchv1.JobComplete, clusterAPI)
+
+	usage, err = MeasureStorageUsage(workspace.Namespace, clusterAPI)
+	if assert.NoError(t, err, "Storage usage job should be complete") {
+		assert.Equal(t, map[string]int64{"workspace1234": 4096, "workspace5678": 1048576}, usage, "Should report usage per workspace")
+	}
+	err = clusterAPI.Client.Get(clusterAPI.Ctx, jobName, &batchv1.Job{})
+	assert.True(t, k8sErrors.IsNotFound(err), "Storage usage job should be deleted")
+}
+
+func TestParseStorageUsage(t *testing.T) {
+	tests := []struct {
+		name     string
+		message  string
+		expected map[string]int64
+		wantErr  bool
+	}{
+		{
+			name:     "No workspaces",
+			message:  "\n",
+			expected: map[string]int64{},
+		},
+		{
+			name:     "Multiple workspaces",
+			message:  " workspace1=10 workspace2=20\n",
+			expected: map[string]int64{"workspace1": 10, "workspace2": 20},
+		},
+		{
+			name:     "Truncated output",
+			message:  "ace1=10" + strings.Repeat(" workspace2=20", 292) + "\n",
+			expected: map[string]int64{"workspace2": 20},
+		},
+		{
+			name:    "Missing usage",
+			message: " workspace1=\n",
+			wantErr: true,
+		},
+		{
+			name:    "Malformed output",
+			message: "du: cannot access\n",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			usage, err := parseStorageUsage(tt.message)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			if assert.NoError(t, err) {
+				assert.Equal(t, tt.expected, usage)
+			}
+		})
+	}
+}
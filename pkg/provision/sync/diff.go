@@ -27,6 +27,7 @@ import (
 	networkingv1 "k8s.io/api/networking/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
 	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	crclient "sigs.k8s.io/controller-runtime/pkg/client"
 )
 
@@ -42,9 +43,12 @@ var diffFuncs = map[reflect.Type]diffFunc{
 	reflect.TypeOf(corev1.ConfigMap{}):             basicDiffFunc(configmapDiffOpts),
 	reflect.TypeOf(v1alpha1.DevWorkspaceRouting{}): allDiffFuncs(routingDiffFunc, labelsAndAnnotationsDiffFunc, basicDiffFunc(routingDiffOpts)),
 	reflect.TypeOf(batchv1.Job{}):                  jobDiffFunc,
-	reflect.TypeOf(corev1.Service{}):               serviceDiffFunc,
-	reflect.TypeOf(networkingv1.Ingress{}):         basicDiffFunc(ingressDiffOpts),
-	reflect.TypeOf(routev1.Route{}):                basicDiffFunc(routeDiffOpts),
+	reflect.TypeOf(corev1.Service{}):               allDiffFuncs(labelsAndAnnotationsDiffFunc, serviceDiffFunc),
+	reflect.TypeOf(corev1.PersistentVolumeClaim{}): pvcDiffFunc,
+	reflect.TypeOf(networkingv1.Ingress{}):         allDiffFuncs(labelsAndAnnotationsDiffFunc, ingressDiffFunc),
+	reflect.TypeOf(networkingv1.NetworkPolicy{}):   allDiffFuncs(labelsAndAnnotationsDiffFunc, basicDiffFunc(networkPolicyDiffOpts)),
+	reflect.TypeOf(routev1.Route{}):                allDiffFuncs(labelsAndAnnotationsDiffFunc, basicDiffFunc(routeDiffOpts)),
+	reflect.TypeOf(unstructured.Unstructured{}):    allDiffFuncs(labelsAndAnnotationsDiffFunc, unstructuredDiffFunc),
 }
 
 // basicDiffFunc returns a diffFunc that specifies an object needs an update if cmp.Equal fails
@@ -126,6 +130,8 @@ func serviceDiffFunc(spec, cluster crclient.Object) (delete, update bool) {
 			return strings.Compare(servicePorts[i].Name, servicePorts[j].Name) > 0
 		}
 	}
+	// Node ports are allocated by the cluster for NodePort and LoadBalancer services
+	copyAllocatedNodePorts(specCopy, clusterCopy)
 	sort.Slice(specCopy.Spec.Ports, servicePortSorter(specCopy.Spec.Ports))
 	sort.Slice(clusterCopy.Spec.Ports, servicePortSorter(clusterCopy.Spec.Ports))
 	if !cmp.Equal(specCopy.Spec.Ports, clusterCopy.Spec.Ports) {
@@ -133,3 +139,78 @@ func serviceDiffFunc(spec, cluster crclient.Object) (delete, update bool) {
 	}
 	return false, specCopy.Spec.Type != clusterCopy.Spec.Type
 }
+
+// ingressDiffFunc requires an Ingress to be updated if its spec differs from the cluster object. If the spec object
+// does not specify an ingress class, the cluster object's ingress class is ignored, as it may be set when the Ingress
+// is created if the cluster has a default IngressClass.
+func ingressDiffFunc(spec, cluster crclient.Object) (delete, update bool) {
+	specIngress := spec.(*networkingv1.Ingress)
+	clusterIngress := cluster.(*networkingv1.Ingress)
+	if specIngress.Spec.IngressClassName == nil && clusterIngress.Spec.IngressClassName != nil {
+		clusterIngress = clusterIngress.DeepCopy()
+		clusterIngress.Spec.IngressClassName = nil
+	}
+	return false, !cmp.Equal(specIngress, clusterIngress, ingressDiffOpts)
+}
+
+// pvcDiffFunc requires a PVC to be updated if the spec object requests more storage than the cluster object. Other
+// differences are ignored, as the rest of a bound PVC's spec is immutable and PVCs cannot be shrunk.
+func pvcDiffFunc(spec, cluster crclient.Object) (delete, update bool) {
+	specPVC := spec.(*corev1.PersistentVolumeClaim)
+	clusterPVC := cluster.(*corev1.PersistentVolumeClaim)
+	specSize := specPVC.Spec.Resources.Requests[corev1.ResourceStorage]
+	clusterSize := clusterPVC.Spec.Resources.Requests[corev1.ResourceStorage]
+	return false, specSize.Cmp(clusterSize) > 0
+}
+
+// unstructuredDiffFunc requires an unstructured object to be updated if any field set in the spec object (other than
+// metadata and status) is not set to the same value in the cluster object. Fields that are only present on the cluster
+// object (e.g. fields defaulted by the API server) are ignored.
+func unstructuredDiffFunc(spec, cluster crclient.Object) (delete, update bool) {
+	specObj := spec.(*unstructured.Unstructured)
+	clusterObj := cluster.(*unstructured.Unstructured)
+	for field, specValue := range specObj.Object {
+		switch field {
+		case "apiVersion", "kind", "metadata", "status":
+			continue
+		}
+		if !isUnstructuredSubset(specValue, clusterObj.Object[field]) {
+			return false, true
+		}
+	}
+	return false, false
+}
+
+// isUnstructuredSubset checks whether all values in spec are present in cluster. Maps are compared key-by-key,
+// ignoring keys only present in cluster, while lists must have the same length and contain matching elements.
+func isUnstructuredSubset(spec, cluster interface{}) bool {
+	switch specVal := spec.(type) {
+	case map[string]interface{}:
+		clusterVal, ok := cluster.(map[string]interface{})
+		if !ok {
+			return len(specVal) == 0 && cluster == nil
+		}
+		for key, value := range specVal {
+			if !isUnstructuredSubset(value, clusterVal[key]) {
+				return false
+			}
+		}
+		return true
+	case []interface{}:
+		clusterVal, ok := cluster.([]interface{})
+		if !ok {
+			return len(specVal) == 0 && cluster == nil
+		}
+		if len(specVal) != len(clusterVal) {
+			return false
+		}
+		for idx := range specVal {
+			if !isUnstructuredSubset(specVal[idx], clusterVal[idx]) {
+				return false
+			}
+		}
+		return true
+	default:
+		return equality.Semantic.DeepEqual(spec, cluster)
+	}
+}
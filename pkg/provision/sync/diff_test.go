@@ -0,0 +1,33 @@
+// Copyright (c) 2019-2021 Red Hat, Inc.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sync
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestServiceDiffFuncIgnoresAllocatedNodePorts(t *testing.T) {
+	specService := getTestService(corev1.ServiceTypeNodePort, corev1.ServicePort{Name: "ssh", Port: 22})
+	clusterService := getTestService(corev1.ServiceTypeNodePort, corev1.ServicePort{Name: "ssh", Port: 22, NodePort: 30022})
+	_, update := serviceDiffFunc(specService, clusterService)
+	assert.False(t, update, "Service should not be updated when cluster has allocated node ports")
+	assert.Equal(t, int32(0), specService.Spec.Ports[0].NodePort, "Diff should not modify spec service")
+
+	specService.Spec.Ports = append(specService.Spec.Ports, corev1.ServicePort{Name: "dns", Port: 53, Protocol: corev1.ProtocolUDP})
+	_, update = serviceDiffFunc(specService, clusterService)
+	assert.True(t, update, "Service should be updated when ports are added")
+}
@@ -70,3 +70,19 @@ var ingressDiffOpts = cmp.Options{
 	cmpopts.IgnoreFields(networkingv1.Ingress{}, "TypeMeta", "ObjectMeta", "Status"),
 	cmpopts.IgnoreFields(networkingv1.HTTPIngressPath{}, "PathType"),
 }
+
+var networkPolicyDiffOpts = cmp.Options{
+	cmpopts.IgnoreFields(networkingv1.NetworkPolicy{}, "TypeMeta", "ObjectMeta"),
+	// The API server defaults the protocol of NetworkPolicy ports to TCP
+	cmp.Comparer(func(a, b *corev1.Protocol) bool {
+		protocolA, protocolB := corev1.ProtocolTCP, corev1.ProtocolTCP
+		if a != nil {
+			protocolA = *a
+		}
+		if b != nil {
+			protocolB = *b
+		}
+		return protocolA == protocolB
+	}),
+	cmpopts.EquateEmpty(),
+}
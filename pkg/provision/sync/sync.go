@@ -27,6 +27,7 @@ import (
 	networkingv1 "k8s.io/api/networking/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
 	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/types"
 	crclient "sigs.k8s.io/controller-runtime/pkg/client"
 )
@@ -38,6 +39,10 @@ import (
 func SyncObjectWithCluster(specObj crclient.Object, api ClusterAPI) (crclient.Object, error) {
 	objType := reflect.TypeOf(specObj).Elem()
 	clusterObj := reflect.New(objType).Interface().(crclient.Object)
+	if unstructuredObj, ok := specObj.(*unstructured.Unstructured); ok {
+		// Unstructured objects need to have their kind set in order to be read from the cluster
+		clusterObj.GetObjectKind().SetGroupVersionKind(unstructuredObj.GroupVersionKind())
+	}
 
 	err := api.Client.Get(api.Ctx, types.NamespacedName{Name: specObj.GetName(), Namespace: specObj.GetNamespace()}, clusterObj)
 	if err != nil {
@@ -115,10 +120,12 @@ func updateObjectGeneric(specObj, clusterObj crclient.Object, api ClusterAPI) er
 	}
 }
 
+// isMutableObject returns whether an object can be updated on the cluster. PersistentVolumeClaims are only mutable as
+// structured objects, where updates are limited to expanding the PVC's requested storage (see pvcDiffFunc).
 func isMutableObject(obj crclient.Object) bool {
-	switch obj.(type) {
-	case *corev1.PersistentVolumeClaim:
-		return false
+	switch t := obj.(type) {
+	case *unstructured.Unstructured:
+		return t.GroupVersionKind() != corev1.SchemeGroupVersion.WithKind("PersistentVolumeClaim")
 	default:
 		return true
 	}
@@ -140,6 +147,8 @@ func printDiff(specObj, clusterObj crclient.Object, log logr.Logger) {
 			diffOpts = routingDiffOpts
 		case *networkingv1.Ingress:
 			diffOpts = ingressDiffOpts
+		case *networkingv1.NetworkPolicy:
+			diffOpts = networkPolicyDiffOpts
 		case *routev1.Route:
 			diffOpts = routeDiffOpts
 		default:
@@ -47,14 +47,50 @@ func serviceUpdateFunc(spec, cluster crclient.Object) (crclient.Object, error) {
 	clusterService := cluster.(*corev1.Service)
 	specService.ResourceVersion = clusterService.ResourceVersion
 	specService.Spec.ClusterIP = clusterService.Spec.ClusterIP
+	copyAllocatedNodePorts(specService, clusterService)
 	return specService, nil
 }
 
+// copyAllocatedNodePorts sets the node port of any port in the spec service that does not specify one to the node
+// port allocated for the port with the same name in the cluster service, if both services use node ports.
+func copyAllocatedNodePorts(specService, clusterService *corev1.Service) {
+	if specService.Spec.Type != clusterService.Spec.Type || specService.Spec.Type == corev1.ServiceTypeClusterIP {
+		return
+	}
+	for idx, specPort := range specService.Spec.Ports {
+		if specPort.NodePort != 0 {
+			continue
+		}
+		for _, clusterPort := range clusterService.Spec.Ports {
+			if clusterPort.Name == specPort.Name {
+				specService.Spec.Ports[idx].NodePort = clusterPort.NodePort
+			}
+		}
+	}
+}
+
+// pvcUpdateFunc applies the storage requested by the spec PVC to the cluster PVC, leaving the rest of the cluster
+// object unchanged since most of a bound PVC's spec cannot be updated.
+func pvcUpdateFunc(spec, cluster crclient.Object) (crclient.Object, error) {
+	if cluster == nil {
+		return defaultUpdateFunc(spec, cluster)
+	}
+	specPVC := spec.(*corev1.PersistentVolumeClaim)
+	updatedPVC := cluster.DeepCopyObject().(*corev1.PersistentVolumeClaim)
+	if updatedPVC.Spec.Resources.Requests == nil {
+		updatedPVC.Spec.Resources.Requests = corev1.ResourceList{}
+	}
+	updatedPVC.Spec.Resources.Requests[corev1.ResourceStorage] = specPVC.Spec.Resources.Requests[corev1.ResourceStorage]
+	return updatedPVC, nil
+}
+
 func getUpdateFunc(obj crclient.Object) updateFunc {
 	objType := reflect.TypeOf(obj).Elem()
 	switch objType {
 	case reflect.TypeOf(corev1.Service{}):
 		return serviceUpdateFunc
+	case reflect.TypeOf(corev1.PersistentVolumeClaim{}):
+		return pvcUpdateFunc
 	default:
 		return defaultUpdateFunc
 	}
@@ -0,0 +1,91 @@
+// Copyright (c) 2019-2021 Red Hat, Inc.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sync
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func getTestService(serviceType corev1.ServiceType, ports ...corev1.ServicePort) *corev1.Service {
+	service := &corev1.Service{}
+	service.Name = "workspace-id-external"
+	service.Spec.Type = serviceType
+	service.Spec.Ports = ports
+	return service
+}
+
+func TestCopyAllocatedNodePorts(t *testing.T) {
+	tests := []struct {
+		name           string
+		specService    *corev1.Service
+		clusterService *corev1.Service
+		expectedPorts  []corev1.ServicePort
+	}{
+		{
+			name:           "Copies allocated node ports by port name",
+			specService:    getTestService(corev1.ServiceTypeNodePort, corev1.ServicePort{Name: "ssh", Port: 22}, corev1.ServicePort{Name: "dns", Port: 53}),
+			clusterService: getTestService(corev1.ServiceTypeNodePort, corev1.ServicePort{Name: "dns", Port: 53, NodePort: 30053}, corev1.ServicePort{Name: "ssh", Port: 22, NodePort: 30022}),
+			expectedPorts:  []corev1.ServicePort{{Name: "ssh", Port: 22, NodePort: 30022}, {Name: "dns", Port: 53, NodePort: 30053}},
+		},
+		{
+			name:           "Copies node ports for LoadBalancer services",
+			specService:    getTestService(corev1.ServiceTypeLoadBalancer, corev1.ServicePort{Name: "ssh", Port: 22}),
+			clusterService: getTestService(corev1.ServiceTypeLoadBalancer, corev1.ServicePort{Name: "ssh", Port: 22, NodePort: 30022}),
+			expectedPorts:  []corev1.ServicePort{{Name: "ssh", Port: 22, NodePort: 30022}},
+		},
+		{
+			name:           "Does not override node port in spec",
+			specService:    getTestService(corev1.ServiceTypeNodePort, corev1.ServicePort{Name: "ssh", Port: 22, NodePort: 30122}),
+			clusterService: getTestService(corev1.ServiceTypeNodePort, corev1.ServicePort{Name: "ssh", Port: 22, NodePort: 30022}),
+			expectedPorts:  []corev1.ServicePort{{Name: "ssh", Port: 22, NodePort: 30122}},
+		},
+		{
+			name:           "Leaves new ports unallocated",
+			specService:    getTestService(corev1.ServiceTypeNodePort, corev1.ServicePort{Name: "ssh", Port: 22}, corev1.ServicePort{Name: "dns", Port: 53}),
+			clusterService: getTestService(corev1.ServiceTypeNodePort, corev1.ServicePort{Name: "ssh", Port: 22, NodePort: 30022}),
+			expectedPorts:  []corev1.ServicePort{{Name: "ssh", Port: 22, NodePort: 30022}, {Name: "dns", Port: 53}},
+		},
+		{
+			name:           "Does not copy node ports when service type changes",
+			specService:    getTestService(corev1.ServiceTypeClusterIP, corev1.ServicePort{Name: "ssh", Port: 22}),
+			clusterService: getTestService(corev1.ServiceTypeNodePort, corev1.ServicePort{Name: "ssh", Port: 22, NodePort: 30022}),
+			expectedPorts:  []corev1.ServicePort{{Name: "ssh", Port: 22}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			copyAllocatedNodePorts(tt.specService, tt.clusterService)
+			assert.Equal(t, tt.expectedPorts, tt.specService.Spec.Ports)
+		})
+	}
+}
+
+func TestServiceUpdateFuncKeepsAllocatedFields(t *testing.T) {
+	specService := getTestService(corev1.ServiceTypeNodePort, corev1.ServicePort{Name: "ssh", Port: 22})
+	clusterService := getTestService(corev1.ServiceTypeNodePort, corev1.ServicePort{Name: "ssh", Port: 22, NodePort: 30022})
+	clusterService.ResourceVersion = "5"
+	clusterService.Spec.ClusterIP = "10.0.0.1"
+
+	updated, err := serviceUpdateFunc(specService, clusterService)
+	if !assert.NoError(t, err) {
+		return
+	}
+	updatedService := updated.(*corev1.Service)
+	assert.Equal(t, "5", updatedService.ResourceVersion)
+	assert.Equal(t, "10.0.0.1", updatedService.Spec.ClusterIP, "Should keep cluster IP allocated on cluster")
+	assert.Equal(t, int32(30022), updatedService.Spec.Ports[0].NodePort, "Should keep node port allocated on cluster")
+}
@@ -0,0 +1,399 @@
+//
+// Copyright (c) 2019-2021 Red Hat, Inc.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package workspace
+
+import (
+	"context"
+	"fmt"
+
+	dw "github.com/devfile/api/v2/pkg/apis/workspaces/v1alpha2"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	runtimeClient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	"github.com/devfile/devworkspace-operator/apis/controller/v1alpha1"
+	maputils "github.com/devfile/devworkspace-operator/internal/map"
+	"github.com/devfile/devworkspace-operator/pkg/common"
+	"github.com/devfile/devworkspace-operator/pkg/config"
+	"github.com/devfile/devworkspace-operator/pkg/constants"
+	"github.com/devfile/devworkspace-operator/pkg/provision/sync"
+)
+
+func isDedicatedPodComponent(component dw.Component) bool {
+	return component.Container != nil && component.Container.DedicatedPod != nil && *component.Container.DedicatedPod
+}
+
+func hasDedicatedPodComponents(workspace *dw.DevWorkspace) bool {
+	for _, component := range workspace.Spec.Template.Components {
+		if isDedicatedPodComponent(component) {
+			return true
+		}
+	}
+	return false
+}
+
+// splitDedicatedPodContainers separates containers that should be run in the main workspace deployment from
+// containers that correspond to container components with dedicatedPod: true
+func splitDedicatedPodContainers(workspace *dw.DevWorkspace, containers []corev1.Container) (main, dedicated []corev1.Container) {
+	dedicatedComponents := map[string]bool{}
+	for _, component := range workspace.Spec.Template.Components {
+		if isDedicatedPodComponent(component) {
+			dedicatedComponents[component.Name] = true
+		}
+	}
+	for _, container := range containers {
+		if dedicatedComponents[container.Name] {
+			dedicated = append(dedicated, container)
+		} else {
+			main = append(main, container)
+		}
+	}
+	return main, dedicated
+}
+
+// syncDedicatedPods syncs a deployment and service for each dedicatedPod container component in the workspace to the
+// cluster, removing any deployments and services for components that are no longer dedicatedPod components. The
+// returned status has Continue set to true only if all dedicated deployments are ready.
+func syncDedicatedPods(
+	workspace *dw.DevWorkspace,
+	podAdditionsList []v1alpha1.PodAdditions,
+	envFromSourceAdditions []corev1.EnvFromSource,
+	saName string,
+	clusterAPI sync.ClusterAPI) ProvisioningStatus {
+
+	specDeployments, specServices, err := getSpecDedicatedPodObjects(workspace, podAdditionsList, envFromSourceAdditions, saName, clusterAPI.Scheme)
+	if err != nil {
+		return ProvisioningStatus{Err: err, FailStartup: true}
+	}
+
+	if err := cleanupStaleDedicatedPodObjects(workspace, specDeployments, specServices, clusterAPI); err != nil {
+		return ProvisioningStatus{Err: err}
+	}
+
+	var specObjs, clusterObjs []runtimeClient.Object
+	specObjs = append(specObjs, specDeployments...)
+	specObjs = append(specObjs, specServices...)
+	requeue := false
+	for _, specObj := range specObjs {
+		clusterObj, err := sync.SyncObjectWithCluster(specObj, clusterAPI)
+		switch t := err.(type) {
+		case nil:
+			clusterObjs = append(clusterObjs, clusterObj)
+		case *sync.NotInSyncError:
+			requeue = true
+		case *sync.UnrecoverableSyncError:
+			return ProvisioningStatus{FailStartup: true, Err: t.Cause}
+		default:
+			return ProvisioningStatus{Err: err}
+		}
+	}
+	if requeue {
+		return ProvisioningStatus{Requeue: true}
+	}
+
+	for _, clusterObj := range clusterObjs {
+		if clusterDeployment, ok := clusterObj.(*appsv1.Deployment); ok && !checkDeploymentStatus(clusterDeployment) {
+			return ProvisioningStatus{Message: fmt.Sprintf("Waiting for dedicated pod %s", clusterDeployment.Name)}
+		}
+	}
+	return ProvisioningStatus{Continue: true}
+}
+
+func getSpecDedicatedPodObjects(
+	workspace *dw.DevWorkspace,
+	podAdditionsList []v1alpha1.PodAdditions,
+	envFromSourceAdditions []corev1.EnvFromSource,
+	saName string,
+	scheme *runtime.Scheme) ([]runtimeClient.Object, []runtimeClient.Object, error) {
+
+	if !hasDedicatedPodComponents(workspace) {
+		return nil, nil, nil
+	}
+
+	podAdditions, err := getWorkspacePodAdditions(workspace, podAdditionsList, envFromSourceAdditions)
+	if err != nil {
+		return nil, nil, err
+	}
+	_, dedicatedContainers := splitDedicatedPodContainers(workspace, podAdditions.Containers)
+
+	var deployments, services []runtimeClient.Object
+	for _, container := range dedicatedContainers {
+		deployment, err := getSpecDedicatedPodDeployment(workspace, container, podAdditions, saName)
+		if err != nil {
+			return nil, nil, err
+		}
+		if err := controllerutil.SetControllerReference(workspace, deployment, scheme); err != nil {
+			return nil, nil, err
+		}
+		deployments = append(deployments, deployment)
+
+		service := getSpecDedicatedPodService(workspace, container.Name)
+		if service == nil {
+			continue
+		}
+		if err := controllerutil.SetControllerReference(workspace, service, scheme); err != nil {
+			return nil, nil, err
+		}
+		services = append(services, service)
+	}
+	return deployments, services, nil
+}
+
+func getSpecDedicatedPodDeployment(
+	workspace *dw.DevWorkspace,
+	container corev1.Container,
+	podAdditions *v1alpha1.PodAdditions,
+	saName string) (*appsv1.Deployment, error) {
+
+	replicas := int32(1)
+	terminationGracePeriod := int64(10)
+	if hasPreStopHooks([]corev1.Container{container}) {
+		terminationGracePeriod = preStopTerminationGracePeriod
+	}
+
+	workspaceCreator, present := workspace.Labels[constants.DevWorkspaceCreatorLabel]
+	if !present {
+		return nil, fmt.Errorf("workspace must have creator specified to be run. Recreate it to fix an issue")
+	}
+
+	// Only add volumes that are used by the container, to avoid e.g. mounting PVCs that are not needed
+	var volumes []corev1.Volume
+	for _, volume := range podAdditions.Volumes {
+		for _, volumeMount := range container.VolumeMounts {
+			if volumeMount.Name == volume.Name {
+				volumes = append(volumes, volume)
+				break
+			}
+		}
+	}
+	for _, volume := range volumes {
+		if volume.Name == config.Workspace.PVCName {
+			// See needsPVCWorkaround in getSpecDeployment
+			container.VolumeMounts = append([]corev1.VolumeMount{getWorkspaceSubpathVolumeMount(workspace.Status.DevWorkspaceId)}, container.VolumeMounts...)
+			break
+		}
+	}
+
+	labels := map[string]string{
+		constants.DevWorkspaceIDLabel:           workspace.Status.DevWorkspaceId,
+		constants.DevWorkspaceNameLabel:         workspace.Name,
+		constants.DevWorkspaceCreatorLabel:      workspaceCreator,
+		constants.DevWorkspaceDedicatedPodLabel: container.Name,
+	}
+	var annotations, podAnnotations map[string]string
+	if restrictedAccess, present := workspace.Annotations[constants.DevWorkspaceRestrictedAccessAnnotation]; present {
+		annotations = maputils.Append(annotations, constants.DevWorkspaceRestrictedAccessAnnotation, restrictedAccess)
+		podAnnotations = maputils.Append(podAnnotations, constants.DevWorkspaceRestrictedAccessAnnotation, restrictedAccess)
+	}
+	// Changing the restart annotation on the pod template triggers a rollout of the deployment
+	if restartedAt, present := workspace.Annotations[constants.DevWorkspaceRestartedAtAnnotation]; present {
+		podAnnotations = maputils.Append(podAnnotations, constants.DevWorkspaceRestartedAtAnnotation, restartedAt)
+	}
+
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        common.DedicatedPodName(workspace.Status.DevWorkspaceId, container.Name),
+			Namespace:   workspace.Namespace,
+			Labels:      labels,
+			Annotations: annotations,
+		},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Selector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{
+					constants.DevWorkspaceIDLabel:           workspace.Status.DevWorkspaceId,
+					constants.DevWorkspaceDedicatedPodLabel: container.Name,
+				},
+			},
+			Strategy: appsv1.DeploymentStrategy{
+				Type: appsv1.RecreateDeploymentStrategyType,
+			},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace:   workspace.Namespace,
+					Labels:      labels,
+					Annotations: podAnnotations,
+				},
+				Spec: corev1.PodSpec{
+					Containers:                    []corev1.Container{container},
+					ImagePullSecrets:              podAdditions.PullSecrets,
+					Volumes:                       volumes,
+					RestartPolicy:                 "Always",
+					TerminationGracePeriodSeconds: &terminationGracePeriod,
+					SecurityContext:               GetDevWorkspaceSecurityContext(),
+					ServiceAccountName:            saName,
+					AutomountServiceAccountToken:  nil,
+				},
+			},
+		},
+	}
+
+	return deployment, nil
+}
+
+// getSpecDedicatedPodService returns a service that exposes all endpoints of the dedicatedPod component with the
+// given name, or nil if the component does not define any endpoints.
+func getSpecDedicatedPodService(workspace *dw.DevWorkspace, componentName string) *corev1.Service {
+	var ports []corev1.ServicePort
+	usedPorts := map[int]bool{}
+	for _, component := range workspace.Spec.Template.Components {
+		if component.Name != componentName || component.Container == nil {
+			continue
+		}
+		for _, endpoint := range component.Container.Endpoints {
+			if usedPorts[endpoint.TargetPort] {
+				continue
+			}
+			usedPorts[endpoint.TargetPort] = true
+			ports = append(ports, corev1.ServicePort{
+				Name:       common.EndpointName(endpoint.Name),
+				Protocol:   corev1.ProtocolTCP,
+				Port:       int32(endpoint.TargetPort),
+				TargetPort: intstr.FromInt(endpoint.TargetPort),
+			})
+		}
+	}
+	if len(ports) == 0 {
+		return nil
+	}
+
+	return &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      common.DedicatedPodName(workspace.Status.DevWorkspaceId, componentName),
+			Namespace: workspace.Namespace,
+			Labels: map[string]string{
+				constants.DevWorkspaceIDLabel:           workspace.Status.DevWorkspaceId,
+				constants.DevWorkspaceDedicatedPodLabel: componentName,
+			},
+		},
+		Spec: corev1.ServiceSpec{
+			Selector: map[string]string{
+				constants.DevWorkspaceIDLabel:           workspace.Status.DevWorkspaceId,
+				constants.DevWorkspaceDedicatedPodLabel: componentName,
+			},
+			Type:  corev1.ServiceTypeClusterIP,
+			Ports: ports,
+		},
+	}
+}
+
+// cleanupStaleDedicatedPodObjects deletes deployments and services for dedicatedPod components that are no longer
+// present in the workspace.
+func cleanupStaleDedicatedPodObjects(workspace *dw.DevWorkspace, specDeployments, specServices []runtimeClient.Object, clusterAPI sync.ClusterAPI) error {
+	specDeploymentNames := map[string]bool{}
+	for _, obj := range specDeployments {
+		specDeploymentNames[obj.GetName()] = true
+	}
+	specServiceNames := map[string]bool{}
+	for _, obj := range specServices {
+		specServiceNames[obj.GetName()] = true
+	}
+
+	deployments, err := listDedicatedPodDeployments(clusterAPI.Ctx, workspace, clusterAPI.Client)
+	if err != nil {
+		return err
+	}
+	for idx, deployment := range deployments.Items {
+		if specDeploymentNames[deployment.Name] {
+			continue
+		}
+		if err := clusterAPI.Client.Delete(clusterAPI.Ctx, &deployments.Items[idx]); err != nil && !k8sErrors.IsNotFound(err) {
+			return err
+		}
+		clusterAPI.Logger.Info("Deleted object", "kind", "Deployment", "name", deployment.Name)
+	}
+
+	services := &corev1.ServiceList{}
+	if err := clusterAPI.Client.List(clusterAPI.Ctx, services, dedicatedPodListOptions(workspace)...); err != nil {
+		return err
+	}
+	for idx, service := range services.Items {
+		if specServiceNames[service.Name] {
+			continue
+		}
+		if err := clusterAPI.Client.Delete(clusterAPI.Ctx, &services.Items[idx]); err != nil && !k8sErrors.IsNotFound(err) {
+			return err
+		}
+		clusterAPI.Logger.Info("Deleted object", "kind", "Service", "name", service.Name)
+	}
+	return nil
+}
+
+// ScaleDedicatedPodsToZero scales all deployments for dedicatedPod components in a workspace to zero. Returns
+// true if all dedicated pods are stopped.
+func ScaleDedicatedPodsToZero(workspace *dw.DevWorkspace, clusterAPI sync.ClusterAPI) (stopped bool, err error) {
+	deployments, err := listDedicatedPodDeployments(clusterAPI.Ctx, workspace, clusterAPI.Client)
+	if err != nil {
+		return false, err
+	}
+	stopped = true
+	patch := []byte(`{"spec":{"replicas": 0}}`)
+	for _, deployment := range deployments.Items {
+		if deployment.Spec.Replicas == nil || *deployment.Spec.Replicas > 0 {
+			err := clusterAPI.Client.Patch(clusterAPI.Ctx, &appsv1.Deployment{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: deployment.Namespace,
+					Name:      deployment.Name,
+				},
+			}, runtimeClient.RawPatch(types.StrategicMergePatchType, patch))
+			if err != nil && !k8sErrors.IsNotFound(err) {
+				return false, err
+			}
+			stopped = false
+		} else if deployment.Status.Replicas != 0 {
+			stopped = false
+		}
+	}
+	return stopped, nil
+}
+
+// DeleteDedicatedPodDeployments deletes all deployments for dedicatedPod components in a workspace. Returns
+// true if deployments still exist on the cluster and the caller should check again later.
+func DeleteDedicatedPodDeployments(ctx context.Context, workspace *dw.DevWorkspace, client runtimeClient.Client) (wait bool, err error) {
+	deployments, err := listDedicatedPodDeployments(ctx, workspace, client)
+	if err != nil {
+		return false, err
+	}
+	for idx := range deployments.Items {
+		err := client.Delete(ctx, &deployments.Items[idx], runtimeClient.PropagationPolicy(metav1.DeletePropagationBackground))
+		if err != nil && !k8sErrors.IsNotFound(err) {
+			return false, err
+		}
+	}
+	return len(deployments.Items) > 0, nil
+}
+
+func listDedicatedPodDeployments(ctx context.Context, workspace *dw.DevWorkspace, client runtimeClient.Client) (*appsv1.DeploymentList, error) {
+	deployments := &appsv1.DeploymentList{}
+	if err := client.List(ctx, deployments, dedicatedPodListOptions(workspace)...); err != nil {
+		return nil, err
+	}
+	return deployments, nil
+}
+
+func dedicatedPodListOptions(workspace *dw.DevWorkspace) []runtimeClient.ListOption {
+	return []runtimeClient.ListOption{
+		runtimeClient.InNamespace(workspace.Namespace),
+		runtimeClient.MatchingLabels{constants.DevWorkspaceIDLabel: workspace.Status.DevWorkspaceId},
+		runtimeClient.HasLabels{constants.DevWorkspaceDedicatedPodLabel},
+	}
+}
@@ -0,0 +1,337 @@
+//
+// Copyright (c) 2019-2021 Red Hat, Inc.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package workspace
+
+import (
+	"context"
+	"testing"
+
+	dw "github.com/devfile/api/v2/pkg/apis/workspaces/v1alpha2"
+	"github.com/devfile/api/v2/pkg/attributes"
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+
+	"github.com/devfile/devworkspace-operator/apis/controller/v1alpha1"
+	"github.com/devfile/devworkspace-operator/pkg/config"
+	"github.com/devfile/devworkspace-operator/pkg/constants"
+	"github.com/devfile/devworkspace-operator/pkg/infrastructure"
+	"github.com/devfile/devworkspace-operator/pkg/provision/sync"
+)
+
+const (
+	testWorkspaceID = "workspace-id"
+	testNamespace   = "test-namespace"
+)
+
+func getDedicatedPodTestWorkspace() *dw.DevWorkspace {
+	dedicated := true
+	return &dw.DevWorkspace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-workspace",
+			Namespace: testNamespace,
+			UID:       "test-uid",
+			Labels: map[string]string{
+				constants.DevWorkspaceCreatorLabel: "test-creator",
+			},
+		},
+		Spec: dw.DevWorkspaceSpec{
+			Template: dw.DevWorkspaceTemplateSpec{
+				DevWorkspaceTemplateSpecContent: dw.DevWorkspaceTemplateSpecContent{
+					Components: []dw.Component{
+						{
+							Name: "tooling",
+							ComponentUnion: dw.ComponentUnion{
+								Container: &dw.ContainerComponent{
+									Endpoints: []dw.Endpoint{
+										{Name: "ide", TargetPort: 3100, Exposure: dw.PublicEndpointExposure},
+									},
+								},
+							},
+						},
+						{
+							Name: "db",
+							ComponentUnion: dw.ComponentUnion{
+								Container: &dw.ContainerComponent{
+									Container: dw.Container{DedicatedPod: &dedicated},
+									Endpoints: []dw.Endpoint{
+										{Name: "db-console", TargetPort: 8080, Exposure: dw.PublicEndpointExposure},
+										{Name: "db-console-alt", TargetPort: 8080, Exposure: dw.InternalEndpointExposure},
+										{Name: "db", TargetPort: 5432, Exposure: dw.InternalEndpointExposure},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		Status: dw.DevWorkspaceStatus{
+			DevWorkspaceId: testWorkspaceID,
+		},
+	}
+}
+
+func getDedicatedPodTestScheme(t *testing.T) *runtime.Scheme {
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	if err := dw.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	if err := v1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	return scheme
+}
+
+func getDedicatedPodTestPodAdditions() []v1alpha1.PodAdditions {
+	return []v1alpha1.PodAdditions{
+		{
+			Containers: []corev1.Container{
+				{Name: "tooling", Image: "tooling-image"},
+				{Name: "db", Image: "db-image"},
+			},
+		},
+	}
+}
+
+func TestSplitDedicatedPodContainers(t *testing.T) {
+	workspace := getDedicatedPodTestWorkspace()
+	containers := getDedicatedPodTestPodAdditions()[0].Containers
+
+	main, dedicated := splitDedicatedPodContainers(workspace, containers)
+	if assert.Len(t, main, 1, "Main deployment should contain only non-dedicated containers") {
+		assert.Equal(t, "tooling", main[0].Name)
+	}
+	if assert.Len(t, dedicated, 1, "Dedicated containers should be split from main deployment") {
+		assert.Equal(t, "db", dedicated[0].Name)
+	}
+}
+
+func TestDedicatedPodSelectorsDoNotOverlapMainDeployment(t *testing.T) {
+	infrastructure.InitializeForTesting(infrastructure.Kubernetes)
+	config.SetConfigForTesting(nil)
+	workspace := getDedicatedPodTestWorkspace()
+	scheme := getDedicatedPodTestScheme(t)
+
+	mainDeployment, err := getSpecDeployment(workspace, getDedicatedPodTestPodAdditions(), nil, "test-sa", scheme)
+	if !assert.NoError(t, err) {
+		return
+	}
+	deployments, services, err := getSpecDedicatedPodObjects(workspace, getDedicatedPodTestPodAdditions(), nil, "test-sa", scheme)
+	if !assert.NoError(t, err) {
+		return
+	}
+	if !assert.Len(t, deployments, 1) || !assert.Len(t, services, 1) {
+		return
+	}
+	dedicatedDeployment := deployments[0].(*appsv1.Deployment)
+	dedicatedService := services[0].(*corev1.Service)
+
+	assert.Equal(t, "workspace-id-db", dedicatedDeployment.Name)
+	assert.Equal(t, "workspace-id-db", dedicatedService.Name)
+	if assert.Len(t, dedicatedDeployment.Spec.Template.Spec.Containers, 1) {
+		assert.Equal(t, "db", dedicatedDeployment.Spec.Template.Spec.Containers[0].Name)
+	}
+	if assert.Len(t, mainDeployment.Spec.Template.Spec.Containers, 1) {
+		assert.Equal(t, "tooling", mainDeployment.Spec.Template.Spec.Containers[0].Name)
+	}
+
+	mainSelector := labelsSelect(mainDeployment.Spec.Selector.MatchLabels)
+	dedicatedSelector := labelsSelect(dedicatedDeployment.Spec.Selector.MatchLabels)
+	assert.True(t, mainSelector(mainDeployment.Spec.Template.Labels), "Main deployment should select its own pods")
+	assert.False(t, mainSelector(dedicatedDeployment.Spec.Template.Labels), "Main deployment should not select dedicated pods")
+	assert.True(t, dedicatedSelector(dedicatedDeployment.Spec.Template.Labels), "Dedicated deployment should select its own pods")
+	assert.False(t, dedicatedSelector(mainDeployment.Spec.Template.Labels), "Dedicated deployment should not select main pod")
+	assert.Equal(t, dedicatedDeployment.Spec.Selector.MatchLabels, dedicatedService.Spec.Selector,
+		"Dedicated service should select the dedicated pod")
+
+	if assert.Len(t, dedicatedService.Spec.Ports, 2, "Service should expose each target port once") {
+		assert.Equal(t, int32(8080), dedicatedService.Spec.Ports[0].Port)
+		assert.Equal(t, int32(5432), dedicatedService.Spec.Ports[1].Port)
+	}
+}
+
+func TestMainDeploymentSelectorWithoutDedicatedPods(t *testing.T) {
+	infrastructure.InitializeForTesting(infrastructure.Kubernetes)
+	config.SetConfigForTesting(nil)
+	workspace := getDedicatedPodTestWorkspace()
+	workspace.Spec.Template.Components = workspace.Spec.Template.Components[:1]
+
+	deployment, err := getSpecDeployment(workspace, getDedicatedPodTestPodAdditions()[:1], nil, "test-sa", getDedicatedPodTestScheme(t))
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, map[string]string{constants.DevWorkspaceIDLabel: testWorkspaceID}, deployment.Spec.Selector.MatchLabels,
+		"Main deployment selector should not change for workspaces without dedicated pods")
+}
+
+func TestGetSpecDedicatedPodServiceNoEndpoints(t *testing.T) {
+	workspace := getDedicatedPodTestWorkspace()
+	workspace.Spec.Template.Components[1].Container.Endpoints = nil
+	assert.Nil(t, getSpecDedicatedPodService(workspace, "db"), "No service should be created for components without endpoints")
+}
+
+func TestGetSpecRoutingIncludesDedicatedPodEndpoints(t *testing.T) {
+	config.SetConfigForTesting(nil)
+	workspace := getDedicatedPodTestWorkspace()
+	// Attribute set in the DevWorkspace should be ignored
+	workspace.Spec.Template.Components[0].Container.Endpoints[0].Attributes = attributes.Attributes{}.
+		PutString(constants.DedicatedPodComponentAttribute, "db")
+
+	routing, err := getSpecRouting(workspace, getDedicatedPodTestScheme(t))
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, "true", routing.Spec.PodSelector[constants.DevWorkspaceMainPodLabel])
+
+	toolingEndpoints := routing.Spec.Endpoints["tooling"]
+	if assert.Len(t, toolingEndpoints, 1) {
+		assert.False(t, toolingEndpoints[0].Attributes.Exists(constants.DedicatedPodComponentAttribute),
+			"Endpoints in main pod should not be marked as dedicated")
+	}
+	dbEndpoints := routing.Spec.Endpoints["db"]
+	if assert.Len(t, dbEndpoints, 3, "Endpoints for dedicatedPod components should be included in routing") {
+		for _, endpoint := range dbEndpoints {
+			assert.Equal(t, "db", endpoint.Attributes.GetString(constants.DedicatedPodComponentAttribute, nil))
+		}
+	}
+	assert.Nil(t, workspace.Spec.Template.Components[1].Container.Endpoints[0].Attributes,
+		"Workspace endpoints should not be modified")
+}
+
+func TestScaleAndCleanupDedicatedPods(t *testing.T) {
+	workspace := getDedicatedPodTestWorkspace()
+	replicas := int32(1)
+	getDeployment := func(name, component string) *appsv1.Deployment {
+		return &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: testNamespace,
+				Labels: map[string]string{
+					constants.DevWorkspaceIDLabel:           testWorkspaceID,
+					constants.DevWorkspaceDedicatedPodLabel: component,
+				},
+			},
+			Spec: appsv1.DeploymentSpec{Replicas: &replicas},
+		}
+	}
+	mainDeployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "workspace-id",
+			Namespace: testNamespace,
+			Labels:    map[string]string{constants.DevWorkspaceIDLabel: testWorkspaceID},
+		},
+		Spec: appsv1.DeploymentSpec{Replicas: &replicas},
+	}
+	clusterAPI := sync.ClusterAPI{
+		Ctx: context.Background(),
+		Client: fake.NewClientBuilder().WithScheme(getDedicatedPodTestScheme(t)).WithObjects(
+			mainDeployment, getDeployment("workspace-id-db", "db"), getDeployment("workspace-id-removed", "removed")).Build(),
+		Logger: zap.New(),
+	}
+
+	stopped, err := ScaleDedicatedPodsToZero(workspace, clusterAPI)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.False(t, stopped, "Dedicated pods should not be stopped until deployments are scaled down")
+	for _, name := range []string{"workspace-id-db", "workspace-id-removed"} {
+		deployment := &appsv1.Deployment{}
+		if assert.NoError(t, clusterAPI.Client.Get(clusterAPI.Ctx, types.NamespacedName{Name: name, Namespace: testNamespace}, deployment)) {
+			assert.Equal(t, int32(0), *deployment.Spec.Replicas, "Dedicated deployment should be scaled to zero")
+		}
+	}
+	stopped, err = ScaleDedicatedPodsToZero(workspace, clusterAPI)
+	if assert.NoError(t, err) {
+		assert.True(t, stopped, "Dedicated pods should be stopped once deployments have no replicas")
+	}
+
+	err = cleanupStaleDedicatedPodObjects(workspace, []client.Object{getDeployment("workspace-id-db", "db")}, nil, clusterAPI)
+	if !assert.NoError(t, err) {
+		return
+	}
+	getErr := func(name string) error {
+		return clusterAPI.Client.Get(clusterAPI.Ctx, types.NamespacedName{Name: name, Namespace: testNamespace}, &appsv1.Deployment{})
+	}
+	assert.NoError(t, getErr("workspace-id-db"), "Deployment for current dedicated component should not be deleted")
+	assert.True(t, k8sErrors.IsNotFound(getErr("workspace-id-removed")), "Deployment for removed component should be deleted")
+	assert.NoError(t, getErr("workspace-id"), "Main deployment should not be deleted")
+	mainDeployment = &appsv1.Deployment{}
+	if assert.NoError(t, clusterAPI.Client.Get(clusterAPI.Ctx, types.NamespacedName{Name: "workspace-id", Namespace: testNamespace}, mainDeployment)) {
+		assert.Equal(t, int32(1), *mainDeployment.Spec.Replicas, "Main deployment should not be scaled by dedicated pod functions")
+	}
+}
+
+func TestSyncDeploymentReportsDedicatedPodStatus(t *testing.T) {
+	infrastructure.InitializeForTesting(infrastructure.Kubernetes)
+	config.SetConfigForTesting(nil)
+	workspace := getDedicatedPodTestWorkspace()
+	scheme := getDedicatedPodTestScheme(t)
+	clusterAPI := sync.ClusterAPI{
+		Ctx:    context.Background(),
+		Client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(workspace).Build(),
+		Scheme: scheme,
+		Logger: zap.New(),
+	}
+
+	var status DeploymentProvisioningStatus
+	for i := 0; i < 3; i++ {
+		status = SyncDeploymentToCluster(workspace, getDedicatedPodTestPodAdditions(), "test-sa", clusterAPI)
+		if !assert.NoError(t, status.Err) || !status.Requeue {
+			break
+		}
+	}
+	assert.False(t, status.Requeue, "Deployments should be in sync after being created")
+
+	// Simulate the main deployment becoming ready while the dedicated pod is still starting
+	mainDeployment := &appsv1.Deployment{}
+	if !assert.NoError(t, clusterAPI.Client.Get(clusterAPI.Ctx, types.NamespacedName{Name: testWorkspaceID, Namespace: testNamespace}, mainDeployment)) {
+		return
+	}
+	mainDeployment.Status.ReadyReplicas = 1
+	mainDeployment.Status.UpdatedReplicas = 1
+	if !assert.NoError(t, clusterAPI.Client.Status().Update(clusterAPI.Ctx, mainDeployment)) {
+		return
+	}
+
+	status = SyncDeploymentToCluster(workspace, getDedicatedPodTestPodAdditions(), "test-sa", clusterAPI)
+	assert.NoError(t, status.Err)
+	assert.False(t, status.Continue, "Deployment should not be ready until dedicated pods are ready")
+	assert.Equal(t, "Waiting for dedicated pod workspace-id-db", status.Message, "Status should report which dedicated pod is not ready")
+}
+
+// labelsSelect returns a function that checks whether a set of labels is matched by selector
+func labelsSelect(selector map[string]string) func(labels map[string]string) bool {
+	return func(labels map[string]string) bool {
+		for k, v := range selector {
+			if labels[k] != v {
+				return false
+			}
+		}
+		return true
+	}
+}
@@ -47,6 +47,10 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 )
 
+// preStopTerminationGracePeriod is the termination grace period (in seconds) used for workspace pods when
+// containers define preStop lifecycle hooks.
+const preStopTerminationGracePeriod = int64(60)
+
 var containerFailureStateReasons = []string{
 	"CrashLoopBackOff",
 	"ImagePullBackOff",
@@ -142,7 +146,12 @@ func SyncDeploymentToCluster(
 	}
 	clusterDeployment := clusterObj.(*appsv1.Deployment)
 
-	deploymentReady := checkDeploymentStatus(clusterDeployment)
+	dedicatedPodStatus := syncDedicatedPods(workspace, podAdditions, envFromSourceAdditions, saName, clusterAPI)
+	if dedicatedPodStatus.Requeue || dedicatedPodStatus.Err != nil {
+		return DeploymentProvisioningStatus{dedicatedPodStatus}
+	}
+
+	deploymentReady := checkDeploymentStatus(clusterDeployment) && dedicatedPodStatus.Continue
 	if deploymentReady {
 		return DeploymentProvisioningStatus{
 			ProvisioningStatus: ProvisioningStatus{
@@ -168,11 +177,19 @@ func SyncDeploymentToCluster(
 		}
 	}
 
-	return DeploymentProvisioningStatus{}
+	// Report which dedicated pod is not ready, if any, so that the workspace's conditions show why it is waiting
+	return DeploymentProvisioningStatus{
+		ProvisioningStatus{Message: dedicatedPodStatus.Message},
+	}
 }
 
-// DeleteWorkspaceDeployment deletes the deployment for the DevWorkspace
+// DeleteWorkspaceDeployment deletes the deployment for the DevWorkspace, as well as any deployments for
+// dedicatedPod components
 func DeleteWorkspaceDeployment(ctx context.Context, workspace *dw.DevWorkspace, client runtimeClient.Client) (wait bool, err error) {
+	dedicatedWait, err := DeleteDedicatedPodDeployments(ctx, workspace, client)
+	if err != nil {
+		return false, err
+	}
 	err = client.Delete(ctx, &appsv1.Deployment{
 		ObjectMeta: metav1.ObjectMeta{
 			Namespace: workspace.Namespace,
@@ -181,7 +198,7 @@ func DeleteWorkspaceDeployment(ctx context.Context, workspace *dw.DevWorkspace,
 	})
 	if err != nil {
 		if k8sErrors.IsNotFound(err) {
-			return false, nil
+			return dedicatedWait, nil
 		}
 		return false, err
 	}
@@ -219,7 +236,24 @@ func GetDevWorkspaceSecurityContext() *corev1.PodSecurityContext {
 	return &corev1.PodSecurityContext{}
 }
 
+func hasPreStopHooks(containers []corev1.Container) bool {
+	for _, container := range containers {
+		if container.Lifecycle != nil && container.Lifecycle.PreStop != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// checkDeploymentStatus checks whether a deployment is ready, i.e. its latest spec has been rolled out and it has a
+// ready replica.
 func checkDeploymentStatus(deployment *appsv1.Deployment) (ready bool) {
+	if deployment.Status.ObservedGeneration < deployment.Generation {
+		return false
+	}
+	if deployment.Spec.Replicas != nil && deployment.Status.UpdatedReplicas < *deployment.Spec.Replicas {
+		return false
+	}
 	return deployment.Status.ReadyReplicas > 0
 }
 
@@ -232,23 +266,16 @@ func getSpecDeployment(
 	replicas := int32(1)
 	terminationGracePeriod := int64(10)
 
-	podAdditions, err := mergePodAdditions(podAdditionsList)
+	podAdditions, err := getWorkspacePodAdditions(workspace, podAdditionsList, envFromSourceAdditions)
 	if err != nil {
 		return nil, err
 	}
+	// Containers from dedicatedPod components are run in separate deployments
+	podAdditions.Containers, _ = splitDedicatedPodContainers(workspace, podAdditions.Containers)
 
-	creator := workspace.Labels[constants.DevWorkspaceCreatorLabel]
-	var envVars []corev1.EnvVar
-	envVars = append(envVars, CommonEnvironmentVariables(workspace.Name, workspace.Status.DevWorkspaceId, workspace.Namespace, creator)...)
-	for idx := range podAdditions.Containers {
-		podAdditions.Containers[idx].Env = append(podAdditions.Containers[idx].Env, envVars...)
-		podAdditions.Containers[idx].VolumeMounts = append(podAdditions.Containers[idx].VolumeMounts, podAdditions.VolumeMounts...)
-		podAdditions.Containers[idx].EnvFrom = append(podAdditions.Containers[idx].EnvFrom, envFromSourceAdditions...)
-	}
-	for idx := range podAdditions.InitContainers {
-		podAdditions.InitContainers[idx].Env = append(podAdditions.InitContainers[idx].Env, envVars...)
-		podAdditions.InitContainers[idx].VolumeMounts = append(podAdditions.InitContainers[idx].VolumeMounts, podAdditions.VolumeMounts...)
-		podAdditions.InitContainers[idx].EnvFrom = append(podAdditions.InitContainers[idx].EnvFrom, envFromSourceAdditions...)
+	if hasPreStopHooks(podAdditions.Containers) {
+		// Give preStop hooks time to complete before containers are killed
+		terminationGracePeriod = preStopTerminationGracePeriod
 	}
 
 	deployment := &appsv1.Deployment{
@@ -318,12 +345,23 @@ func getSpecDeployment(
 		return nil, errors.New("workspace must have creator specified to be run. Recreate it to fix an issue")
 	}
 
+	if hasDedicatedPodComponents(workspace) {
+		// Allows the deployment and services for the workspace to select only the main workspace pod
+		deployment.Spec.Selector.MatchLabels[constants.DevWorkspaceMainPodLabel] = "true"
+		deployment.Spec.Template.Labels[constants.DevWorkspaceMainPodLabel] = "true"
+	}
+
 	restrictedAccess, present := workspace.Annotations[constants.DevWorkspaceRestrictedAccessAnnotation]
 	if present {
 		deployment.Annotations = maputils.Append(deployment.Annotations, constants.DevWorkspaceRestrictedAccessAnnotation, restrictedAccess)
 		deployment.Spec.Template.Annotations = maputils.Append(deployment.Spec.Template.Annotations, constants.DevWorkspaceRestrictedAccessAnnotation, restrictedAccess)
 	}
 
+	// Changing the restart annotation on the pod template triggers a rollout of the deployment
+	if restartedAt, present := workspace.Annotations[constants.DevWorkspaceRestartedAtAnnotation]; present {
+		deployment.Spec.Template.Annotations = maputils.Append(deployment.Spec.Template.Annotations, constants.DevWorkspaceRestartedAtAnnotation, restartedAt)
+	}
+
 	err = controllerutil.SetControllerReference(workspace, deployment, scheme)
 	if err != nil {
 		return nil, err
@@ -372,6 +410,30 @@ func checkPodsState(workspace *dw.DevWorkspace,
 	return "", nil
 }
 
+// getWorkspacePodAdditions merges all pod additions for a workspace and adds the common environment variables,
+// volume mounts, and envFrom sources to all containers and init containers.
+func getWorkspacePodAdditions(workspace *dw.DevWorkspace, podAdditionsList []v1alpha1.PodAdditions, envFromSourceAdditions []corev1.EnvFromSource) (*v1alpha1.PodAdditions, error) {
+	podAdditions, err := mergePodAdditions(podAdditionsList)
+	if err != nil {
+		return nil, err
+	}
+
+	creator := workspace.Labels[constants.DevWorkspaceCreatorLabel]
+	var envVars []corev1.EnvVar
+	envVars = append(envVars, CommonEnvironmentVariables(workspace.Name, workspace.Status.DevWorkspaceId, workspace.Namespace, creator)...)
+	for idx := range podAdditions.Containers {
+		podAdditions.Containers[idx].Env = append(podAdditions.Containers[idx].Env, envVars...)
+		podAdditions.Containers[idx].VolumeMounts = append(podAdditions.Containers[idx].VolumeMounts, podAdditions.VolumeMounts...)
+		podAdditions.Containers[idx].EnvFrom = append(podAdditions.Containers[idx].EnvFrom, envFromSourceAdditions...)
+	}
+	for idx := range podAdditions.InitContainers {
+		podAdditions.InitContainers[idx].Env = append(podAdditions.InitContainers[idx].Env, envVars...)
+		podAdditions.InitContainers[idx].VolumeMounts = append(podAdditions.InitContainers[idx].VolumeMounts, podAdditions.VolumeMounts...)
+		podAdditions.InitContainers[idx].EnvFrom = append(podAdditions.InitContainers[idx].EnvFrom, envFromSourceAdditions...)
+	}
+	return podAdditions, nil
+}
+
 func mergePodAdditions(toMerge []v1alpha1.PodAdditions) (*v1alpha1.PodAdditions, error) {
 	podAdditions := &v1alpha1.PodAdditions{}
 
@@ -0,0 +1,62 @@
+//
+// Copyright (c) 2019-2021 Red Hat, Inc.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package workspace
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	rbacv1 "k8s.io/api/rbac/v1"
+)
+
+// Workspaces report activity by patching the last-activity annotation on their DevWorkspace using the workspace
+// ServiceAccount, so the workspace role must allow patching DevWorkspaces.
+func TestWorkspaceRoleAllowsReportingActivity(t *testing.T) {
+	objs := generateRBAC(testNamespace)
+	var role *rbacv1.Role
+	var binding *rbacv1.RoleBinding
+	for _, obj := range objs {
+		switch t := obj.(type) {
+		case *rbacv1.Role:
+			role = t
+		case *rbacv1.RoleBinding:
+			binding = t
+		}
+	}
+	if !assert.NotNil(t, role) || !assert.NotNil(t, binding) {
+		return
+	}
+
+	allowed := false
+	for _, rule := range role.Rules {
+		if contains(rule.APIGroups, "workspace.devfile.io") && contains(rule.Resources, "devworkspaces") && contains(rule.Verbs, "patch") {
+			allowed = true
+		}
+	}
+	assert.True(t, allowed, "Workspace role should allow patching DevWorkspaces")
+	assert.Equal(t, role.Name, binding.RoleRef.Name)
+	assert.Contains(t, binding.Subjects, rbacv1.Subject{Kind: "Group", Name: "system:serviceaccounts:" + testNamespace},
+		"Workspace role should be bound to ServiceAccounts in the namespace")
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -19,6 +19,7 @@ import (
 	"strings"
 
 	dw "github.com/devfile/api/v2/pkg/apis/workspaces/v1alpha2"
+	"github.com/devfile/api/v2/pkg/attributes"
 	"github.com/devfile/devworkspace-operator/pkg/provision/sync"
 
 	"github.com/devfile/devworkspace-operator/apis/controller/v1alpha1"
@@ -95,9 +96,20 @@ func getSpecRouting(
 		if component.Container == nil {
 			continue
 		}
-		componentEndpoints := component.Container.Endpoints
-		if len(componentEndpoints) > 0 {
-			endpoints[component.Name] = append(endpoints[component.Name], componentEndpoints...)
+		for _, endpoint := range component.Container.Endpoints {
+			endpoint := *endpoint.DeepCopy()
+			if endpoint.Attributes != nil {
+				delete(endpoint.Attributes, constants.DedicatedPodComponentAttribute)
+			}
+			if isDedicatedPodComponent(component) {
+				// Endpoints for dedicatedPod components are served by the component's own service; routing only
+				// needs to expose them outside the cluster
+				if endpoint.Attributes == nil {
+					endpoint.Attributes = attributes.Attributes{}
+				}
+				endpoint.Attributes.PutString(constants.DedicatedPodComponentAttribute, component.Name)
+			}
+			endpoints[component.Name] = append(endpoints[component.Name], endpoint)
 		}
 	}
 
@@ -106,6 +118,10 @@ func getSpecRouting(
 		annotations = maputils.Append(annotations, constants.DevWorkspaceRestrictedAccessAnnotation, val)
 	}
 	annotations = maputils.Append(annotations, constants.DevWorkspaceStartedStatusAnnotation, "true")
+	// Propagate the username of the workspace creator so that routing solvers can restrict access to endpoints
+	if username, ok := workspace.Annotations[constants.DevWorkspaceCreatorUsernameAnnotation]; ok {
+		annotations = maputils.Append(annotations, constants.DevWorkspaceCreatorUsernameAnnotation, username)
+	}
 
 	// copy the annotations for the specific routingClass from the workspace object to the routing
 	expectedAnnotationPrefix := workspace.Spec.RoutingClass + constants.RoutingAnnotationInfix
@@ -115,27 +131,38 @@ func getSpecRouting(
 		}
 	}
 
+	podSelector := map[string]string{
+		constants.DevWorkspaceIDLabel: workspace.Status.DevWorkspaceId,
+	}
+	if hasDedicatedPodComponents(workspace) {
+		podSelector[constants.DevWorkspaceMainPodLabel] = "true"
+	}
+
 	routingClass := workspace.Spec.RoutingClass
 	if routingClass == "" {
 		routingClass = config.Routing.DefaultRoutingClass
 	}
 
+	labels := map[string]string{
+		constants.DevWorkspaceIDLabel: workspace.Status.DevWorkspaceId,
+	}
+	// Propagate the workspace creator so that routing solvers can restrict access to endpoints
+	if creator, ok := workspace.Labels[constants.DevWorkspaceCreatorLabel]; ok {
+		labels[constants.DevWorkspaceCreatorLabel] = creator
+	}
+
 	routing := &v1alpha1.DevWorkspaceRouting{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      common.DevWorkspaceRoutingName(workspace.Status.DevWorkspaceId),
-			Namespace: workspace.Namespace,
-			Labels: map[string]string{
-				constants.DevWorkspaceIDLabel: workspace.Status.DevWorkspaceId,
-			},
+			Name:        common.DevWorkspaceRoutingName(workspace.Status.DevWorkspaceId),
+			Namespace:   workspace.Namespace,
+			Labels:      labels,
 			Annotations: annotations,
 		},
 		Spec: v1alpha1.DevWorkspaceRoutingSpec{
 			DevWorkspaceId: workspace.Status.DevWorkspaceId,
 			RoutingClass:   v1alpha1.DevWorkspaceRoutingClass(routingClass),
 			Endpoints:      endpoints,
-			PodSelector: map[string]string{
-				constants.DevWorkspaceIDLabel: workspace.Status.DevWorkspaceId,
-			},
+			PodSelector:    podSelector,
 		},
 	}
 	err := controllerutil.SetControllerReference(workspace, routing, scheme)
@@ -0,0 +1,42 @@
+//
+// Copyright (c) 2019-2021 Red Hat, Inc.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package workspace
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/devfile/devworkspace-operator/pkg/config"
+	"github.com/devfile/devworkspace-operator/pkg/constants"
+)
+
+func TestGetSpecRoutingPropagatesCreator(t *testing.T) {
+	config.SetConfigForTesting(nil)
+	workspace := getDedicatedPodTestWorkspace()
+	workspace.Annotations = map[string]string{
+		constants.DevWorkspaceCreatorUsernameAnnotation: "test-user",
+	}
+
+	routing, err := getSpecRouting(workspace, getDedicatedPodTestScheme(t))
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, "test-creator", routing.Labels[constants.DevWorkspaceCreatorLabel],
+		"Routing should have the creator label of the DevWorkspace")
+	assert.Equal(t, "test-user", routing.Annotations[constants.DevWorkspaceCreatorUsernameAnnotation],
+		"Routing should have the creator username annotation of the DevWorkspace")
+}
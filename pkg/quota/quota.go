@@ -0,0 +1,158 @@
+//
+// Copyright (c) 2019-2021 Red Hat, Inc.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+// Package quota checks the limits on the number of DevWorkspaces users can create and run, as configured in the
+// DevWorkspace Operator configuration. Checks are shared by the controller and the webhook server.
+package quota
+
+import (
+	"context"
+	"fmt"
+
+	dw "github.com/devfile/api/v2/pkg/apis/workspaces/v1alpha2"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/devfile/devworkspace-operator/apis/controller/v1alpha1"
+	"github.com/devfile/devworkspace-operator/pkg/conditions"
+	"github.com/devfile/devworkspace-operator/pkg/constants"
+)
+
+// CheckRunningQuotas checks whether starting a DevWorkspace would exceed the maximum number of running DevWorkspaces
+// per user or per namespace. If a quota would be exceeded, a user-friendly message explaining the issue is returned;
+// otherwise, msg is empty.
+//
+// If onlyEarlier is true, other DevWorkspaces that are starting count against the quota only if they started before the
+// provided DevWorkspace. This allows the controller to consistently allow the earliest-started DevWorkspaces when
+// multiple DevWorkspaces are started at once.
+func CheckRunningQuotas(ctx context.Context, c client.Client, workspace *dw.DevWorkspace, quotas *v1alpha1.WorkspaceQuotaConfig, onlyEarlier bool) (msg string, err error) {
+	if quotas == nil {
+		return "", nil
+	}
+
+	countsAgainstQuota := func(other *dw.DevWorkspace) bool {
+		if isSameWorkspace(workspace, other) || !other.Spec.Started {
+			return false
+		}
+		if !onlyEarlier || other.Status.Phase == dw.DevWorkspaceStatusRunning {
+			return true
+		}
+		return startedBefore(other, workspace)
+	}
+
+	if quotas.MaxRunningPerUser != nil {
+		creator, ok := workspace.Labels[constants.DevWorkspaceCreatorLabel]
+		if ok {
+			userWorkspaces := &dw.DevWorkspaceList{}
+			if err := c.List(ctx, userWorkspaces, client.MatchingLabels{constants.DevWorkspaceCreatorLabel: creator}); err != nil {
+				return "", err
+			}
+			count := countWorkspaces(userWorkspaces.Items, countsAgainstQuota)
+			if count >= *quotas.MaxRunningPerUser {
+				return fmt.Sprintf("maximum number of running DevWorkspaces per user (%d) reached; stop another DevWorkspace before starting this one", *quotas.MaxRunningPerUser), nil
+			}
+		}
+	}
+
+	if quotas.MaxRunningPerNamespace != nil {
+		namespaceWorkspaces := &dw.DevWorkspaceList{}
+		if err := c.List(ctx, namespaceWorkspaces, client.InNamespace(workspace.Namespace)); err != nil {
+			return "", err
+		}
+		count := countWorkspaces(namespaceWorkspaces.Items, countsAgainstQuota)
+		if count >= *quotas.MaxRunningPerNamespace {
+			return fmt.Sprintf("maximum number of running DevWorkspaces in namespace %s (%d) reached; stop another DevWorkspace before starting this one", workspace.Namespace, *quotas.MaxRunningPerNamespace), nil
+		}
+	}
+
+	return "", nil
+}
+
+// CheckTotalQuota checks whether a DevWorkspace exceeds the maximum number of DevWorkspaces per user. If the quota is
+// exceeded, a user-friendly message explaining the issue is returned; otherwise, msg is empty.
+//
+// If onlyEarlier is true, other DevWorkspaces count against the quota only if they were created before the provided
+// DevWorkspace. This allows the controller to check whether an existing DevWorkspace is within the quota.
+func CheckTotalQuota(ctx context.Context, c client.Client, workspace *dw.DevWorkspace, quotas *v1alpha1.WorkspaceQuotaConfig, onlyEarlier bool) (msg string, err error) {
+	if quotas == nil || quotas.MaxWorkspacesPerUser == nil {
+		return "", nil
+	}
+	creator, ok := workspace.Labels[constants.DevWorkspaceCreatorLabel]
+	if !ok {
+		return "", nil
+	}
+	userWorkspaces := &dw.DevWorkspaceList{}
+	if err := c.List(ctx, userWorkspaces, client.MatchingLabels{constants.DevWorkspaceCreatorLabel: creator}); err != nil {
+		return "", err
+	}
+	count := countWorkspaces(userWorkspaces.Items, func(other *dw.DevWorkspace) bool {
+		if isSameWorkspace(workspace, other) {
+			return false
+		}
+		return !onlyEarlier || createdBefore(other, workspace)
+	})
+	if count >= *quotas.MaxWorkspacesPerUser {
+		return fmt.Sprintf("maximum number of DevWorkspaces per user (%d) reached; delete another DevWorkspace to use this one", *quotas.MaxWorkspacesPerUser), nil
+	}
+	return "", nil
+}
+
+func countWorkspaces(workspaces []dw.DevWorkspace, filter func(*dw.DevWorkspace) bool) int {
+	count := 0
+	for idx := range workspaces {
+		if filter(&workspaces[idx]) {
+			count++
+		}
+	}
+	return count
+}
+
+func isSameWorkspace(a, b *dw.DevWorkspace) bool {
+	return a.Namespace == b.Namespace && a.Name == b.Name
+}
+
+// startedBefore returns whether DevWorkspace a was started before DevWorkspace b, based on their Started conditions.
+// DevWorkspaces that do not have a true Started condition have not yet been processed by the controller and are
+// considered started after those that do. Ties are broken by namespace and name.
+func startedBefore(a, b *dw.DevWorkspace) bool {
+	aStarted := conditions.GetConditionByType(a.Status.Conditions, conditions.Started)
+	bStarted := conditions.GetConditionByType(b.Status.Conditions, conditions.Started)
+	aIsStarted := aStarted != nil && aStarted.Status == corev1.ConditionTrue
+	bIsStarted := bStarted != nil && bStarted.Status == corev1.ConditionTrue
+	switch {
+	case aIsStarted && !bIsStarted:
+		return true
+	case !aIsStarted && bIsStarted:
+		return false
+	case aIsStarted && bIsStarted && !aStarted.LastTransitionTime.Equal(&bStarted.LastTransitionTime):
+		return aStarted.LastTransitionTime.Before(&bStarted.LastTransitionTime)
+	}
+	return nameBefore(a, b)
+}
+
+// createdBefore returns whether DevWorkspace a was created before DevWorkspace b. Ties are broken by namespace and name.
+func createdBefore(a, b *dw.DevWorkspace) bool {
+	if !a.CreationTimestamp.Equal(&b.CreationTimestamp) {
+		return a.CreationTimestamp.Before(&b.CreationTimestamp)
+	}
+	return nameBefore(a, b)
+}
+
+func nameBefore(a, b *dw.DevWorkspace) bool {
+	if a.Namespace != b.Namespace {
+		return a.Namespace < b.Namespace
+	}
+	return a.Name < b.Name
+}
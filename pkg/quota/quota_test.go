@@ -0,0 +1,174 @@
+//
+// Copyright (c) 2019-2021 Red Hat, Inc.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package quota
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	dw "github.com/devfile/api/v2/pkg/apis/workspaces/v1alpha2"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/devfile/devworkspace-operator/apis/controller/v1alpha1"
+	"github.com/devfile/devworkspace-operator/pkg/conditions"
+	"github.com/devfile/devworkspace-operator/pkg/constants"
+)
+
+var (
+	scheme   = runtime.NewScheme()
+	baseTime = time.Date(2021, 10, 4, 12, 0, 0, 0, time.UTC)
+	one      = 1
+	two      = 2
+)
+
+func init() {
+	utilruntime.Must(dw.AddToScheme(scheme))
+}
+
+func getTestWorkspace(namespace, name, creator string, started bool, phase dw.DevWorkspacePhase, startedMinutes int) *dw.DevWorkspace {
+	workspace := &dw.DevWorkspace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              name,
+			Namespace:         namespace,
+			Labels:            map[string]string{constants.DevWorkspaceCreatorLabel: creator},
+			CreationTimestamp: metav1.Time{Time: baseTime.Add(time.Duration(startedMinutes) * time.Minute)},
+		},
+		Spec: dw.DevWorkspaceSpec{
+			Started: started,
+		},
+		Status: dw.DevWorkspaceStatus{
+			Phase: phase,
+		},
+	}
+	if started {
+		workspace.Status.Conditions = []dw.DevWorkspaceCondition{
+			{
+				Type:               conditions.Started,
+				Status:             corev1.ConditionTrue,
+				LastTransitionTime: metav1.Time{Time: baseTime.Add(time.Duration(startedMinutes) * time.Minute)},
+			},
+		}
+	}
+	return workspace
+}
+
+func TestCheckRunningQuotas(t *testing.T) {
+	tests := []struct {
+		name        string
+		workspace   *dw.DevWorkspace
+		existing    []client.Object
+		quotas      *v1alpha1.WorkspaceQuotaConfig
+		onlyEarlier bool
+		errRegexp   string
+	}{
+		{
+			name:      "Allows workspace when quotas are not configured",
+			workspace: getTestWorkspace("ns-a", "test", "user-a", true, dw.DevWorkspaceStatusStarting, 10),
+			existing: []client.Object{
+				getTestWorkspace("ns-a", "other", "user-a", true, dw.DevWorkspaceStatusRunning, 0),
+			},
+		},
+		{
+			name:      "Denies workspace when user has too many running workspaces",
+			workspace: getTestWorkspace("ns-a", "test", "user-a", true, dw.DevWorkspaceStatusStarting, 10),
+			existing: []client.Object{
+				getTestWorkspace("ns-b", "other", "user-a", true, dw.DevWorkspaceStatusRunning, 0),
+				getTestWorkspace("ns-a", "stopped", "user-a", false, dw.DevWorkspaceStatusStopped, 0),
+				getTestWorkspace("ns-a", "other-user", "user-b", true, dw.DevWorkspaceStatusRunning, 0),
+			},
+			quotas:    &v1alpha1.WorkspaceQuotaConfig{MaxRunningPerUser: &one},
+			errRegexp: `maximum number of running DevWorkspaces per user \(1\) reached`,
+		},
+		{
+			name:      "Denies workspace when namespace has too many running workspaces",
+			workspace: getTestWorkspace("ns-a", "test", "user-a", true, dw.DevWorkspaceStatusStarting, 10),
+			existing: []client.Object{
+				getTestWorkspace("ns-a", "other-1", "user-b", true, dw.DevWorkspaceStatusRunning, 0),
+				getTestWorkspace("ns-a", "other-2", "user-c", true, dw.DevWorkspaceStatusRunning, 0),
+				getTestWorkspace("ns-b", "other-3", "user-c", true, dw.DevWorkspaceStatusRunning, 0),
+			},
+			quotas:    &v1alpha1.WorkspaceQuotaConfig{MaxRunningPerNamespace: &two},
+			errRegexp: `maximum number of running DevWorkspaces in namespace ns-a \(2\) reached`,
+		},
+		{
+			name:      "Allows earliest started workspace when only counting earlier workspaces",
+			workspace: getTestWorkspace("ns-a", "test", "user-a", true, dw.DevWorkspaceStatusStarting, 0),
+			existing: []client.Object{
+				getTestWorkspace("ns-a", "other", "user-a", true, dw.DevWorkspaceStatusStarting, 10),
+			},
+			quotas:      &v1alpha1.WorkspaceQuotaConfig{MaxRunningPerUser: &one},
+			onlyEarlier: true,
+		},
+		{
+			name:      "Denies later started workspace when only counting earlier workspaces",
+			workspace: getTestWorkspace("ns-a", "test", "user-a", true, dw.DevWorkspaceStatusStarting, 10),
+			existing: []client.Object{
+				getTestWorkspace("ns-a", "other", "user-a", true, dw.DevWorkspaceStatusStarting, 0),
+			},
+			quotas:      &v1alpha1.WorkspaceQuotaConfig{MaxRunningPerUser: &one},
+			onlyEarlier: true,
+			errRegexp:   `maximum number of running DevWorkspaces per user \(1\) reached`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(tt.existing...).Build()
+			msg, err := CheckRunningQuotas(context.Background(), c, tt.workspace, tt.quotas, tt.onlyEarlier)
+			if !assert.NoError(t, err, "Should not return error") {
+				return
+			}
+			if tt.errRegexp == "" {
+				assert.Empty(t, msg, "Workspace should be allowed")
+			} else {
+				assert.Regexp(t, tt.errRegexp, msg)
+			}
+		})
+	}
+}
+
+func TestCheckTotalQuota(t *testing.T) {
+	quotas := &v1alpha1.WorkspaceQuotaConfig{MaxWorkspacesPerUser: &two}
+	existing := []client.Object{
+		getTestWorkspace("ns-a", "first", "user-a", false, dw.DevWorkspaceStatusStopped, 0),
+		getTestWorkspace("ns-b", "second", "user-a", false, dw.DevWorkspaceStatusStopped, 5),
+		getTestWorkspace("ns-a", "third", "user-a", false, dw.DevWorkspaceStatusStopped, 10),
+		getTestWorkspace("ns-a", "other-user", "user-b", false, dw.DevWorkspaceStatusStopped, 0),
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(existing...).Build()
+
+	newWorkspace := getTestWorkspace("ns-a", "new", "user-a", false, "", 20)
+	msg, err := CheckTotalQuota(context.Background(), c, newWorkspace, quotas, false)
+	if assert.NoError(t, err) {
+		assert.Regexp(t, `maximum number of DevWorkspaces per user \(2\) reached`, msg, "Should deny new workspace")
+	}
+
+	msg, err = CheckTotalQuota(context.Background(), c, existing[1].(*dw.DevWorkspace), quotas, true)
+	if assert.NoError(t, err) {
+		assert.Empty(t, msg, "Should allow workspace within the earliest-created workspaces")
+	}
+
+	msg, err = CheckTotalQuota(context.Background(), c, existing[2].(*dw.DevWorkspace), quotas, true)
+	if assert.NoError(t, err) {
+		assert.Regexp(t, `maximum number of DevWorkspaces per user \(2\) reached`, msg, "Should deny later-created workspace")
+	}
+}
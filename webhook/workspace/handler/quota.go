@@ -0,0 +1,78 @@
+//
+// Copyright (c) 2019-2021 Red Hat, Inc.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package handler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	dwv2 "github.com/devfile/api/v2/pkg/apis/workspaces/v1alpha2"
+	admissionv1 "k8s.io/api/admission/v1"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	"github.com/devfile/devworkspace-operator/pkg/config"
+	"github.com/devfile/devworkspace-operator/pkg/quota"
+)
+
+// ValidateQuotas checks that creating or starting a DevWorkspace does not exceed the quotas configured in the
+// DevWorkspace Operator configuration. Updates that do not start a DevWorkspace are always allowed.
+func (h *WebhookHandler) ValidateQuotas(ctx context.Context, req admission.Request) admission.Response {
+	wksp := &dwv2.DevWorkspace{}
+	err := h.Decoder.Decode(req, wksp)
+	if err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+
+	checkTotal := req.Operation == admissionv1.Create
+	checkRunning := wksp.Spec.Started
+	if req.Operation == admissionv1.Update {
+		oldWksp := &dwv2.DevWorkspace{}
+		if err := h.Decoder.DecodeRaw(req.OldObject, oldWksp); err != nil {
+			return admission.Errored(http.StatusInternalServerError, err)
+		}
+		checkRunning = wksp.Spec.Started && !oldWksp.Spec.Started
+	}
+	if !checkTotal && !checkRunning {
+		return admission.Allowed("DevWorkspace is not created or started")
+	}
+
+	operatorConfig, err := config.GetOperatorConfigFromCluster(h.Client)
+	if err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+	quotas := operatorConfig.Workspace.Quotas
+
+	if checkTotal {
+		msg, err := quota.CheckTotalQuota(ctx, h.Client, wksp, quotas, false)
+		if err != nil {
+			return admission.Errored(http.StatusInternalServerError, err)
+		}
+		if msg != "" {
+			return admission.Denied(fmt.Sprintf("cannot create DevWorkspace: %s", msg))
+		}
+	}
+	if checkRunning {
+		msg, err := quota.CheckRunningQuotas(ctx, h.Client, wksp, quotas, false)
+		if err != nil {
+			return admission.Errored(http.StatusInternalServerError, err)
+		}
+		if msg != "" {
+			return admission.Denied(fmt.Sprintf("cannot start DevWorkspace: %s", msg))
+		}
+	}
+	return admission.Allowed("DevWorkspace quotas are not exceeded")
+}
@@ -0,0 +1,57 @@
+//
+// Copyright (c) 2019-2021 Red Hat, Inc.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package handler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	dwv2 "github.com/devfile/api/v2/pkg/apis/workspaces/v1alpha2"
+	admissionv1 "k8s.io/api/admission/v1"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	"github.com/devfile/devworkspace-operator/pkg/provision/storage"
+)
+
+// ValidateStorageTypeChange checks that changes to the storage type of a DevWorkspace can be handled by migrating
+// the DevWorkspace's data. The storage type can only be changed while the DevWorkspace is stopped.
+func (h *WebhookHandler) ValidateStorageTypeChange(_ context.Context, req admission.Request) admission.Response {
+	if req.Operation != admissionv1.Update {
+		return admission.Allowed("Storage type is not changed")
+	}
+	wksp := &dwv2.DevWorkspace{}
+	if err := h.Decoder.Decode(req, wksp); err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+	oldWksp := &dwv2.DevWorkspace{}
+	if err := h.Decoder.DecodeRaw(req.OldObject, oldWksp); err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+
+	oldStorageType := storage.GetStorageType(oldWksp)
+	newStorageType := storage.GetStorageType(wksp)
+	if oldStorageType == newStorageType {
+		return admission.Allowed("Storage type is not changed")
+	}
+	if oldWksp.Spec.Started {
+		return admission.Denied(fmt.Sprintf("cannot change storage type from %s to %s while DevWorkspace is running; stop the DevWorkspace first", oldStorageType, newStorageType))
+	}
+	if err := storage.CheckMigration(oldStorageType, newStorageType); err != nil {
+		return admission.Denied(fmt.Sprintf("cannot change storage type: %s", err))
+	}
+	return admission.Allowed("Storage type change can be migrated")
+}
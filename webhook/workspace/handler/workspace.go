@@ -25,6 +25,7 @@ import (
 
 	dwv1 "github.com/devfile/api/v2/pkg/apis/workspaces/v1alpha1"
 	dwv2 "github.com/devfile/api/v2/pkg/apis/workspaces/v1alpha2"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
 )
 
@@ -36,6 +37,7 @@ func (h *WebhookHandler) MutateWorkspaceV1alpha1OnCreate(_ context.Context, req
 	}
 
 	wksp.Labels = maputils.Append(wksp.Labels, constants.DevWorkspaceCreatorLabel, req.UserInfo.UID)
+	wksp.Annotations = maputils.Append(wksp.Annotations, constants.DevWorkspaceCreatorUsernameAnnotation, req.UserInfo.Username)
 
 	return h.returnPatched(req, wksp)
 }
@@ -48,6 +50,7 @@ func (h *WebhookHandler) MutateWorkspaceV1alpha2OnCreate(_ context.Context, req
 	}
 
 	wksp.Labels = maputils.Append(wksp.Labels, constants.DevWorkspaceCreatorLabel, req.UserInfo.UID)
+	wksp.Annotations = maputils.Append(wksp.Annotations, constants.DevWorkspaceCreatorUsernameAnnotation, req.UserInfo.Username)
 
 	return h.returnPatched(req, wksp)
 }
@@ -75,6 +78,7 @@ func (h *WebhookHandler) MutateWorkspaceV1alpha1OnUpdate(_ context.Context, req
 			newWksp.Labels = map[string]string{}
 		}
 		newWksp.Labels[constants.DevWorkspaceCreatorLabel] = oldCreator
+		preserveCreatorUsernameAnnotation(&oldWksp.ObjectMeta, &newWksp.ObjectMeta)
 		return h.returnPatched(req, newWksp)
 	}
 
@@ -82,6 +86,10 @@ func (h *WebhookHandler) MutateWorkspaceV1alpha1OnUpdate(_ context.Context, req
 		return admission.Denied(fmt.Sprintf("label '%s' is assigned once devworkspace is created and is immutable", constants.DevWorkspaceCreatorLabel))
 	}
 
+	if preserveCreatorUsernameAnnotation(&oldWksp.ObjectMeta, &newWksp.ObjectMeta) {
+		return h.returnPatched(req, newWksp)
+	}
+
 	return admission.Allowed("new devworkspace has the same devworkspace creator as old one")
 }
 
@@ -108,6 +116,7 @@ func (h *WebhookHandler) MutateWorkspaceV1alpha2OnUpdate(_ context.Context, req
 			newWksp.Labels = map[string]string{}
 		}
 		newWksp.Labels[constants.DevWorkspaceCreatorLabel] = oldCreator
+		preserveCreatorUsernameAnnotation(&oldWksp.ObjectMeta, &newWksp.ObjectMeta)
 		return h.returnPatched(req, newWksp)
 	}
 
@@ -115,5 +124,20 @@ func (h *WebhookHandler) MutateWorkspaceV1alpha2OnUpdate(_ context.Context, req
 		return admission.Denied(fmt.Sprintf("label '%s' is assigned once devworkspace is created and is immutable", constants.DevWorkspaceCreatorLabel))
 	}
 
+	if preserveCreatorUsernameAnnotation(&oldWksp.ObjectMeta, &newWksp.ObjectMeta) {
+		return h.returnPatched(req, newWksp)
+	}
+
 	return admission.Allowed("new workspace has the same devworkspace as old one")
 }
+
+// preserveCreatorUsernameAnnotation sets the creator username annotation on newMeta to its value in oldMeta, as it is
+// assigned once a devworkspace is created. Returns whether newMeta was modified.
+func preserveCreatorUsernameAnnotation(oldMeta, newMeta *metav1.ObjectMeta) bool {
+	oldUsername, found := oldMeta.Annotations[constants.DevWorkspaceCreatorUsernameAnnotation]
+	if !found || newMeta.Annotations[constants.DevWorkspaceCreatorUsernameAnnotation] == oldUsername {
+		return false
+	}
+	newMeta.Annotations = maputils.Append(newMeta.Annotations, constants.DevWorkspaceCreatorUsernameAnnotation, oldUsername)
+	return true
+}
@@ -41,7 +41,13 @@ func (v *ResourcesValidator) Handle(ctx context.Context, req admission.Request)
 		return v.ValidateExecOnConnect(ctx, req)
 	}
 	if req.Kind == handler.V1alpha2DevWorkspaceKind && (req.Operation == admissionv1.Create || req.Operation == admissionv1.Update) {
-		return v.ValidateDevfile(ctx, req)
+		if resp := v.ValidateDevfile(ctx, req); !resp.Allowed {
+			return resp
+		}
+		if resp := v.ValidateStorageTypeChange(ctx, req); !resp.Allowed {
+			return resp
+		}
+		return v.ValidateQuotas(ctx, req)
 	}
 
 	// Do not allow operation if the corresponding handler is not found